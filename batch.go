@@ -0,0 +1,386 @@
+package dbgen
+
+import (
+	"math"
+
+	"github.com/gozssky/dbgen/constant"
+	"github.com/gozssky/dbgen/template"
+)
+
+// VectorKind is the representation of the values of a Vector.
+type VectorKind uint8
+
+const (
+	// VectorValues stores boxed values.
+	VectorValues VectorKind = iota
+	// VectorInt64 stores 64-bit integers, none of which is NULL.
+	VectorInt64
+	// VectorFloat64 stores floats, none of which is NULL.
+	VectorFloat64
+)
+
+// Vector is a column of the values of an expression for a batch of rows.
+// Only the slice matching Kind is set.
+type Vector struct {
+	Kind   VectorKind
+	Ints   []int64
+	Floats []float64
+	Values []constant.Value
+}
+
+// Len returns the number of rows of the vector.
+func (v *Vector) Len() int {
+	switch v.Kind {
+	case VectorInt64:
+		return len(v.Ints)
+	case VectorFloat64:
+		return len(v.Floats)
+	default:
+		return len(v.Values)
+	}
+}
+
+// Value returns the value of the i-th row.
+func (v *Vector) Value(i int) constant.Value {
+	switch v.Kind {
+	case VectorInt64:
+		return constant.MakeInt64(v.Ints[i])
+	case VectorFloat64:
+		return constant.MakeFloat(v.Floats[i])
+	default:
+		return v.Values[i]
+	}
+}
+
+// Datum returns the value of the i-th row as a datum.
+func (v *Vector) Datum(i int) constant.Datum {
+	switch v.Kind {
+	case VectorInt64:
+		return constant.MakeInt64Datum(v.Ints[i])
+	case VectorFloat64:
+		return constant.MakeFloatDatum(v.Floats[i])
+	default:
+		return constant.DatumOf(v.Values[i])
+	}
+}
+
+// BatchCompiled is a compiled expression that can evaluate a batch of rows at
+// once. It is only used for expressions without side effects, since the rows
+// are not evaluated in order.
+type BatchCompiled interface {
+	Compiled
+	// EvalBatch evaluates the expression for n rows numbered from
+	// state.RowNum.
+	EvalBatch(state *State, n int) (*Vector, error)
+}
+
+// batchFunction is a pure function with vectorized implementations for some
+// kinds of arguments. callBatch reports false if there is none for args.
+type batchFunction interface {
+	callBatch(args []*Vector) (*Vector, bool)
+}
+
+// EvalBatch evaluates n rows numbered from state.RowNum and returns their
+// columns. Columns without side effects are evaluated a whole column at a
+// time. The others are evaluated row by row in their order, so variables and
+// random numbers see the same sequence as with Eval.
+func (r Row) EvalBatch(state *State, n int) ([]*Vector, error) {
+	columns := make([]*Vector, len(r))
+	var impure []int
+	for i, compiled := range r {
+		if !isPure(compiled) {
+			impure = append(impure, i)
+			columns[i] = &Vector{Values: make([]constant.Value, n)}
+			continue
+		}
+		column, err := evalBatch(compiled, state, n)
+		if err != nil {
+			return nil, err
+		}
+		columns[i] = column
+	}
+	if len(impure) == 0 {
+		return columns, nil
+	}
+	first := state.RowNum
+	defer func() { state.RowNum = first }()
+	for row := 0; row < n; row++ {
+		state.RowNum = first + int64(row)
+		for _, i := range impure {
+			value, err := r[i].Eval(state)
+			if err != nil {
+				return nil, err
+			}
+			columns[i].Values[row] = value
+		}
+	}
+	return columns, nil
+}
+
+// isPure reports whether a compiled expression has no side effects and only
+// depends on the row number, so its rows can be evaluated in any order.
+func isPure(compiled Compiled) bool {
+	switch c := compiled.(type) {
+	case *RowNum, *SubRowNum, *Constant:
+		return true
+	case *FunctionCall:
+		return allPure(c.Args)
+	case *Logical:
+		return isPure(c.Left) && isPure(c.Right)
+	case *Coalesce:
+		return allPure(c.Args)
+	case *CaseValueWhen:
+		if c.Value != nil && !isPure(c.Value) {
+			return false
+		}
+		for _, when := range c.Whens {
+			if !isPure(when.Cond) || !isPure(when.Then) {
+				return false
+			}
+		}
+		// A CASE without ELSE is NULL when no WHEN matches.
+		return c.Else == nil || isPure(c.Else)
+	default:
+		return false
+	}
+}
+
+func allPure(compiled []Compiled) bool {
+	for _, c := range compiled {
+		if !isPure(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// evalBatch evaluates an expression for n rows numbered from state.RowNum,
+// falling back to evaluating them one at a time if it cannot be batched.
+func evalBatch(compiled Compiled, state *State, n int) (*Vector, error) {
+	if batch, ok := compiled.(BatchCompiled); ok {
+		return batch.EvalBatch(state, n)
+	}
+	first := state.RowNum
+	defer func() { state.RowNum = first }()
+	values := make([]constant.Value, n)
+	for i := range values {
+		state.RowNum = first + int64(i)
+		value, err := compiled.Eval(state)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return &Vector{Values: values}, nil
+}
+
+func (*RowNum) EvalBatch(state *State, n int) (*Vector, error) {
+	ints := make([]int64, n)
+	for i := range ints {
+		ints[i] = state.RowNum + int64(i)
+	}
+	return &Vector{Kind: VectorInt64, Ints: ints}, nil
+}
+
+func (*SubRowNum) EvalBatch(state *State, n int) (*Vector, error) {
+	ints := make([]int64, n)
+	for i := range ints {
+		ints[i] = state.SubRowNum
+	}
+	return &Vector{Kind: VectorInt64, Ints: ints}, nil
+}
+
+func (c *Constant) EvalBatch(_ *State, n int) (*Vector, error) {
+	switch c.Value.Kind() {
+	case constant.KindInt:
+		if constant.IsInt64(c.Value) {
+			x, _ := constant.AsInt64(c.Value)
+			ints := make([]int64, n)
+			for i := range ints {
+				ints[i] = x
+			}
+			return &Vector{Kind: VectorInt64, Ints: ints}, nil
+		}
+	case constant.KindFloat:
+		x, _ := constant.AsFloat(c.Value)
+		floats := make([]float64, n)
+		for i := range floats {
+			floats[i] = x
+		}
+		return &Vector{Kind: VectorFloat64, Floats: floats}, nil
+	}
+	values := make([]constant.Value, n)
+	for i := range values {
+		values[i] = c.Value
+	}
+	return &Vector{Values: values}, nil
+}
+
+func (f *FunctionCall) EvalBatch(state *State, n int) (*Vector, error) {
+	args := make([]*Vector, len(f.Args))
+	for i, arg := range f.Args {
+		var err error
+		if args[i], err = evalBatch(arg, state, n); err != nil {
+			return nil, err
+		}
+	}
+	if fn, ok := f.Fn.(batchFunction); ok {
+		if result, ok := fn.callBatch(args); ok {
+			return result, nil
+		}
+	}
+	values := make([]constant.Value, n)
+	for i := range values {
+		for j, arg := range args {
+			f.values[j] = arg.Value(i)
+		}
+		value, err := f.Fn.Call(state, f.values)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return &Vector{Values: values}, nil
+}
+
+func (a ArithFunc) callBatch(args []*Vector) (*Vector, bool) {
+	x, y := args[0], args[1]
+	if x.Kind == VectorInt64 && y.Kind == VectorInt64 {
+		if a.FastOp == template.OpFloatDiv {
+			return floatBatch(a.FastOp, x, y)
+		}
+		return intBatch(a.FastOp, x.Ints, y.Ints)
+	}
+	if x.Kind == VectorValues || y.Kind == VectorValues {
+		return nil, false
+	}
+	return floatBatch(a.FastOp, x, y)
+}
+
+// intBatch applies an arithmetic operator to integers. It reports false on
+// overflow, which the generic path promotes to a big integer.
+func intBatch(op template.Op, x, y []int64) (*Vector, bool) {
+	result := make([]int64, len(x))
+	var ok bool
+	switch op {
+	case template.OpAdd:
+		for i, a := range x {
+			if result[i], ok = addInt64(a, y[i]); !ok {
+				return nil, false
+			}
+		}
+	case template.OpSub:
+		for i, a := range x {
+			if result[i], ok = subInt64(a, y[i]); !ok {
+				return nil, false
+			}
+		}
+	case template.OpMul:
+		for i, a := range x {
+			if result[i], ok = mulInt64(a, y[i]); !ok {
+				return nil, false
+			}
+		}
+	default:
+		return nil, false
+	}
+	return &Vector{Kind: VectorInt64, Ints: result}, true
+}
+
+// addInt64, subInt64 and mulInt64 report false on overflow.
+
+func addInt64(a, b int64) (int64, bool) {
+	sum := a + b
+	return sum, (sum > a) == (b > 0)
+}
+
+func subInt64(a, b int64) (int64, bool) {
+	diff := a - b
+	return diff, (diff < a) == (b > 0)
+}
+
+func mulInt64(a, b int64) (int64, bool) {
+	prod := a * b
+	return prod, a == 0 || prod/a == b && !(a == -1 && b == math.MinInt64)
+}
+
+// floatBatch applies an arithmetic operator to numbers, at least one of
+// which is a float or which are divided. It reports false on division by
+// zero, which the generic path reports as an error.
+func floatBatch(op template.Op, x, y *Vector) (*Vector, bool) {
+	xs, ys := asFloats(x), asFloats(y)
+	result := make([]float64, len(xs))
+	switch op {
+	case template.OpAdd:
+		for i, a := range xs {
+			result[i] = a + ys[i]
+		}
+	case template.OpSub:
+		for i, a := range xs {
+			result[i] = a - ys[i]
+		}
+	case template.OpMul:
+		for i, a := range xs {
+			result[i] = a * ys[i]
+		}
+	case template.OpFloatDiv:
+		for i, a := range xs {
+			if ys[i] == 0 {
+				return nil, false
+			}
+			result[i] = a / ys[i]
+		}
+	default:
+		return nil, false
+	}
+	return &Vector{Kind: VectorFloat64, Floats: result}, true
+}
+
+// asFloats returns the values of an integer or float vector as floats.
+func asFloats(v *Vector) []float64 {
+	if v.Kind == VectorFloat64 {
+		return v.Floats
+	}
+	floats := make([]float64, len(v.Ints))
+	for i, x := range v.Ints {
+		floats[i] = float64(x)
+	}
+	return floats
+}
+
+func (c CompareFunc) callBatch(args []*Vector) (*Vector, bool) {
+	x, y := args[0], args[1]
+	if x.Kind != y.Kind || x.Kind == VectorValues {
+		return nil, false
+	}
+	values := make([]constant.Value, x.Len())
+	for i := range values {
+		var cmp int
+		if x.Kind == VectorInt64 {
+			cmp = compareOrdered(x.Ints[i], y.Ints[i])
+		} else {
+			cmp = compareOrdered(x.Floats[i], y.Floats[i])
+		}
+		switch cmp {
+		case -1:
+			values[i] = constant.MakeBool(c.LT)
+		case 0:
+			values[i] = constant.MakeBool(c.EQ)
+		default:
+			values[i] = constant.MakeBool(c.GT)
+		}
+	}
+	return &Vector{Values: values}, true
+}
+
+// compareOrdered compares numbers like constant.Cmp.
+func compareOrdered[T int64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
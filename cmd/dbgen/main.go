@@ -1,4 +1,200 @@
 package main
 
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gozssky/dbgen"
+	"github.com/gozssky/dbgen/constant"
+	"github.com/gozssky/dbgen/template"
+)
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(check(os.Args[2:]))
+	}
+	os.Exit(generate(os.Args[1:]))
+}
+
+// generate generates the rows of a template into a CSV file per table. It
+// returns the exit status.
+func generate(args []string) int {
+	flags := flag.NewFlagSet("dbgen", flag.ContinueOnError)
+	input := flags.String("i", "", "the template file")
+	outDir := flags.String("o", ".", "the directory of the generated files")
+	rows := flags.Int64("N", 1, "the number of rows of each table which is not derived from another one")
+	seed := flags.Int64("s", 0, "the seed of the random number generator, random if 0")
+	var onError dbgen.ErrorPolicy
+	flags.Var(&onError, "on-error", "what to do when a row fails to generate: abort, null or skip-row")
+	stats := flags.String("stats", "", "collect statistics on the generated values: table prints them, json writes them to stats.json in the output directory")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: dbgen -i TEMPLATE [flags]\n       dbgen check TEMPLATE...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *input == "" || flags.NArg() > 0 || *stats != "" && *stats != "table" && *stats != "json" {
+		flags.Usage()
+		return 2
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	g := &dbgen.Generator{OnError: onError}
+	if *stats != "" {
+		g.Stats = &dbgen.Stats{}
+	}
+	err := run(g, *input, *outDir, *rows, *seed)
+	if g.Errors.Total > 0 {
+		fmt.Fprint(os.Stderr, g.Errors.String())
+	}
+	if err == nil {
+		switch *stats {
+		case "table":
+			err = g.Stats.WriteTable(os.Stdout)
+		case "json":
+			err = writeStatsJSON(g.Stats, filepath.Join(*outDir, "stats.json"))
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// run compiles the template and generates n rows with g into outDir.
+func run(g *dbgen.Generator, path, outDir string, n, seed int64) error {
+	input, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.Parse(string(input))
+	if err != nil {
+		return err
+	}
+	ctx := dbgen.NewCompileContext()
+	compiled, err := ctx.CompileTemplate(tmpl)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return err
+	}
+	files := make(map[*dbgen.Table]*csvFile)
+	g.Template = compiled
+	g.State = &dbgen.State{
+		Rng:        rand.NewSource(seed).(rand.Source64),
+		CompileCtx: ctx,
+	}
+	g.EmitDatums = func(table *dbgen.Table, datums []constant.Datum) error {
+		f, ok := files[table]
+		if !ok {
+			var err error
+			if f, err = createCSVFile(filepath.Join(outDir, table.Name.UniqueName()+".csv")); err != nil {
+				return err
+			}
+			files[table] = f
+		}
+		return f.write(datums)
+	}
+	if err = g.Init(); err == nil {
+		err = g.Generate(1, n)
+	}
+	for _, f := range files {
+		if closeErr := f.close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// writeStatsJSON writes the statistics to a JSON file.
+func writeStatsJSON(stats *dbgen.Stats, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = stats.WriteJSON(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// csvFile is a CSV file of the rows of a table.
+type csvFile struct {
+	file *os.File
+	w    *dbgen.CSVWriter
+}
+
+func createCSVFile(path string) (*csvFile, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &csvFile{file: file, w: dbgen.NewCSVWriter(file)}, nil
+}
+
+// write writes a row.
+func (f *csvFile) write(datums []constant.Datum) error {
+	for i, datum := range datums {
+		if i > 0 {
+			if err := f.w.WriteValueSeparator(); err != nil {
+				return err
+			}
+		}
+		if err := f.w.WriteDatum(datum); err != nil {
+			return err
+		}
+	}
+	return f.w.WriteRowSeparator()
+}
+
+func (f *csvFile) close() error {
+	err := f.w.Flush()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// check type checks and lints the given template files, printing the
+// problems found. It returns the exit status.
+func check(paths []string) int {
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: dbgen check TEMPLATE...")
+		return 2
+	}
+	status := 0
+	for _, path := range paths {
+		input, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		tmpl, err := template.Parse(string(input))
+		if err != nil {
+			fmt.Printf("%s: %v\n", path, err)
+			status = 1
+			continue
+		}
+		if err := dbgen.CheckTypes(tmpl); err != nil {
+			for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+				fmt.Printf("%s: %v\n", path, err)
+			}
+			status = 1
+		}
+		for _, issue := range dbgen.Lint(tmpl) {
+			fmt.Printf("%s: %s\n", path, issue)
+			status = 1
+		}
+	}
+	return status
 }
@@ -0,0 +1,315 @@
+package constant
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type is a SQL data type that values can be converted to with Convert.
+type Type struct {
+	Kind Kind
+	// Bits is the size of an integer type (16, 32 or 64) or a float type
+	// (32 or 64). Zero means 64.
+	Bits int
+	// Length is the maximum number of characters of a string type, or zero
+	// if it is unbounded.
+	Length int
+	// Binary marks the BYTEA type, which only accepts strings.
+	Binary bool
+	// Precision is the maximum number of digits of a decimal type, or zero
+	// if it is unconstrained. Scale is its number of digits after the point.
+	Precision, Scale int
+}
+
+func (t Type) String() string {
+	switch t.Kind {
+	case KindBool:
+		return "BOOLEAN"
+	case KindBytes:
+		switch {
+		case t.Binary:
+			return "BYTEA"
+		case t.Length > 0:
+			return "VARCHAR(" + strconv.Itoa(t.Length) + ")"
+		default:
+			return "TEXT"
+		}
+	case KindInt:
+		switch t.Bits {
+		case 16:
+			return "SMALLINT"
+		case 32:
+			return "INTEGER"
+		default:
+			return "BIGINT"
+		}
+	case KindFloat:
+		if t.Bits == 32 {
+			return "REAL"
+		}
+		return "DOUBLE PRECISION"
+	case KindDecimal:
+		if t.Precision > 0 {
+			return "DECIMAL(" + strconv.Itoa(t.Precision) + ", " + strconv.Itoa(t.Scale) + ")"
+		}
+		return "DECIMAL"
+	case KindTimestamp:
+		return "TIMESTAMP"
+	case KindDate:
+		return "DATE"
+	case KindTime:
+		return "TIME"
+	case KindInterval:
+		return "INTERVAL"
+	case KindJSON:
+		return "JSON"
+	default:
+		return strings.ToUpper(t.Kind.String())
+	}
+}
+
+var errOutOfRange = errors.New("value out of range")
+
+// The layouts of strings converted to timestamps, dates and times. Seconds
+// may have a fraction.
+var (
+	timestampLayouts = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02"}
+	dateLayouts      = []string{"2006-01-02", "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04"}
+	timeLayouts      = []string{"15:04:05", "15:04"}
+)
+
+// Convert converts a value to a type with the semantics of SQL CAST:
+//
+//   - NULL converts to NULL of any type.
+//   - Strings are parsed as literals of the type, ignoring surrounding
+//     spaces, and every value converts to a string of its text. A string
+//     longer than a VARCHAR is truncated.
+//   - Numbers convert to each other, rounding to the nearest value. Floats
+//     round half to even and decimals half away from zero. A number that
+//     does not fit the type is an error.
+//   - Integers convert to booleans, which are true if non-zero, and back.
+//   - Dates and timestamps convert to each other, as do timestamps and
+//     times, and times and intervals.
+//
+// loc is the time zone of timestamps parsed from strings or converted from
+// dates. Conversions that are not allowed return a *ConvertError.
+func Convert(v Value, t Type, loc *time.Location) (Value, error) {
+	if v == Null {
+		return Null, nil
+	}
+	result, err := convert(v, t, loc)
+	if err != nil || result == nil {
+		return nil, &ConvertError{From: v, To: t.String(), Cause: err}
+	}
+	return result, nil
+}
+
+// convert returns a nil value if the conversion is not allowed, or an error
+// explaining why it failed.
+func convert(v Value, t Type, loc *time.Location) (Value, error) {
+	var text string
+	if b, ok := v.(bytesVal); ok {
+		text = strings.TrimSpace(string(b))
+	}
+	switch t.Kind {
+	case KindBool:
+		switch v := v.(type) {
+		case boolVal:
+			return v, nil
+		case int64Val, intVal:
+			return MakeBool(Sign(v) != 0), nil
+		case bytesVal:
+			switch strings.ToLower(text) {
+			case "t", "true", "y", "yes", "on", "1":
+				return MakeBool(true), nil
+			case "f", "false", "n", "no", "off", "0":
+				return MakeBool(false), nil
+			}
+			return nil, errors.New("invalid boolean")
+		}
+	case KindInt:
+		var i *big.Int
+		switch v := v.(type) {
+		case boolVal:
+			i = new(big.Int)
+			if v {
+				i.SetInt64(1)
+			}
+		case int64Val, intVal:
+			i, _ = AsInt(v)
+		case floatVal:
+			f := math.RoundToEven(float64(v))
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return nil, errOutOfRange
+			}
+			i, _ = big.NewFloat(f).Int(nil)
+		case decimalVal:
+			i = v.val.round(0).Unscaled
+		case bytesVal:
+			var ok bool
+			if i, ok = new(big.Int).SetString(strings.TrimPrefix(text, "+"), 10); !ok {
+				return nil, errors.New("invalid integer")
+			}
+		default:
+			return nil, nil
+		}
+		bits := t.Bits
+		if bits == 0 {
+			bits = 64
+		}
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(bits-1))
+		if i.Cmp(new(big.Int).Neg(limit)) < 0 || i.Cmp(limit) >= 0 {
+			return nil, errOutOfRange
+		}
+		return MakeInt(i), nil
+	case KindFloat:
+		var f float64
+		switch v := v.(type) {
+		case floatVal, int64Val, intVal, decimalVal:
+			f, _ = AsFloat(v)
+		case bytesVal:
+			var err error
+			if f, err = strconv.ParseFloat(text, 64); err != nil {
+				if errors.Is(err, strconv.ErrRange) {
+					return nil, errOutOfRange
+				}
+				return nil, errors.New("invalid number")
+			}
+		default:
+			return nil, nil
+		}
+		if t.Bits == 32 {
+			f32 := float32(f)
+			if math.IsInf(float64(f32), 0) && !math.IsInf(f, 0) {
+				return nil, errOutOfRange
+			}
+			f = float64(f32)
+		}
+		return MakeFloat(f), nil
+	case KindDecimal:
+		var d Decimal
+		switch v := v.(type) {
+		case int64Val, intVal, decimalVal:
+			d, _ = AsDecimal(v)
+		case floatVal:
+			f := float64(v)
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				return nil, errOutOfRange
+			}
+			var err error
+			if d, err = ParseDecimal(strconv.FormatFloat(f, 'g', -1, 64)); err != nil {
+				return nil, err
+			}
+		case bytesVal:
+			var err error
+			if d, err = ParseDecimal(text); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, nil
+		}
+		if t.Precision > 0 {
+			d = d.round(t.Scale)
+			if d.Unscaled.Sign() != 0 && len(new(big.Int).Abs(d.Unscaled).String()) > t.Precision {
+				return nil, errOutOfRange
+			}
+		}
+		return decimalVal{d}, nil
+	case KindBytes:
+		if b, ok := v.(bytesVal); ok {
+			return truncateChars(b, t.Length), nil
+		}
+		if t.Binary {
+			return nil, nil
+		}
+		return truncateChars(bytesVal(v.String()), t.Length), nil
+	case KindTimestamp:
+		switch v := v.(type) {
+		case timestampVal:
+			return v, nil
+		case dateVal:
+			year, month, day := v.val.Date()
+			return MakeTimestamp(time.Date(year, month, day, 0, 0, 0, 0, loc)), nil
+		case bytesVal:
+			for _, layout := range timestampLayouts {
+				if ts, err := time.ParseInLocation(layout, text, loc); err == nil {
+					return MakeTimestamp(ts), nil
+				}
+			}
+			return nil, errors.New("invalid timestamp")
+		}
+	case KindDate:
+		switch v := v.(type) {
+		case dateVal:
+			return v, nil
+		case timestampVal:
+			return MakeDate(v.val), nil
+		case bytesVal:
+			for _, layout := range dateLayouts {
+				if ts, err := time.Parse(layout, text); err == nil {
+					return MakeDate(ts), nil
+				}
+			}
+			return nil, errors.New("invalid date")
+		}
+	case KindTime:
+		switch v := v.(type) {
+		case timeVal:
+			return v, nil
+		case timestampVal:
+			return MakeTimeOfDay(sinceMidnight(v.val)), nil
+		case intervalVal:
+			return MakeTimeOfDay(time.Duration(v.val.Microseconds) * time.Microsecond), nil
+		case bytesVal:
+			for _, layout := range timeLayouts {
+				if ts, err := time.Parse(layout, text); err == nil {
+					return MakeTimeOfDay(sinceMidnight(ts)), nil
+				}
+			}
+			return nil, errors.New("invalid time")
+		}
+	case KindInterval:
+		switch v := v.(type) {
+		case intervalVal:
+			return v, nil
+		case timeVal:
+			return MakeInterval(v.val), nil
+		case bytesVal:
+			i, err := ParseInterval(text)
+			if err != nil {
+				return nil, err
+			}
+			return intervalVal{i}, nil
+		}
+	case KindJSON:
+		if _, ok := v.(bytesVal); ok {
+			return MakeJSON([]byte(text))
+		}
+		return ToJSON(v)
+	}
+	return nil, nil
+}
+
+// sinceMidnight returns the time elapsed since midnight of the day of t.
+func sinceMidnight(t time.Time) time.Duration {
+	hour, minute, sec := t.Clock()
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(sec)*time.Second + time.Duration(t.Nanosecond())
+}
+
+// truncateChars truncates a string to at most n characters, if n is positive.
+func truncateChars(b bytesVal, n int) bytesVal {
+	if n <= 0 {
+		return b
+	}
+	for i := range string(b) {
+		if n == 0 {
+			return b[:i]
+		}
+		n--
+	}
+	return b
+}
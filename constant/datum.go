@@ -0,0 +1,142 @@
+package constant
+
+import (
+	"math"
+	"strconv"
+)
+
+// Datum is a compact representation of a value for the hot path of
+// generation. NULLs, booleans, 64-bit integers, floats and strings are
+// stored inline, so making them does not allocate. Other values are kept
+// boxed as a Value.
+type Datum struct {
+	kind Kind
+	// num is the payload of a boolean, a 64-bit integer or the bits of a
+	// float.
+	num uint64
+	// bytes is the payload of a string.
+	bytes []byte
+	// boxed is any other value.
+	boxed Value
+}
+
+// NullDatum is the NULL datum, which is also the zero Datum.
+var NullDatum = Datum{}
+
+// MakeBoolDatum returns a boolean datum.
+func MakeBoolDatum(b bool) Datum {
+	d := Datum{kind: KindBool}
+	if b {
+		d.num = 1
+	}
+	return d
+}
+
+// MakeInt64Datum returns an integer datum.
+func MakeInt64Datum(i int64) Datum {
+	return Datum{kind: KindInt, num: uint64(i)}
+}
+
+// MakeFloatDatum returns a float datum.
+func MakeFloatDatum(f float64) Datum {
+	return Datum{kind: KindFloat, num: math.Float64bits(f)}
+}
+
+// MakeBytesDatum returns a string datum referencing b.
+func MakeBytesDatum(b []byte) Datum {
+	return Datum{kind: KindBytes, bytes: b}
+}
+
+// DatumOf converts a value to a datum without copying it.
+func DatumOf(v Value) Datum {
+	switch v := v.(type) {
+	case nullVal:
+		return NullDatum
+	case boolVal:
+		return MakeBoolDatum(bool(v))
+	case int64Val:
+		return MakeInt64Datum(int64(v))
+	case floatVal:
+		return MakeFloatDatum(float64(v))
+	case bytesVal:
+		return MakeBytesDatum(v)
+	default:
+		return Datum{kind: v.Kind(), boxed: v}
+	}
+}
+
+// Value converts a datum to a value. It allocates for inline integers,
+// floats and strings.
+func (d Datum) Value() Value {
+	if d.boxed != nil {
+		return d.boxed
+	}
+	switch d.kind {
+	case KindBool:
+		return boolVal(d.num != 0)
+	case KindInt:
+		return int64Val(d.num)
+	case KindFloat:
+		return floatVal(math.Float64frombits(d.num))
+	case KindBytes:
+		return bytesVal(d.bytes)
+	default:
+		return Null
+	}
+}
+
+func (d Datum) Kind() Kind {
+	return d.kind
+}
+
+// IsNull reports whether the datum is NULL.
+func (d Datum) IsNull() bool {
+	return d.kind == KindNull
+}
+
+// Int64 returns the integer of a datum, if it is an inline 64-bit integer.
+func (d Datum) Int64() (int64, bool) {
+	return int64(d.num), d.kind == KindInt && d.boxed == nil
+}
+
+// Float returns the float of a datum, if it is a float.
+func (d Datum) Float() (float64, bool) {
+	return math.Float64frombits(d.num), d.kind == KindFloat
+}
+
+// Bool returns the boolean of a datum, if it is a boolean.
+func (d Datum) Bool() (bool, bool) {
+	return d.num != 0, d.kind == KindBool
+}
+
+// Bytes returns the string of a datum, if it is a string.
+func (d Datum) Bytes() ([]byte, bool) {
+	return d.bytes, d.kind == KindBytes
+}
+
+// AppendText appends the text of the datum, as returned by the String method
+// of its value, to dst.
+func (d Datum) AppendText(dst []byte) []byte {
+	if d.boxed != nil {
+		return append(dst, d.boxed.String()...)
+	}
+	switch d.kind {
+	case KindBool:
+		if d.num != 0 {
+			return append(dst, "TRUE"...)
+		}
+		return append(dst, "FALSE"...)
+	case KindInt:
+		return strconv.AppendInt(dst, int64(d.num), 10)
+	case KindFloat:
+		return strconv.AppendFloat(dst, math.Float64frombits(d.num), 'g', -1, 64)
+	case KindBytes:
+		return append(dst, d.bytes...)
+	default:
+		return append(dst, "NULL"...)
+	}
+}
+
+func (d Datum) String() string {
+	return string(d.AppendText(nil))
+}
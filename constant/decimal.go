@@ -0,0 +1,177 @@
+package constant
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, equal to Unscaled × 10^-Scale.
+// The scale is the number of digits after the decimal point and is never
+// negative. Like in PostgreSQL, the scale is kept as is, so 12.30 and 12.3
+// compare equal but are rendered differently.
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int
+}
+
+const (
+	// MaxDecimalScale bounds the scale of decimals.
+	MaxDecimalScale = 1000
+	// minDivScale is the minimum scale of the result of a division.
+	minDivScale = 16
+)
+
+var errDecimalScaleOutOfRange = errors.New("decimal scale out of range")
+
+var bigTen = big.NewInt(10)
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// String formats the decimal with exactly Scale digits after the decimal point.
+func (d Decimal) String() string {
+	if d.Scale == 0 {
+		return d.Unscaled.String()
+	}
+	digits := new(big.Int).Abs(d.Unscaled).String()
+	if len(digits) <= d.Scale {
+		digits = strings.Repeat("0", d.Scale-len(digits)+1) + digits
+	}
+	point := len(digits) - d.Scale
+	s := digits[:point] + "." + digits[point:]
+	if d.Unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Float64 returns the nearest float64 value of the decimal.
+func (d Decimal) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(d.Unscaled, pow10(d.Scale)).Float64()
+	return f
+}
+
+// Rescale returns the unscaled value of the decimal at a scale not less
+// than its own.
+func (d Decimal) Rescale(scale int) *big.Int {
+	if scale == d.Scale {
+		return d.Unscaled
+	}
+	return new(big.Int).Mul(d.Unscaled, pow10(scale-d.Scale))
+}
+
+func (d Decimal) neg() Decimal {
+	return Decimal{new(big.Int).Neg(d.Unscaled), d.Scale}
+}
+
+func (d Decimal) cmp(e Decimal) int {
+	scale := maxInt(d.Scale, e.Scale)
+	return d.Rescale(scale).Cmp(e.Rescale(scale))
+}
+
+func (d Decimal) add(e Decimal) Decimal {
+	scale := maxInt(d.Scale, e.Scale)
+	return Decimal{new(big.Int).Add(d.Rescale(scale), e.Rescale(scale)), scale}
+}
+
+func (d Decimal) mul(e Decimal) (Decimal, error) {
+	scale := d.Scale + e.Scale
+	if scale > MaxDecimalScale {
+		return Decimal{}, errDecimalScaleOutOfRange
+	}
+	return Decimal{new(big.Int).Mul(d.Unscaled, e.Unscaled), scale}, nil
+}
+
+// div divides the decimals, rounding half away from zero. The scale of the
+// result is at least 16, so that e.g. 1/3 keeps a useful precision.
+func (d Decimal) div(e Decimal) (Decimal, error) {
+	if e.Unscaled.Sign() == 0 {
+		return Decimal{}, ErrDivideByZero
+	}
+	scale := maxInt(minDivScale, maxInt(d.Scale, e.Scale))
+	// d/e at the scale is d.Unscaled × 10^(scale - d.Scale + e.Scale) / e.Unscaled.
+	num := new(big.Int).Mul(d.Unscaled, pow10(scale-d.Scale+e.Scale))
+	q, r := new(big.Int).QuoRem(num, e.Unscaled, new(big.Int))
+	if new(big.Int).Lsh(new(big.Int).Abs(r), 1).CmpAbs(e.Unscaled) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign()*e.Unscaled.Sign())))
+	}
+	return Decimal{q, scale}, nil
+}
+
+// quo returns the quotient of the decimals truncated towards zero.
+func (d Decimal) quo(e Decimal) (*big.Int, error) {
+	if e.Unscaled.Sign() == 0 {
+		return nil, ErrDivideByZero
+	}
+	scale := maxInt(d.Scale, e.Scale)
+	return new(big.Int).Quo(d.Rescale(scale), e.Rescale(scale)), nil
+}
+
+// rem returns the remainder of the truncated division, with the sign of d.
+func (d Decimal) rem(e Decimal) (Decimal, error) {
+	if e.Unscaled.Sign() == 0 {
+		return Decimal{}, ErrDivideByZero
+	}
+	scale := maxInt(d.Scale, e.Scale)
+	return Decimal{new(big.Int).Rem(d.Rescale(scale), e.Rescale(scale)), scale}, nil
+}
+
+// round rounds the decimal to the scale, half away from zero.
+func (d Decimal) round(scale int) Decimal {
+	if scale >= d.Scale {
+		return Decimal{d.Rescale(scale), scale}
+	}
+	unit := pow10(d.Scale - scale)
+	q, r := new(big.Int).QuoRem(d.Unscaled, unit, new(big.Int))
+	if new(big.Int).Lsh(new(big.Int).Abs(r), 1).Cmp(unit) >= 0 {
+		q.Add(q, big.NewInt(int64(d.Unscaled.Sign())))
+	}
+	return Decimal{q, scale}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// ParseDecimal parses a decimal number such as `-12.30` or `1.5e3`.
+func ParseDecimal(s string) (Decimal, error) {
+	mantissa, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		mantissa = s[:i]
+		if exp, err = strconv.Atoi(s[i+1:]); err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal literal: %s", s)
+		}
+	}
+	scale := 0
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		scale = len(mantissa) - i - 1
+		mantissa = mantissa[:i] + mantissa[i+1:]
+	}
+	if mantissa == "" || mantissa == "-" || mantissa == "+" || strings.ContainsAny(mantissa[1:], "+-_") {
+		return Decimal{}, fmt.Errorf("invalid decimal literal: %s", s)
+	}
+	unscaled, ok := new(big.Int).SetString(mantissa, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal literal: %s", s)
+	}
+	scale -= exp
+	if scale < 0 {
+		if -scale > MaxDecimalScale {
+			return Decimal{}, errDecimalScaleOutOfRange
+		}
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	if scale > MaxDecimalScale {
+		return Decimal{}, errDecimalScaleOutOfRange
+	}
+	return Decimal{unscaled, scale}, nil
+}
@@ -0,0 +1,357 @@
+package constant
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Interval is a calendar-aware time span. Like in PostgreSQL, it keeps
+// months, days and microseconds separately, because the length of a month
+// or a day (across a daylight saving time change) depends on the timestamp
+// it is added to.
+type Interval struct {
+	Months       int64
+	Days         int64
+	Microseconds int64
+}
+
+const (
+	// daysPerMonth and microsecondsPerDay are the conversion factors used
+	// when intervals are compared or scaled, as in PostgreSQL.
+	daysPerMonth       = 30
+	microsecondsPerDay = 24 * 60 * 60 * 1e6
+)
+
+var errIntervalOutOfRange = errors.New("interval out of range")
+
+// DurationInterval returns the interval of the given duration, truncated to
+// microseconds.
+func DurationInterval(d time.Duration) Interval {
+	return Interval{Microseconds: int64(d / time.Microsecond)}
+}
+
+func (i Interval) neg() (Interval, error) {
+	if i.Months == math.MinInt64 || i.Days == math.MinInt64 || i.Microseconds == math.MinInt64 {
+		return Interval{}, errIntervalOutOfRange
+	}
+	return Interval{-i.Months, -i.Days, -i.Microseconds}, nil
+}
+
+// add adds each component of the intervals.
+func (i Interval) add(j Interval) (Interval, error) {
+	months, ok1 := addInt64(i.Months, j.Months)
+	days, ok2 := addInt64(i.Days, j.Days)
+	micros, ok3 := addInt64(i.Microseconds, j.Microseconds)
+	if !ok1 || !ok2 || !ok3 {
+		return Interval{}, errIntervalOutOfRange
+	}
+	return Interval{months, days, micros}, nil
+}
+
+// sub subtracts each component of the intervals.
+func (i Interval) sub(j Interval) (Interval, error) {
+	months, ok1 := subInt64(i.Months, j.Months)
+	days, ok2 := subInt64(i.Days, j.Days)
+	micros, ok3 := subInt64(i.Microseconds, j.Microseconds)
+	if !ok1 || !ok2 || !ok3 {
+		return Interval{}, errIntervalOutOfRange
+	}
+	return Interval{months, days, micros}, nil
+}
+
+// addInt64 returns a+b and reports whether it did not overflow.
+func addInt64(a, b int64) (int64, bool) {
+	c := a + b
+	return c, (c > a) == (b > 0)
+}
+
+// subInt64 returns a-b and reports whether it did not overflow.
+func subInt64(a, b int64) (int64, bool) {
+	c := a - b
+	return c, (c < a) == (b > 0)
+}
+
+// mulInt multiplies each component of the interval by n.
+func (i Interval) mulInt(n *big.Int) (Interval, error) {
+	var result [3]int64
+	for k, v := range [3]int64{i.Months, i.Days, i.Microseconds} {
+		p := new(big.Int).Mul(big.NewInt(v), n)
+		if !p.IsInt64() {
+			return Interval{}, errIntervalOutOfRange
+		}
+		result[k] = p.Int64()
+	}
+	return Interval{result[0], result[1], result[2]}, nil
+}
+
+// mulFloat multiplies the interval by f.
+func (i Interval) mulFloat(f float64) (Interval, error) {
+	return i.scale(func(v float64) float64 { return v * f })
+}
+
+// divFloat divides the interval by f.
+func (i Interval) divFloat(f float64) (Interval, error) {
+	return i.scale(func(v float64) float64 { return v / f })
+}
+
+// scale applies op to each component of the interval. Fractional months are
+// carried into days, and fractional days into microseconds.
+func (i Interval) scale(op func(float64) float64) (Interval, error) {
+	months := op(float64(i.Months))
+	days := op(float64(i.Days)) + (months-math.Trunc(months))*daysPerMonth
+	micros := op(float64(i.Microseconds)) + (days-math.Trunc(days))*microsecondsPerDay
+	for _, v := range [3]float64{months, days, micros} {
+		if math.IsNaN(v) || math.Abs(v) >= 1<<63 {
+			return Interval{}, errIntervalOutOfRange
+		}
+	}
+	return Interval{int64(months), int64(days), int64(math.Round(micros))}, nil
+}
+
+// approxMicroseconds returns the length of the interval in microseconds,
+// assuming 30-day months and 24-hour days.
+func (i Interval) approxMicroseconds() *big.Int {
+	days := new(big.Int).Mul(big.NewInt(i.Months), big.NewInt(daysPerMonth))
+	days.Add(days, big.NewInt(i.Days))
+	micros := days.Mul(days, big.NewInt(microsecondsPerDay))
+	return micros.Add(micros, big.NewInt(i.Microseconds))
+}
+
+func (i Interval) cmp(j Interval) int {
+	return i.approxMicroseconds().Cmp(j.approxMicroseconds())
+}
+
+// AddTo adds the interval to a timestamp. Months are added first, clamping
+// the day to the end of the resulting month, so that January 31 plus one
+// month is the last day of February. Then the days are added in the time
+// zone of the timestamp, and finally the microseconds, as an absolute
+// duration which may exceed the range of time.Duration.
+func (i Interval) AddTo(t time.Time) time.Time {
+	if i.Months != 0 {
+		year, month, day := t.Date()
+		hour, minute, sec := t.Clock()
+		totalMonths := int64(year)*12 + int64(month-1) + i.Months
+		year, month = int(floorDiv(totalMonths, 12)), time.Month(totalMonths-floorDiv(totalMonths, 12)*12+1)
+		if lastDay := daysIn(year, month); day > lastDay {
+			day = lastDay
+		}
+		t = time.Date(year, month, day, hour, minute, sec, t.Nanosecond(), t.Location())
+	}
+	if i.Days != 0 {
+		t = t.AddDate(0, 0, int(i.Days))
+	}
+	if i.Microseconds == 0 {
+		return t
+	}
+	secs, micros := i.Microseconds/1e6, i.Microseconds%1e6
+	return time.Unix(t.Unix()+secs, int64(t.Nanosecond())+micros*1e3).In(t.Location())
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// daysIn returns the number of days in the month.
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// String formats the interval like PostgreSQL, e.g. `1 year 2 mons 3 days 04:05:06.5`.
+func (i Interval) String() string {
+	var parts []string
+	plural := func(n int64, unit string) {
+		if n == 1 || n == -1 {
+			parts = append(parts, strconv.FormatInt(n, 10)+" "+unit)
+		} else if n != 0 {
+			parts = append(parts, strconv.FormatInt(n, 10)+" "+unit+"s")
+		}
+	}
+	plural(i.Months/12, "year")
+	plural(i.Months%12, "mon")
+	plural(i.Days, "day")
+	if i.Microseconds != 0 || len(parts) == 0 {
+		var sb strings.Builder
+		micros := i.Microseconds
+		if micros < 0 {
+			sb.WriteByte('-')
+		}
+		// Convert to unsigned to handle math.MinInt64.
+		abs := uint64(micros)
+		if micros < 0 {
+			abs = -abs
+		}
+		secs := abs / 1e6
+		sb.WriteString(padInt(secs/3600, 2))
+		sb.WriteByte(':')
+		sb.WriteString(padInt(secs/60%60, 2))
+		sb.WriteByte(':')
+		sb.WriteString(padInt(secs%60, 2))
+		if frac := abs % 1e6; frac != 0 {
+			sb.WriteByte('.')
+			sb.WriteString(strings.TrimRight(padInt(frac, 6), "0"))
+		}
+		parts = append(parts, sb.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+func padInt(n uint64, width int) string {
+	s := strconv.FormatUint(n, 10)
+	if len(s) < width {
+		s = strings.Repeat("0", width-len(s)) + s
+	}
+	return s
+}
+
+// intervalUnits maps the unit names accepted by ParseInterval to intervals.
+var intervalUnits = map[string]Interval{
+	"year":        {Months: 12},
+	"y":           {Months: 12},
+	"yr":          {Months: 12},
+	"quarter":     {Months: 3},
+	"month":       {Months: 1},
+	"mon":         {Months: 1},
+	"week":        {Days: 7},
+	"w":           {Days: 7},
+	"day":         {Days: 1},
+	"d":           {Days: 1},
+	"hour":        {Microseconds: 3600e6},
+	"h":           {Microseconds: 3600e6},
+	"hr":          {Microseconds: 3600e6},
+	"minute":      {Microseconds: 60e6},
+	"min":         {Microseconds: 60e6},
+	"m":           {Microseconds: 60e6},
+	"second":      {Microseconds: 1e6},
+	"sec":         {Microseconds: 1e6},
+	"s":           {Microseconds: 1e6},
+	"millisecond": {Microseconds: 1e3},
+	"msec":        {Microseconds: 1e3},
+	"ms":          {Microseconds: 1e3},
+	"microsecond": {Microseconds: 1},
+	"usec":        {Microseconds: 1},
+	"us":          {Microseconds: 1},
+}
+
+// ParseInterval parses an interval in the PostgreSQL format, which is a
+// sequence of quantities with units and an optional `[-]hh:mm[:ss[.ffffff]]`
+// time, optionally followed by `ago` to negate it. For example,
+// `1 year 2 mons 3 days 04:05:06.5` and `-1.5 hours`.
+func ParseInterval(s string) (Interval, error) {
+	invalid := fmt.Errorf("invalid interval: %q", s)
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) == 0 {
+		return Interval{}, invalid
+	}
+	negate := false
+	if fields[len(fields)-1] == "ago" {
+		negate = true
+		fields = fields[:len(fields)-1]
+	}
+	var result Interval
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if strings.Contains(field, ":") {
+			micros, ok := parseClock(field)
+			if !ok {
+				return Interval{}, invalid
+			}
+			var err error
+			if result, err = result.add(Interval{Microseconds: micros}); err != nil {
+				return Interval{}, err
+			}
+			continue
+		}
+		// The unit may be attached to the number, as in `3days`.
+		end := strings.IndexFunc(field, func(r rune) bool {
+			return !(r >= '0' && r <= '9' || r == '.' || r == '-' || r == '+')
+		})
+		number, unitName := field, ""
+		if end >= 0 {
+			number, unitName = field[:end], field[end:]
+		} else if i+1 < len(fields) {
+			i++
+			unitName = fields[i]
+		}
+		unit, ok := intervalUnits[unitName]
+		if !ok {
+			unit, ok = intervalUnits[strings.TrimSuffix(unitName, "s")]
+		}
+		if !ok {
+			return Interval{}, invalid
+		}
+		var part Interval
+		if n, err := strconv.ParseInt(number, 10, 64); err == nil {
+			if part, err = unit.mulInt(big.NewInt(n)); err != nil {
+				return Interval{}, err
+			}
+		} else if f, err := strconv.ParseFloat(number, 64); err == nil {
+			if part, err = unit.mulFloat(f); err != nil {
+				return Interval{}, err
+			}
+		} else {
+			return Interval{}, invalid
+		}
+		var err error
+		if result, err = result.add(part); err != nil {
+			return Interval{}, err
+		}
+	}
+	if negate {
+		return result.neg()
+	}
+	return result, nil
+}
+
+// parseClock parses `[-]hh:mm[:ss[.ffffff]]` as a number of microseconds.
+// Digits beyond microseconds are truncated.
+func parseClock(s string) (int64, bool) {
+	sign := int64(1)
+	if strings.HasPrefix(s, "-") {
+		sign, s = -1, s[1:]
+	} else {
+		s = strings.TrimPrefix(s, "+")
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, false
+	}
+	var frac string
+	if len(parts) == 3 {
+		if dot := strings.IndexByte(parts[2], '.'); dot >= 0 {
+			parts[2], frac = parts[2][:dot], parts[2][dot+1:]
+		}
+	}
+	var micros int64
+	for _, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return 0, false
+		}
+		micros = micros*60 + int64(n)
+	}
+	// `hh:mm` has no seconds.
+	for k := len(parts); k < 3; k++ {
+		micros *= 60
+	}
+	micros *= 1e6
+	if frac != "" {
+		if len(frac) > 6 {
+			frac = frac[:6]
+		}
+		n, err := strconv.ParseUint(frac+strings.Repeat("0", 6-len(frac)), 10, 32)
+		if err != nil {
+			return 0, false
+		}
+		micros += int64(n)
+	}
+	return sign * micros, true
+}
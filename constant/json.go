@@ -0,0 +1,143 @@
+package constant
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// MakeJSON returns a JSON value from its text. The text is validated and
+// stored in its compact form, keeping the order of object keys and the
+// exact text of numbers.
+func MakeJSON(text []byte) (Value, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, text); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return jsonVal(buf.Bytes()), nil
+}
+
+// MustMakeJSON is like MakeJSON but panics if the text is not valid JSON.
+func MustMakeJSON(text []byte) Value {
+	v, err := MakeJSON(text)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// AsJSON returns the compact text of a JSON value.
+func AsJSON(v Value) ([]byte, error) {
+	switch v := v.(type) {
+	case jsonVal:
+		return v, nil
+	default:
+		return nil, &ConvertError{From: v, To: "JSON"}
+	}
+}
+
+// ToJSON converts a value to JSON. Like PostgreSQL's to_json, numbers and
+// booleans map to their JSON counterparts, arrays to JSON arrays, NULL to
+// null and everything else to a JSON string of its text.
+func ToJSON(v Value) (Value, error) {
+	if v, ok := v.(jsonVal); ok {
+		return v, nil
+	}
+	text, err := AppendJSON(nil, v)
+	if err != nil {
+		return nil, err
+	}
+	return jsonVal(text), nil
+}
+
+// AppendJSON appends the JSON text of a value to dst.
+func AppendJSON(dst []byte, v Value) ([]byte, error) {
+	switch v := v.(type) {
+	case nullVal:
+		return append(dst, "null"...), nil
+	case boolVal:
+		return strconv.AppendBool(dst, bool(v)), nil
+	case int64Val, intVal, decimalVal:
+		return append(dst, v.String()...), nil
+	case floatVal:
+		f := float64(v)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			// JSON has no representation of these, so use strings as
+			// PostgreSQL does.
+			return appendJSONString(dst, formatSpecialFloat(f)), nil
+		}
+		return strconv.AppendFloat(dst, f, 'g', -1, 64), nil
+	case bytesVal:
+		return appendJSONString(dst, string(v)), nil
+	case timestampVal:
+		return appendJSONString(dst, v.val.Format("2006-01-02T15:04:05.999999")), nil
+	case dateVal, timeVal, intervalVal:
+		return appendJSONString(dst, v.String()), nil
+	case arrayVal:
+		dst = append(dst, '[')
+		for i, elem := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			var err error
+			if dst, err = AppendJSON(dst, elem); err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, ']'), nil
+	case jsonVal:
+		return append(dst, v...), nil
+	default:
+		return nil, &ConvertError{From: v, To: "JSON"}
+	}
+}
+
+func formatSpecialFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case f > 0:
+		return "Infinity"
+	default:
+		return "-Infinity"
+	}
+}
+
+// appendJSONString appends s as a JSON string. Unlike encoding/json, it does
+// not escape HTML characters. Invalid UTF-8 is replaced by U+FFFD.
+func appendJSONString(dst []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				dst = append(dst, '\\', c)
+			case c == '\n':
+				dst = append(dst, '\\', 'n')
+			case c == '\r':
+				dst = append(dst, '\\', 'r')
+			case c == '\t':
+				dst = append(dst, '\\', 't')
+			case c < 0x20:
+				dst = append(dst, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			default:
+				dst = append(dst, c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, "�"...)
+		} else {
+			dst = append(dst, s[i:i+size]...)
+		}
+		i += size
+	}
+	return append(dst, '"')
+}
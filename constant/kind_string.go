@@ -13,14 +13,18 @@ func _() {
 	_ = x[KindBytes-2]
 	_ = x[KindInt-3]
 	_ = x[KindFloat-4]
-	_ = x[KindTimestamp-5]
-	_ = x[KindInterval-6]
-	_ = x[KindArray-7]
+	_ = x[KindDecimal-5]
+	_ = x[KindTimestamp-6]
+	_ = x[KindDate-7]
+	_ = x[KindTime-8]
+	_ = x[KindInterval-9]
+	_ = x[KindArray-10]
+	_ = x[KindJSON-11]
 }
 
-const _Kind_name = "NullBoolBytesIntFloatTimestampIntervalArray"
+const _Kind_name = "NullBoolBytesIntFloatDecimalTimestampDateTimeIntervalArrayJSON"
 
-var _Kind_index = [...]uint8{0, 4, 8, 13, 16, 21, 30, 38, 43}
+var _Kind_index = [...]uint8{0, 4, 8, 13, 16, 21, 28, 37, 41, 45, 53, 58, 62}
 
 func (i Kind) String() string {
 	if i < 0 || i >= Kind(len(_Kind_index)-1) {
@@ -0,0 +1,46 @@
+package constant
+
+import "strings"
+
+// KindSet is a set of kinds, such as the possible kinds of the value of an
+// expression.
+type KindSet uint16
+
+// AnyKind is the set of all kinds.
+const AnyKind KindSet = 1<<(KindJSON+1) - 1
+
+// KindSetOf returns the set of the given kinds.
+func KindSetOf(kinds ...Kind) KindSet {
+	var s KindSet
+	for _, k := range kinds {
+		s |= 1 << k
+	}
+	return s
+}
+
+// Has reports whether the set contains k.
+func (s KindSet) Has(k Kind) bool {
+	return s&(1<<k) != 0
+}
+
+// Without returns the set without k.
+func (s KindSet) Without(k Kind) KindSet {
+	return s &^ (1 << k)
+}
+
+// String returns the kinds of the set separated by `|`.
+func (s KindSet) String() string {
+	if s == AnyKind {
+		return "Any"
+	}
+	var kinds []string
+	for k := KindNull; k <= KindJSON; k++ {
+		if s.Has(k) {
+			kinds = append(kinds, k.String())
+		}
+	}
+	if len(kinds) == 0 {
+		return "None"
+	}
+	return strings.Join(kinds, "|")
+}
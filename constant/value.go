@@ -24,9 +24,13 @@ const (
 	KindBytes
 	KindInt
 	KindFloat
+	KindDecimal
 	KindTimestamp
+	KindDate
+	KindTime
 	KindInterval
 	KindArray
+	KindJSON
 )
 
 type Value interface {
@@ -43,9 +47,13 @@ type (
 	intVal       struct{ val *big.Int }
 	int64Val     int64
 	floatVal     float64
+	decimalVal   struct{ val Decimal }
 	timestampVal struct{ val time.Time }
-	intervalVal  struct{ val time.Duration }
+	dateVal      struct{ val time.Time }
+	timeVal      struct{ val time.Duration }
+	intervalVal  struct{ val Interval }
 	arrayVal     []Value
+	jsonVal      []byte
 )
 
 func (nullVal) Kind() Kind      { return KindNull }
@@ -54,9 +62,13 @@ func (bytesVal) Kind() Kind     { return KindBytes }
 func (intVal) Kind() Kind       { return KindInt }
 func (int64Val) Kind() Kind     { return KindInt }
 func (floatVal) Kind() Kind     { return KindFloat }
+func (decimalVal) Kind() Kind   { return KindDecimal }
 func (timestampVal) Kind() Kind { return KindTimestamp }
+func (dateVal) Kind() Kind      { return KindDate }
+func (timeVal) Kind() Kind      { return KindTime }
 func (intervalVal) Kind() Kind  { return KindInterval }
 func (arrayVal) Kind() Kind     { return KindArray }
+func (jsonVal) Kind() Kind      { return KindJSON }
 
 func (nullVal) String() string {
 	return "NULL"
@@ -86,10 +98,22 @@ func (f floatVal) String() string {
 	return strconv.FormatFloat(float64(f), 'g', -1, 64)
 }
 
+func (d decimalVal) String() string {
+	return d.val.String()
+}
+
 func (t timestampVal) String() string {
 	return t.val.Format("2006-01-02 15:04:05.999")
 }
 
+func (d dateVal) String() string {
+	return d.val.Format("2006-01-02")
+}
+
+func (t timeVal) String() string {
+	return time.Time{}.Add(t.val).Format("15:04:05.999999")
+}
+
 func (i intervalVal) String() string {
 	return i.val.String()
 }
@@ -107,6 +131,10 @@ func (a arrayVal) String() string {
 	return b.String()
 }
 
+func (j jsonVal) String() string {
+	return string(j)
+}
+
 func MakeBool(b bool) Value {
 	return boolVal(b)
 }
@@ -130,17 +158,48 @@ func MakeFloat(f float64) Value {
 	return floatVal(f)
 }
 
+// MakeDecimal returns a decimal value. It panics if the scale is out of range.
+func MakeDecimal(d Decimal) Value {
+	if d.Scale < 0 || d.Scale > MaxDecimalScale {
+		panic(errDecimalScaleOutOfRange)
+	}
+	return decimalVal{d}
+}
+
 func MakeTimestamp(t time.Time) Value {
 	return timestampVal{t}
 }
 
+// MakeDate returns the date of t in its time zone.
+func MakeDate(t time.Time) Value {
+	year, month, day := t.Date()
+	return dateVal{time.Date(year, month, day, 0, 0, 0, 0, time.UTC)}
+}
+
+// MakeTimeOfDay returns a time of day. The duration since midnight is taken
+// modulo 24 hours and truncated to microseconds.
+func MakeTimeOfDay(d time.Duration) Value {
+	d %= 24 * time.Hour
+	if d < 0 {
+		d += 24 * time.Hour
+	}
+	return timeVal{d.Truncate(time.Microsecond)}
+}
+
+// MakeInterval returns an interval of the given duration, truncated to
+// microseconds.
 func MakeInterval(d time.Duration) Value {
-	return intervalVal{d}
+	return intervalVal{DurationInterval(d)}
+}
+
+// MakeCalendarInterval returns an interval with months and days.
+func MakeCalendarInterval(i Interval) Value {
+	return intervalVal{i}
 }
 
 func MakeArray(a []Value) Value {
 	if len(a) == 0 {
-		return arrayVal{nil}
+		return arrayVal{}
 	}
 	return arrayVal(a)
 }
@@ -166,21 +225,43 @@ func MakeNumberFromLiteral(s string) (Value, error) {
 	return MakeFloat(f), nil
 }
 
+// MakeExactNumberFromLiteral is like MakeNumberFromLiteral, but makes
+// decimals instead of floating-point numbers, so that e.g. 12.30 is exact.
+func MakeExactNumberFromLiteral(s string) (Value, error) {
+	if strings.IndexAny(s, ".eE") < 0 || strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return MakeNumberFromLiteral(s)
+	}
+	d, err := ParseDecimal(s)
+	if err != nil {
+		return nil, err
+	}
+	return decimalVal{d}, nil
+}
+
 type ConvertError struct {
 	From Value
 	To   string
+	// Cause optionally explains why the conversion failed.
+	Cause error
 }
 
 func (e *ConvertError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("cannot convert %s(%s) to %s: %v", e.From.Kind(), e.From.String(), e.To, e.Cause)
+	}
 	return fmt.Sprintf("cannot convert %s(%s) to %s", e.From.Kind(), e.From.String(), e.To)
 }
 
+func (e *ConvertError) Unwrap() error {
+	return e.Cause
+}
+
 func AsBool(v Value) (bool, error) {
 	switch v := v.(type) {
 	case boolVal:
 		return bool(v), nil
 	default:
-		return false, &ConvertError{v, "bool"}
+		return false, &ConvertError{From: v, To: "bool"}
 	}
 }
 
@@ -189,7 +270,7 @@ func AsBytes(v Value) ([]byte, error) {
 	case bytesVal:
 		return v, nil
 	default:
-		return nil, &ConvertError{v, "[]byte"}
+		return nil, &ConvertError{From: v, To: "[]byte"}
 	}
 }
 
@@ -209,13 +290,13 @@ func AsInt64(v Value) (int64, error) {
 	switch v := v.(type) {
 	case intVal:
 		if !v.val.IsInt64() {
-			return 0, &ConvertError{v, "int64"}
+			return 0, &ConvertError{From: v, To: "int64"}
 		}
 		return v.val.Int64(), nil
 	case int64Val:
 		return int64(v), nil
 	default:
-		return 0, &ConvertError{v, "int64"}
+		return 0, &ConvertError{From: v, To: "int64"}
 	}
 }
 
@@ -226,7 +307,7 @@ func AsInt(v Value) (*big.Int, error) {
 	case int64Val:
 		return big.NewInt(int64(v)), nil
 	default:
-		return nil, &ConvertError{v, "*big.Int"}
+		return nil, &ConvertError{From: v, To: "*big.Int"}
 	}
 }
 
@@ -239,8 +320,24 @@ func AsFloat(v Value) (float64, error) {
 		return f, nil
 	case int64Val:
 		return float64(v), nil
+	case decimalVal:
+		return v.val.Float64(), nil
+	default:
+		return 0, &ConvertError{From: v, To: "float64"}
+	}
+}
+
+// AsDecimal converts an integer or a decimal to a decimal.
+func AsDecimal(v Value) (Decimal, error) {
+	switch v := v.(type) {
+	case decimalVal:
+		return v.val, nil
+	case intVal:
+		return Decimal{v.val, 0}, nil
+	case int64Val:
+		return Decimal{big.NewInt(int64(v)), 0}, nil
 	default:
-		return 0, &ConvertError{v, "float64"}
+		return Decimal{}, &ConvertError{From: v, To: "Decimal"}
 	}
 }
 
@@ -249,16 +346,36 @@ func AsTimestamp(v Value) (time.Time, error) {
 	case timestampVal:
 		return v.val, nil
 	default:
-		return time.Time{}, &ConvertError{v, "time.Time"}
+		return time.Time{}, &ConvertError{From: v, To: "time.Time"}
 	}
 }
 
-func AsInterval(v Value) (time.Duration, error) {
+// AsDate returns a date as midnight UTC of that day.
+func AsDate(v Value) (time.Time, error) {
+	switch v := v.(type) {
+	case dateVal:
+		return v.val, nil
+	default:
+		return time.Time{}, &ConvertError{From: v, To: "date"}
+	}
+}
+
+// AsTimeOfDay returns a time of day as the duration since midnight.
+func AsTimeOfDay(v Value) (time.Duration, error) {
+	switch v := v.(type) {
+	case timeVal:
+		return v.val, nil
+	default:
+		return 0, &ConvertError{From: v, To: "time of day"}
+	}
+}
+
+func AsInterval(v Value) (Interval, error) {
 	switch v := v.(type) {
 	case intervalVal:
 		return v.val, nil
 	default:
-		return 0, &ConvertError{v, "time.Duration"}
+		return Interval{}, &ConvertError{From: v, To: "Interval"}
 	}
 }
 
@@ -267,7 +384,7 @@ func AsArray(v Value) ([]Value, error) {
 	case arrayVal:
 		return v, nil
 	default:
-		return nil, &ConvertError{v, "[]Value"}
+		return nil, &ConvertError{From: v, To: "[]Value"}
 	}
 }
 
@@ -279,13 +396,26 @@ func Sign(v Value) int {
 		return numberCmp(v, 0)
 	case floatVal:
 		return numberCmp(v, 0)
+	case decimalVal:
+		return v.val.Unscaled.Sign()
 	case intervalVal:
-		return numberCmp(v.val, 0)
+		return v.val.cmp(Interval{})
 	default:
 		return 1
 	}
 }
 
+// civilDays returns the number of days since 1970-01-01 of a date.
+func civilDays(date time.Time) int64 {
+	return floorDiv(date.Unix(), 24*60*60)
+}
+
+// addTimeOfDay adds microseconds to a time of day, wrapping around midnight.
+func addTimeOfDay(t time.Duration, micros int64) Value {
+	micros %= int64(24 * time.Hour / time.Microsecond)
+	return MakeTimeOfDay(t + time.Duration(micros)*time.Microsecond)
+}
+
 type CompareError struct {
 	Left, Right Value
 }
@@ -334,6 +464,12 @@ func Cmp(a, b Value) (_ int, isNull bool, retErr error) {
 				return 0, false, err
 			}
 			return a.val.Cmp(b), false, nil
+		case KindDecimal:
+			b, err := AsDecimal(b)
+			if err != nil {
+				return 0, false, err
+			}
+			return Decimal{a.val, 0}.cmp(b), false, nil
 		case KindFloat:
 			a1, _ := new(big.Float).SetInt(a.val).Float64()
 			b, err := AsFloat(b)
@@ -358,6 +494,12 @@ func Cmp(a, b Value) (_ int, isNull bool, retErr error) {
 				return 0, false, err
 			}
 			return a1.Cmp(b), false, nil
+		case KindDecimal:
+			b, err := AsDecimal(b)
+			if err != nil {
+				return 0, false, err
+			}
+			return Decimal{big.NewInt(int64(a)), 0}.cmp(b), false, nil
 		case KindFloat:
 			b, err := AsFloat(b)
 			if err != nil {
@@ -365,6 +507,19 @@ func Cmp(a, b Value) (_ int, isNull bool, retErr error) {
 			}
 			return numberCmp(float64(a), b), false, nil
 		}
+	case decimalVal:
+		if b.Kind() == KindFloat {
+			b, err := AsFloat(b)
+			if err != nil {
+				return 0, false, err
+			}
+			return numberCmp(a.val.Float64(), b), false, nil
+		}
+		b, err := AsDecimal(b)
+		if err != nil {
+			return 0, false, err
+		}
+		return a.val.cmp(b), false, nil
 	case floatVal:
 		b, err := AsFloat(b)
 		if err != nil {
@@ -372,17 +527,37 @@ func Cmp(a, b Value) (_ int, isNull bool, retErr error) {
 		}
 		return numberCmp(float64(a), b), false, nil
 	case timestampVal:
+		if d, ok := b.(dateVal); ok {
+			// The date is midnight UTC here; the comparison functions
+			// convert it to the session time zone first.
+			return a.val.Compare(d.val), false, nil
+		}
 		b, err := AsTimestamp(b)
 		if err != nil {
 			return 0, false, err
 		}
 		return a.val.Compare(b), false, nil
+	case dateVal:
+		if t, ok := b.(timestampVal); ok {
+			return a.val.Compare(t.val), false, nil
+		}
+		b, err := AsDate(b)
+		if err != nil {
+			return 0, false, err
+		}
+		return a.val.Compare(b), false, nil
+	case timeVal:
+		b, err := AsTimeOfDay(b)
+		if err != nil {
+			return 0, false, err
+		}
+		return numberCmp(a.val, b), false, nil
 	case intervalVal:
 		b, err := AsInterval(b)
 		if err != nil {
 			return 0, false, err
 		}
-		return numberCmp(a.val, b), false, nil
+		return a.val.cmp(b), false, nil
 	case arrayVal:
 		b, err := AsArray(b)
 		if err != nil {
@@ -400,6 +575,13 @@ func Cmp(a, b Value) (_ int, isNull bool, retErr error) {
 			}
 		}
 		return numberCmp(len(a), len(b)), false, nil
+	case jsonVal:
+		// JSON values compare by their compact text.
+		b, err := AsJSON(b)
+		if err != nil {
+			return 0, false, err
+		}
+		return bytes.Compare(a, b), false, nil
 	}
 	return 0, false, &CompareError{a, b}
 }
@@ -459,6 +641,12 @@ func Add(a, b Value) (_ Value, retErr error) {
 				return nil, err
 			}
 			return intVal{new(big.Int).Add(a.val, b)}, nil
+		case KindDecimal:
+			b, err := AsDecimal(b)
+			if err != nil {
+				return nil, err
+			}
+			return decimalVal{Decimal{a.val, 0}.add(b)}, nil
 		case KindFloat:
 			a1, _ := new(big.Float).SetInt(a.val).Float64()
 			b, err := AsFloat(b)
@@ -487,6 +675,12 @@ func Add(a, b Value) (_ Value, retErr error) {
 				return nil, err
 			}
 			return intVal{a1.Add(a1, b)}, nil
+		case KindDecimal:
+			b, err := AsDecimal(b)
+			if err != nil {
+				return nil, err
+			}
+			return decimalVal{Decimal{big.NewInt(int64(a)), 0}.add(b)}, nil
 		case KindFloat:
 			b, err := AsFloat(b)
 			if err != nil {
@@ -494,6 +688,19 @@ func Add(a, b Value) (_ Value, retErr error) {
 			}
 			return floatVal(float64(a) + b), nil
 		}
+	case decimalVal:
+		if b.Kind() == KindFloat {
+			b, err := AsFloat(b)
+			if err != nil {
+				return nil, err
+			}
+			return floatVal(a.val.Float64() + b), nil
+		}
+		b, err := AsDecimal(b)
+		if err != nil {
+			return nil, err
+		}
+		return decimalVal{a.val.add(b)}, nil
 	case floatVal:
 		b, err := AsFloat(b)
 		if err != nil {
@@ -505,17 +712,57 @@ func Add(a, b Value) (_ Value, retErr error) {
 		if err != nil {
 			return nil, err
 		}
-		return timestampVal{a.val.Add(b)}, nil
+		return timestampVal{b.AddTo(a.val)}, nil
+	case dateVal:
+		switch b.Kind() {
+		case KindInt:
+			days, err := AsInt64(b)
+			if err != nil {
+				return nil, err
+			}
+			return dateVal{a.val.AddDate(0, 0, int(days))}, nil
+		case KindTime:
+			// The date is midnight UTC here; the arithmetic functions convert
+			// it to the session time zone first.
+			b, err := AsTimeOfDay(b)
+			if err != nil {
+				return nil, err
+			}
+			return timestampVal{a.val.Add(b)}, nil
+		case KindInterval:
+			b, err := AsInterval(b)
+			if err != nil {
+				return nil, err
+			}
+			return timestampVal{b.AddTo(a.val)}, nil
+		}
+	case timeVal:
+		switch b.Kind() {
+		case KindDate:
+			return Add(b, a)
+		case KindInterval:
+			b, err := AsInterval(b)
+			if err != nil {
+				return nil, err
+			}
+			return addTimeOfDay(a.val, b.Microseconds), nil
+		}
 	case intervalVal:
-		t, ok := b.(timestampVal)
-		if ok {
-			return timestampVal{t.val.Add(a.val)}, nil
+		switch b := b.(type) {
+		case timestampVal:
+			return timestampVal{a.val.AddTo(b.val)}, nil
+		case dateVal, timeVal:
+			return Add(b, a)
 		}
 		b, err := AsInterval(b)
 		if err != nil {
 			return nil, err
 		}
-		return intervalVal{a.val + b}, nil
+		result, err := a.val.add(b)
+		if err != nil {
+			return nil, err
+		}
+		return intervalVal{result}, nil
 	}
 	return nil, MakeBinaryOpError("add", a, b, nil)
 }
@@ -542,6 +789,12 @@ func Sub(a, b Value) (_ Value, retErr error) {
 				return nil, err
 			}
 			return intVal{new(big.Int).Sub(a.val, b)}, nil
+		case KindDecimal:
+			b, err := AsDecimal(b)
+			if err != nil {
+				return nil, err
+			}
+			return decimalVal{Decimal{a.val, 0}.add(b.neg())}, nil
 		case KindFloat:
 			a1, _ := new(big.Float).SetInt(a.val).Float64()
 			b, err := AsFloat(b)
@@ -556,9 +809,9 @@ func Sub(a, b Value) (_ Value, retErr error) {
 			if err != nil {
 				return nil, err
 			}
-			sum := int64(a) + b
-			if (sum > int64(a)) == (b < 0) {
-				return int64Val(sum), nil
+			diff := int64(a) - b
+			if (diff < int64(a)) == (b > 0) {
+				return int64Val(diff), nil
 			}
 			// Overflow.
 		}
@@ -570,6 +823,12 @@ func Sub(a, b Value) (_ Value, retErr error) {
 				return nil, err
 			}
 			return intVal{a1.Sub(a1, b)}, nil
+		case KindDecimal:
+			b, err := AsDecimal(b)
+			if err != nil {
+				return nil, err
+			}
+			return decimalVal{Decimal{big.NewInt(int64(a)), 0}.add(b.neg())}, nil
 		case KindFloat:
 			b, err := AsFloat(b)
 			if err != nil {
@@ -577,6 +836,19 @@ func Sub(a, b Value) (_ Value, retErr error) {
 			}
 			return floatVal(float64(a) - b), nil
 		}
+	case decimalVal:
+		if b.Kind() == KindFloat {
+			b, err := AsFloat(b)
+			if err != nil {
+				return nil, err
+			}
+			return floatVal(a.val.Float64() - b), nil
+		}
+		b, err := AsDecimal(b)
+		if err != nil {
+			return nil, err
+		}
+		return decimalVal{a.val.add(b.neg())}, nil
 	case floatVal:
 		b, err := AsFloat(b)
 		if err != nil {
@@ -584,21 +856,67 @@ func Sub(a, b Value) (_ Value, retErr error) {
 		}
 		return a - floatVal(b), nil
 	case timestampVal:
+		if t, ok := b.(timestampVal); ok {
+			return intervalVal{DurationInterval(a.val.Sub(t.val))}, nil
+		}
 		b, err := AsInterval(b)
 		if err != nil {
 			return nil, err
 		}
-		return timestampVal{a.val.Add(-b)}, nil
-	case intervalVal:
-		t, ok := b.(timestampVal)
-		if ok {
-			return timestampVal{t.val.Add(-a.val)}, nil
+		if b, err = b.neg(); err != nil {
+			return nil, err
 		}
+		return timestampVal{b.AddTo(a.val)}, nil
+	case dateVal:
+		switch b.Kind() {
+		case KindInt:
+			days, err := AsInt64(b)
+			if err != nil {
+				return nil, err
+			}
+			return dateVal{a.val.AddDate(0, 0, -int(days))}, nil
+		case KindDate:
+			b, err := AsDate(b)
+			if err != nil {
+				return nil, err
+			}
+			return int64Val(civilDays(a.val) - civilDays(b)), nil
+		case KindInterval:
+			b, err := AsInterval(b)
+			if err != nil {
+				return nil, err
+			}
+			if b, err = b.neg(); err != nil {
+				return nil, err
+			}
+			return timestampVal{b.AddTo(a.val)}, nil
+		}
+	case timeVal:
+		switch b.Kind() {
+		case KindTime:
+			b, err := AsTimeOfDay(b)
+			if err != nil {
+				return nil, err
+			}
+			return intervalVal{DurationInterval(a.val - b)}, nil
+		case KindInterval:
+			b, err := AsInterval(b)
+			if err != nil {
+				return nil, err
+			}
+			// The remainder avoids negating math.MinInt64.
+			return addTimeOfDay(a.val, -(b.Microseconds % microsecondsPerDay)), nil
+		}
+	case intervalVal:
 		b, err := AsInterval(b)
 		if err != nil {
 			return nil, err
 		}
-		return intervalVal{a.val - b}, nil
+		result, err := a.val.sub(b)
+		if err != nil {
+			return nil, err
+		}
+		return intervalVal{result}, nil
 	}
 	return nil, MakeBinaryOpError("sub", a, b, nil)
 }
@@ -625,6 +943,16 @@ func Mul(a, b Value) (_ Value, retErr error) {
 				return nil, err
 			}
 			return intVal{new(big.Int).Mul(a.val, b)}, nil
+		case KindDecimal:
+			b, err := AsDecimal(b)
+			if err != nil {
+				return nil, err
+			}
+			result, err := Decimal{a.val, 0}.mul(b)
+			if err != nil {
+				return nil, err
+			}
+			return decimalVal{result}, nil
 		case KindFloat:
 			a1, _ := new(big.Float).SetInt(a.val).Float64()
 			b, err := AsFloat(b)
@@ -637,8 +965,11 @@ func Mul(a, b Value) (_ Value, retErr error) {
 			if err != nil {
 				return nil, err
 			}
-			result := new(big.Int).Mul(a.val, big.NewInt(int64(b)))
-			return intervalVal{time.Duration(result.Int64())}, nil
+			result, err := b.mulInt(a.val)
+			if err != nil {
+				return nil, err
+			}
+			return intervalVal{result}, nil
 		}
 	case int64Val:
 		if IsInt64(b) {
@@ -647,7 +978,7 @@ func Mul(a, b Value) (_ Value, retErr error) {
 				return nil, err
 			}
 			result := int64(a) * b
-			if a == 0 || b == 0 || a == 1 || b == 1 || (result/int64(a) == b) {
+			if a == 0 || b == 0 || a == 1 || b == 1 || (result/int64(a) == b && !(a == -1 && b == math.MinInt64)) {
 				return int64Val(result), nil
 			}
 			// Overflow.
@@ -660,6 +991,16 @@ func Mul(a, b Value) (_ Value, retErr error) {
 				return nil, err
 			}
 			return intVal{a1.Mul(a1, b)}, nil
+		case KindDecimal:
+			b, err := AsDecimal(b)
+			if err != nil {
+				return nil, err
+			}
+			result, err := Decimal{big.NewInt(int64(a)), 0}.mul(b)
+			if err != nil {
+				return nil, err
+			}
+			return decimalVal{result}, nil
 		case KindFloat:
 			b, err := AsFloat(b)
 			if err != nil {
@@ -671,15 +1012,51 @@ func Mul(a, b Value) (_ Value, retErr error) {
 			if err != nil {
 				return nil, err
 			}
-			return intervalVal{time.Duration(int64(a) * int64(b))}, nil
+			result, err := b.mulInt(big.NewInt(int64(a)))
+			if err != nil {
+				return nil, err
+			}
+			return intervalVal{result}, nil
 		}
+	case decimalVal:
+		if b.Kind() == KindFloat {
+			b, err := AsFloat(b)
+			if err != nil {
+				return nil, err
+			}
+			return floatVal(a.val.Float64() * b), nil
+		}
+		if b.Kind() == KindInterval {
+			b, err := AsInterval(b)
+			if err != nil {
+				return nil, err
+			}
+			result, err := b.mulFloat(a.val.Float64())
+			if err != nil {
+				return nil, err
+			}
+			return intervalVal{result}, nil
+		}
+		b, err := AsDecimal(b)
+		if err != nil {
+			return nil, err
+		}
+		result, err := a.val.mul(b)
+		if err != nil {
+			return nil, err
+		}
+		return decimalVal{result}, nil
 	case floatVal:
 		if b.Kind() == KindInterval {
 			b, err := AsInterval(b)
 			if err != nil {
 				return nil, err
 			}
-			return intervalVal{time.Duration(a * floatVal(b))}, nil
+			result, err := b.mulFloat(float64(a))
+			if err != nil {
+				return nil, err
+			}
+			return intervalVal{result}, nil
 		}
 		b, err := AsFloat(b)
 		if err != nil {
@@ -689,25 +1066,25 @@ func Mul(a, b Value) (_ Value, retErr error) {
 	case intervalVal:
 		switch b.Kind() {
 		case KindInt:
-			if a.val == 0 {
-				return intervalVal{0}, nil
-			}
-			if IsInt64(b) {
-				b, err := AsInt64(b)
-				if err != nil {
-					return nil, err
-				}
-				result := int64(a.val) * b
-				if b == 0 || a.val == 1 || b == 1 || (result/int64(a.val) == b) {
-					return intervalVal{time.Duration(result)}, nil
-				}
+			b, err := AsInt(b)
+			if err != nil {
+				return nil, err
 			}
-		case KindFloat:
+			result, err := a.val.mulInt(b)
+			if err != nil {
+				return nil, err
+			}
+			return intervalVal{result}, nil
+		case KindFloat, KindDecimal:
 			b, err := AsFloat(b)
 			if err != nil {
 				return nil, err
 			}
-			return intervalVal{time.Duration(float64(a.val) * b)}, nil
+			result, err := a.val.mulFloat(b)
+			if err != nil {
+				return nil, err
+			}
+			return intervalVal{result}, nil
 		}
 	}
 	return nil, MakeBinaryOpError("mul", a, b, nil)
@@ -740,6 +1117,16 @@ func Div(a, b Value) (_ Value, retErr error) {
 				return nil, ErrDivideByZero
 			}
 			return intVal{new(big.Int).Div(a.val, b)}, nil
+		case KindDecimal:
+			b, err := AsDecimal(b)
+			if err != nil {
+				return nil, err
+			}
+			result, err := Decimal{a.val, 0}.quo(b)
+			if err != nil {
+				return nil, err
+			}
+			return MakeInt(result), nil
 		case KindFloat:
 			a1, _ := new(big.Float).SetInt(a.val).Float64()
 			b, err := AsFloat(b)
@@ -770,6 +1157,16 @@ func Div(a, b Value) (_ Value, retErr error) {
 				return nil, err
 			}
 			return intVal{a1.Div(a1, b)}, nil
+		case KindDecimal:
+			b, err := AsDecimal(b)
+			if err != nil {
+				return nil, err
+			}
+			result, err := Decimal{big.NewInt(int64(a)), 0}.quo(b)
+			if err != nil {
+				return nil, err
+			}
+			return MakeInt(result), nil
 		case KindFloat:
 			b, err := AsFloat(b)
 			if err != nil {
@@ -777,6 +1174,26 @@ func Div(a, b Value) (_ Value, retErr error) {
 			}
 			return floatVal(float64(a) / b), nil
 		}
+	case decimalVal:
+		if b.Kind() == KindFloat {
+			b, err := AsFloat(b)
+			if err != nil {
+				return nil, err
+			}
+			if b == 0 {
+				return nil, ErrDivideByZero
+			}
+			return floatVal(a.val.Float64() / b), nil
+		}
+		b, err := AsDecimal(b)
+		if err != nil {
+			return nil, err
+		}
+		result, err := a.val.quo(b)
+		if err != nil {
+			return nil, err
+		}
+		return MakeInt(result), nil
 	case floatVal:
 		b, err := AsFloat(b)
 		if err != nil {
@@ -804,7 +1221,22 @@ func FloatDiv(a, b Value) (_ Value, retErr error) {
 	}()
 
 	switch a.Kind() {
-	case KindInt, KindFloat:
+	case KindInt, KindFloat, KindDecimal:
+		if a.Kind() != KindFloat && b.Kind() != KindFloat && (a.Kind() == KindDecimal || b.Kind() == KindDecimal) {
+			a1, err := AsDecimal(a)
+			if err != nil {
+				return nil, err
+			}
+			b1, err := AsDecimal(b)
+			if err != nil {
+				return nil, err
+			}
+			result, err := a1.div(b1)
+			if err != nil {
+				return nil, err
+			}
+			return decimalVal{result}, nil
+		}
 		a1, err := AsFloat(a)
 		if err != nil {
 			return nil, err
@@ -829,7 +1261,11 @@ func FloatDiv(a, b Value) (_ Value, retErr error) {
 		if b1 == 0 {
 			return nil, errors.New("divide by zero")
 		}
-		return intervalVal{time.Duration(float64(a1) / b1)}, nil
+		result, err := a1.divFloat(b1)
+		if err != nil {
+			return nil, err
+		}
+		return intervalVal{result}, nil
 	}
 	return nil, MakeBinaryOpError("float_div", a, b, nil)
 }
@@ -859,6 +1295,16 @@ func Mod(a, b Value) (_ Value, retErr error) {
 				return nil, ErrDivideByZero
 			}
 			return intVal{new(big.Int).Mod(a.val, b)}, nil
+		case KindDecimal:
+			b, err := AsDecimal(b)
+			if err != nil {
+				return nil, err
+			}
+			result, err := Decimal{a.val, 0}.rem(b)
+			if err != nil {
+				return nil, err
+			}
+			return decimalVal{result}, nil
 		case KindFloat:
 			a1, _ := new(big.Float).SetInt(a.val).Float64()
 			b, err := AsFloat(b)
@@ -889,6 +1335,16 @@ func Mod(a, b Value) (_ Value, retErr error) {
 				return nil, err
 			}
 			return intVal{a1.Mod(a1, b)}, nil
+		case KindDecimal:
+			b, err := AsDecimal(b)
+			if err != nil {
+				return nil, err
+			}
+			result, err := Decimal{big.NewInt(int64(a)), 0}.rem(b)
+			if err != nil {
+				return nil, err
+			}
+			return decimalVal{result}, nil
 		case KindFloat:
 			b, err := AsFloat(b)
 			if err != nil {
@@ -896,6 +1352,26 @@ func Mod(a, b Value) (_ Value, retErr error) {
 			}
 			return floatVal(math.Mod(float64(a), b)), nil
 		}
+	case decimalVal:
+		if b.Kind() == KindFloat {
+			b, err := AsFloat(b)
+			if err != nil {
+				return nil, err
+			}
+			if b == 0 {
+				return nil, ErrDivideByZero
+			}
+			return floatVal(math.Mod(a.val.Float64(), b)), nil
+		}
+		b, err := AsDecimal(b)
+		if err != nil {
+			return nil, err
+		}
+		result, err := a.val.rem(b)
+		if err != nil {
+			return nil, err
+		}
+		return decimalVal{result}, nil
 	case floatVal:
 		b, err := AsFloat(b)
 		if err != nil {
@@ -949,8 +1425,41 @@ func Neg(a Value) (_ Value, retErr error) {
 		return -a, nil
 	case floatVal:
 		return -a, nil
+	case decimalVal:
+		return decimalVal{a.val.neg()}, nil
 	case intervalVal:
-		return intervalVal{-a.val}, nil
+		result, err := a.val.neg()
+		if err != nil {
+			return nil, err
+		}
+		return intervalVal{result}, nil
 	}
 	return nil, MakeUnaryOpError("neg", a, nil)
 }
+
+func Abs(a Value) (Value, error) {
+	switch a := a.(type) {
+	case nullVal:
+		return Null, nil
+	case intVal:
+		return intVal{new(big.Int).Abs(a.val)}, nil
+	case int64Val:
+		if a < 0 {
+			return Neg(a)
+		}
+		return a, nil
+	case floatVal:
+		return floatVal(math.Abs(float64(a))), nil
+	case decimalVal:
+		if a.val.Unscaled.Sign() < 0 {
+			return decimalVal{a.val.neg()}, nil
+		}
+		return a, nil
+	case intervalVal:
+		if a.val.cmp(Interval{}) < 0 {
+			return Neg(a)
+		}
+		return a, nil
+	}
+	return nil, MakeUnaryOpError("abs", a, nil)
+}
@@ -2,6 +2,7 @@ package constant_test
 
 import (
 	"fmt"
+	"math"
 	"math/big"
 	"testing"
 	"time"
@@ -22,7 +23,14 @@ func TestString(t *testing.T) {
 		{constant.MakeInt64(123), "123"},
 		{constant.MakeFloat(123.456), "123.456"},
 		{constant.MakeTimestamp(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)), "2019-01-01 00:00:00"},
-		{constant.MakeInterval(time.Hour), "1h0m0s"},
+		{constant.MakeDate(time.Date(2019, 1, 2, 15, 4, 5, 0, time.UTC)), "2019-01-02"},
+		{constant.MakeTimeOfDay(15*time.Hour + 4*time.Minute + 5*time.Second), "15:04:05"},
+		{constant.MakeTimeOfDay(-1500 * time.Millisecond), "23:59:58.5"},
+		{constant.MakeInterval(time.Hour), "01:00:00"},
+		{constant.MakeInterval(-1500 * time.Millisecond), "-00:00:01.5"},
+		{constant.MakeCalendarInterval(constant.Interval{Months: 14, Days: 3, Microseconds: 14706789000}), "1 year 2 mons 3 days 04:05:06.789"},
+		{constant.MakeCalendarInterval(constant.Interval{Months: -1, Days: 1}), "-1 mon 1 day"},
+		{constant.MakeCalendarInterval(constant.Interval{}), "00:00:00"},
 		{constant.MakeArray([]constant.Value{constant.MakeInt64(1), constant.MakeInt64(2)}), "[1, 2]"},
 	}
 
@@ -50,6 +58,300 @@ func TestMakeNumericFromLiteral(t *testing.T) {
 	}
 }
 
+func TestMakeExactNumberFromLiteral(t *testing.T) {
+	testCases := []struct {
+		lit  string
+		str  string
+		kind constant.Kind
+	}{
+		{"123", "123", constant.KindInt},
+		{"0x1f", "31", constant.KindInt},
+		{"12.30", "12.30", constant.KindDecimal},
+		{".5", "0.5", constant.KindDecimal},
+		{"1.5e3", "1500", constant.KindDecimal},
+		{"1.5e-3", "0.0015", constant.KindDecimal},
+		{"123456789012345678901234567890.123456789", "123456789012345678901234567890.123456789", constant.KindDecimal},
+	}
+
+	for _, tc := range testCases {
+		val, err := constant.MakeExactNumberFromLiteral(tc.lit)
+		require.NoError(t, err)
+		require.Equal(t, tc.kind, val.Kind(), tc.lit)
+		require.Equal(t, tc.str, val.String(), tc.lit)
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	dec := func(s string) constant.Value {
+		d, err := constant.ParseDecimal(s)
+		if err != nil {
+			panic(err)
+		}
+		return constant.MakeDecimal(d)
+	}
+
+	testCases := []struct {
+		op       func(a, b constant.Value) (constant.Value, error)
+		a, b     constant.Value
+		expected string
+	}{
+		{constant.Add, dec("0.1"), dec("0.2"), "0.3"},
+		{constant.Add, dec("12.30"), constant.MakeInt64(1), "13.30"},
+		{constant.Add, constant.MakeInt64(1), dec("-1.005"), "-0.005"},
+		{constant.Add, dec("0.5"), constant.MakeFloat(0.25), "0.75"},
+		{constant.Sub, dec("1.00"), dec("0.005"), "0.995"},
+		{constant.Sub, constant.MakeInt64(0), dec("2.5"), "-2.5"},
+		{constant.Mul, dec("1.10"), dec("1.1"), "1.210"},
+		{constant.Mul, constant.MakeInt64(3), dec("0.1"), "0.3"},
+		{constant.FloatDiv, dec("1.0"), constant.MakeInt64(3), "0.3333333333333333"},
+		{constant.FloatDiv, dec("10.00"), constant.MakeInt64(4), "2.5000000000000000"},
+		{constant.FloatDiv, constant.MakeInt64(-2), dec("3"), "-0.6666666666666667"},
+		{constant.Div, dec("7.5"), dec("2"), "3"},
+		{constant.Div, dec("-7.5"), constant.MakeInt64(2), "-3"},
+		{constant.Mod, dec("7.5"), dec("2"), "1.5"},
+	}
+
+	for _, tc := range testCases {
+		result, err := tc.op(tc.a, tc.b)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, result.String(), "%s, %s", tc.a, tc.b)
+	}
+
+	c, _, err := constant.Cmp(dec("12.30"), dec("12.3"))
+	require.NoError(t, err)
+	require.Equal(t, 0, c)
+	c, _, err = constant.Cmp(constant.MakeInt64(13), dec("12.99"))
+	require.NoError(t, err)
+	require.Equal(t, 1, c)
+	c, _, err = constant.Cmp(dec("0.1"), constant.MakeFloat(0.2))
+	require.NoError(t, err)
+	require.Equal(t, -1, c)
+
+	neg, err := constant.Neg(dec("1.50"))
+	require.NoError(t, err)
+	require.Equal(t, "-1.50", neg.String())
+	abs, err := constant.Abs(neg)
+	require.NoError(t, err)
+	require.Equal(t, "1.50", abs.String())
+	require.Equal(t, -1, constant.Sign(neg))
+
+	_, err = constant.FloatDiv(dec("1.5"), dec("0.00"))
+	require.ErrorIs(t, err, constant.ErrDivideByZero)
+	_, err = constant.Add(dec("1.5"), constant.MakeBytes([]byte("a")))
+	require.Error(t, err)
+}
+
+func TestInt64Arithmetic(t *testing.T) {
+	bigInt := func(s string) constant.Value {
+		i, ok := new(big.Int).SetString(s, 10)
+		require.True(t, ok)
+		return constant.MakeInt(i)
+	}
+	testCases := []struct {
+		op       func(a, b constant.Value) (constant.Value, error)
+		a, b     int64
+		expected constant.Value
+	}{
+		{constant.Sub, 1, 3, constant.MakeInt64(-2)},
+		{constant.Sub, 1, -3, constant.MakeInt64(4)},
+		{constant.Sub, math.MinInt64, 1, bigInt("-9223372036854775809")},
+		{constant.Sub, math.MaxInt64, -1, bigInt("9223372036854775808")},
+		{constant.Mul, -3, 4, constant.MakeInt64(-12)},
+		{constant.Mul, -1, math.MinInt64, bigInt("9223372036854775808")},
+		{constant.Mul, math.MinInt64, -1, bigInt("9223372036854775808")},
+	}
+	for _, tc := range testCases {
+		result, err := tc.op(constant.MakeInt64(tc.a), constant.MakeInt64(tc.b))
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, result, "%d, %d", tc.a, tc.b)
+	}
+}
+
+func TestDatum(t *testing.T) {
+	for _, v := range []constant.Value{
+		constant.Null,
+		constant.MakeBool(true),
+		constant.MakeBool(false),
+		constant.MakeInt64(-42),
+		constant.MakeInt(new(big.Int).Lsh(big.NewInt(1), 70)),
+		constant.MakeFloat(2.5),
+		constant.MakeBytes([]byte("abc")),
+		constant.MakeDate(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)),
+		constant.MakeArray([]constant.Value{constant.MakeInt64(1)}),
+	} {
+		d := constant.DatumOf(v)
+		require.Equal(t, v.Kind(), d.Kind(), v.String())
+		require.Equal(t, v, d.Value(), v.String())
+		require.Equal(t, v.String(), string(d.AppendText([]byte{})), v.String())
+		require.Equal(t, v == constant.Null, d.IsNull(), v.String())
+	}
+
+	i, ok := constant.MakeInt64Datum(7).Int64()
+	require.True(t, ok)
+	require.Equal(t, int64(7), i)
+	_, ok = constant.DatumOf(constant.MakeInt(new(big.Int).Lsh(big.NewInt(1), 70))).Int64()
+	require.False(t, ok)
+	f, ok := constant.MakeFloatDatum(1.5).Float()
+	require.True(t, ok)
+	require.Equal(t, 1.5, f)
+	_, ok = constant.MakeInt64Datum(1).Float()
+	require.False(t, ok)
+	b, ok := constant.MakeBytesDatum([]byte("x")).Bytes()
+	require.True(t, ok)
+	require.Equal(t, []byte("x"), b)
+	require.Equal(t, constant.NullDatum, constant.Datum{})
+
+	str := constant.MakeBytes(b)
+	allocs := testing.AllocsPerRun(100, func() {
+		d := constant.MakeInt64Datum(123456789)
+		_, _ = d.Int64()
+		_, _ = constant.DatumOf(str).Bytes()
+	})
+	require.Zero(t, allocs)
+}
+
+func TestAbs(t *testing.T) {
+	testCases := []struct {
+		val    constant.Value
+		result constant.Value
+	}{
+		{constant.Null, constant.Null},
+		{constant.MakeInt64(-3), constant.MakeInt64(3)},
+		{constant.MakeInt64(math.MinInt64), constant.MakeInt(makeBigInt("9223372036854775808", 10))},
+		{constant.MakeFloat(-1.5), constant.MakeFloat(1.5)},
+		{constant.MakeInterval(-time.Hour), constant.MakeInterval(time.Hour)},
+	}
+
+	for _, tc := range testCases {
+		result, err := constant.Abs(tc.val)
+		require.NoError(t, err)
+		require.Equal(t, tc.result, result)
+	}
+
+	_, err := constant.Abs(constant.MakeBytes([]byte("abc")))
+	require.Error(t, err)
+}
+
+func TestIntervalArithmetic(t *testing.T) {
+	ts := func(s string) constant.Value {
+		t, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			panic(err)
+		}
+		return constant.MakeTimestamp(t)
+	}
+	months := func(n int64) constant.Value {
+		return constant.MakeCalendarInterval(constant.Interval{Months: n})
+	}
+	days := func(n int64) constant.Value {
+		return constant.MakeCalendarInterval(constant.Interval{Days: n})
+	}
+	micros := func(n int64) constant.Value {
+		return constant.MakeCalendarInterval(constant.Interval{Microseconds: n})
+	}
+
+	testCases := []struct {
+		op       func(a, b constant.Value) (constant.Value, error)
+		a, b     constant.Value
+		expected constant.Value
+	}{
+		{constant.Add, ts("2016-01-31 12:00:00"), months(1), ts("2016-02-29 12:00:00")},
+		{constant.Add, ts("2015-01-31 12:00:00"), months(1), ts("2015-02-28 12:00:00")},
+		{constant.Add, months(13), ts("2016-02-29 00:00:00"), ts("2017-03-29 00:00:00")},
+		{constant.Add, ts("2016-03-31 00:00:00"), constant.MakeCalendarInterval(constant.Interval{Months: 1, Days: 1}), ts("2016-05-01 00:00:00")},
+		{constant.Sub, ts("2016-03-31 00:00:00"), months(1), ts("2016-02-29 00:00:00")},
+		{constant.Sub, ts("2016-01-15 00:00:00"), months(13), ts("2014-12-15 00:00:00")},
+		{constant.Sub, ts("2016-01-02 00:00:00"), ts("2016-01-01 23:00:00"), constant.MakeInterval(time.Hour)},
+		{constant.Add, months(1), days(2), constant.MakeCalendarInterval(constant.Interval{Months: 1, Days: 2})},
+		{constant.Sub, months(1), days(2), constant.MakeCalendarInterval(constant.Interval{Months: 1, Days: -2})},
+		{constant.Mul, months(1), constant.MakeInt64(3), months(3)},
+		{constant.Mul, constant.MakeFloat(1.5), months(1), constant.MakeCalendarInterval(constant.Interval{Months: 1, Days: 15})},
+		{constant.Mul, days(1), constant.MakeFloat(0.5), constant.MakeInterval(12 * time.Hour)},
+		{constant.FloatDiv, months(1), constant.MakeInt64(4), constant.MakeCalendarInterval(constant.Interval{Days: 7, Microseconds: 43200000000})},
+		// Beyond the range of time.Duration.
+		{constant.Add, ts("2020-01-01 00:00:00"), micros(3000000 * 3600e6), ts("2362-03-29 00:00:00")},
+		{constant.Sub, ts("2020-01-01 00:00:00"), micros(3000000 * 3600e6), ts("1677-10-05 00:00:00")},
+		{constant.Sub, micros(-1), micros(math.MinInt64), micros(math.MaxInt64)},
+	}
+
+	for _, tc := range testCases {
+		result, err := tc.op(tc.a, tc.b)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, result, "%s, %s", tc.a, tc.b)
+	}
+
+	c, _, err := constant.Cmp(months(1), days(30))
+	require.NoError(t, err)
+	require.Equal(t, 0, c)
+	c, _, err = constant.Cmp(months(1), days(31))
+	require.NoError(t, err)
+	require.Equal(t, -1, c)
+	require.Equal(t, -1, constant.Sign(constant.MakeCalendarInterval(constant.Interval{Months: 1, Days: -31})))
+
+	_, err = constant.Mul(months(math.MaxInt64), constant.MakeInt64(2))
+	require.Error(t, err)
+	_, err = constant.Add(micros(math.MaxInt64), micros(1))
+	require.Error(t, err)
+	_, err = constant.Sub(days(math.MinInt64), days(1))
+	require.Error(t, err)
+	_, err = constant.Sub(ts("2020-01-01 00:00:00"), months(math.MinInt64))
+	require.Error(t, err)
+	_, err = constant.Neg(micros(math.MinInt64))
+	require.Error(t, err)
+}
+
+func TestDateTimeArithmetic(t *testing.T) {
+	date := func(year int, month time.Month, day int) constant.Value {
+		return constant.MakeDate(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+	}
+	tod := func(d time.Duration) constant.Value {
+		return constant.MakeTimeOfDay(d)
+	}
+	ts := func(year int, month time.Month, day, hour, min int) constant.Value {
+		return constant.MakeTimestamp(time.Date(year, month, day, hour, min, 0, 0, time.UTC))
+	}
+	months := func(n int64) constant.Value {
+		return constant.MakeCalendarInterval(constant.Interval{Months: n})
+	}
+
+	testCases := []struct {
+		op       func(a, b constant.Value) (constant.Value, error)
+		a, b     constant.Value
+		expected constant.Value
+	}{
+		{constant.Add, date(2016, 2, 28), constant.MakeInt64(2), date(2016, 3, 1)},
+		{constant.Sub, date(2016, 3, 1), constant.MakeInt64(1), date(2016, 2, 29)},
+		{constant.Sub, date(2017, 1, 1), date(2016, 1, 1), constant.MakeInt64(366)},
+		{constant.Sub, date(1969, 12, 31), date(1970, 1, 2), constant.MakeInt64(-2)},
+		{constant.Add, date(2016, 1, 31), months(1), ts(2016, 2, 29, 0, 0)},
+		{constant.Add, months(1), date(2016, 1, 31), ts(2016, 2, 29, 0, 0)},
+		{constant.Sub, date(2016, 3, 1), constant.MakeInterval(time.Hour), ts(2016, 2, 29, 23, 0)},
+		{constant.Add, date(2016, 3, 1), tod(12*time.Hour + 30*time.Minute), ts(2016, 3, 1, 12, 30)},
+		{constant.Add, tod(12 * time.Hour), date(2016, 3, 1), ts(2016, 3, 1, 12, 0)},
+		{constant.Add, tod(23 * time.Hour), constant.MakeInterval(2 * time.Hour), tod(time.Hour)},
+		{constant.Add, tod(23 * time.Hour), months(1), tod(23 * time.Hour)},
+		{constant.Sub, tod(time.Hour), constant.MakeInterval(2 * time.Hour), tod(23 * time.Hour)},
+		{constant.Sub, tod(time.Hour), tod(3 * time.Hour), constant.MakeInterval(-2 * time.Hour)},
+	}
+
+	for _, tc := range testCases {
+		result, err := tc.op(tc.a, tc.b)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, result, "%s, %s", tc.a, tc.b)
+	}
+
+	c, _, err := constant.Cmp(date(2016, 1, 1), date(2015, 12, 31))
+	require.NoError(t, err)
+	require.Equal(t, 1, c)
+	c, _, err = constant.Cmp(tod(time.Hour), tod(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, -1, c)
+	_, _, err = constant.Cmp(date(2016, 1, 1), tod(time.Hour))
+	require.Error(t, err)
+	_, err = constant.Add(date(2016, 1, 1), date(2016, 1, 1))
+	require.Error(t, err)
+}
+
 func makeBigInt(s string, base int) *big.Int {
 	i, ok := new(big.Int).SetString(s, base)
 	if !ok {
@@ -57,3 +359,167 @@ func makeBigInt(s string, base int) *big.Int {
 	}
 	return i
 }
+
+func TestJSON(t *testing.T) {
+	testCases := []struct {
+		value    constant.Value
+		expected string
+	}{
+		{constant.Null, `null`},
+		{constant.MakeBool(true), `true`},
+		{constant.MakeInt64(-12), `-12`},
+		{constant.MakeFloat(1.5), `1.5`},
+		{constant.MakeFloat(math.Inf(-1)), `"-Infinity"`},
+		{constant.MakeBytes([]byte("a\"<b>\n")), `"a\"<b>\n"`},
+		{constant.MakeTimestamp(time.Date(2020, 1, 2, 3, 4, 5, 600000000, time.UTC)), `"2020-01-02T03:04:05.6"`},
+		{constant.MakeArray([]constant.Value{constant.MakeInt64(1), constant.Null}), `[1,null]`},
+	}
+	for _, tc := range testCases {
+		v, err := constant.ToJSON(tc.value)
+		require.NoError(t, err, tc.value)
+		require.Equal(t, constant.KindJSON, v.Kind())
+		require.Equal(t, tc.expected, v.String())
+	}
+
+	v, err := constant.MakeJSON([]byte(` { "b" : 1.50, "a" : [ ] } `))
+	require.NoError(t, err)
+	require.Equal(t, `{"b":1.50,"a":[]}`, v.String())
+	w, err := constant.ToJSON(v)
+	require.NoError(t, err)
+	c, isNull, err := constant.Cmp(v, w)
+	require.NoError(t, err)
+	require.False(t, isNull)
+	require.Equal(t, 0, c)
+
+	_, err = constant.MakeJSON([]byte(`{"a":}`))
+	require.Error(t, err)
+}
+
+func TestConvert(t *testing.T) {
+	str := func(s string) constant.Value { return constant.MakeBytes([]byte(s)) }
+	dec := func(s string) constant.Value {
+		d, err := constant.ParseDecimal(s)
+		require.NoError(t, err)
+		return constant.MakeDecimal(d)
+	}
+	integer := constant.Type{Kind: constant.KindInt, Bits: 32}
+	bigint := constant.Type{Kind: constant.KindInt, Bits: 64}
+	real := constant.Type{Kind: constant.KindFloat, Bits: 32}
+	double := constant.Type{Kind: constant.KindFloat, Bits: 64}
+	text := constant.Type{Kind: constant.KindBytes}
+	bytea := constant.Type{Kind: constant.KindBytes, Binary: true}
+	boolean := constant.Type{Kind: constant.KindBool}
+	decimal := constant.Type{Kind: constant.KindDecimal, Precision: 5, Scale: 2}
+	timestamp := constant.Type{Kind: constant.KindTimestamp}
+	date := constant.Type{Kind: constant.KindDate}
+	timeOfDay := constant.Type{Kind: constant.KindTime}
+	interval := constant.Type{Kind: constant.KindInterval}
+	loc := time.FixedZone("UTC+8", 8*3600)
+
+	testCases := []struct {
+		value    constant.Value
+		typ      constant.Type
+		expected string // empty if the conversion fails
+	}{
+		{constant.Null, integer, "NULL"},
+		{str(" -42 "), integer, "-42"},
+		{str("2147483648"), integer, ""},
+		{str("2147483648"), bigint, "2147483648"},
+		{str("-2147483648"), integer, "-2147483648"},
+		{str("1.5"), integer, ""},
+		{constant.MakeFloat(2.5), integer, "2"},
+		{constant.MakeFloat(3.5), integer, "4"},
+		{constant.MakeFloat(math.NaN()), integer, ""},
+		{dec("-2.5"), integer, "-3"},
+		{constant.MakeBool(true), integer, "1"},
+		{constant.MakeFloat(1e300), bigint, ""},
+		{str("1e3"), double, "1000"},
+		{str("x"), double, ""},
+		{constant.MakeFloat(0.1), real, "0.10000000149011612"},
+		{constant.MakeFloat(1e300), real, ""},
+		{constant.MakeBool(true), double, ""},
+		{constant.MakeInt64(7), text, "7"},
+		{constant.MakeBool(false), text, "FALSE"},
+		{str("héllo"), constant.Type{Kind: constant.KindBytes, Length: 2}, "hé"},
+		{str("abc"), bytea, "abc"},
+		{constant.MakeInt64(7), bytea, ""},
+		{str(" Yes "), boolean, "TRUE"},
+		{str("off"), boolean, "FALSE"},
+		{str("maybe"), boolean, ""},
+		{constant.MakeInt64(-3), boolean, "TRUE"},
+		{constant.MakeFloat(1), boolean, ""},
+		{str("123.455"), decimal, "123.46"},
+		{constant.MakeInt64(-7), decimal, "-7.00"},
+		{constant.MakeFloat(0.125), decimal, "0.13"},
+		{str("999.995"), decimal, ""},
+		{str("1e2"), constant.Type{Kind: constant.KindDecimal}, "100"},
+		{str("2020-01-02 03:04:05.5"), timestamp, "2020-01-02 03:04:05.5"},
+		{str("2020-01-02"), timestamp, "2020-01-02 00:00:00"},
+		{constant.MakeDate(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)), timestamp, "2020-01-02 00:00:00"},
+		{str("2020-13-01"), timestamp, ""},
+		{constant.MakeTimestamp(time.Date(2020, 1, 2, 23, 4, 5, 0, loc)), date, "2020-01-02"},
+		{str("2020-01-02 23:00:00"), date, "2020-01-02"},
+		{constant.MakeTimestamp(time.Date(2020, 1, 2, 23, 4, 5, 0, loc)), timeOfDay, "23:04:05"},
+		{str("12:30"), timeOfDay, "12:30:00"},
+		{constant.MakeInterval(25 * time.Hour), timeOfDay, "01:00:00"},
+		{str("1 year 2 mons 3 days 04:05:06.5"), interval, "1 year 2 mons 3 days 04:05:06.5"},
+		{constant.MakeTimeOfDay(90 * time.Minute), interval, "01:30:00"},
+		{constant.MakeInt64(1), interval, ""},
+		{str(`{"a": 1}`), constant.Type{Kind: constant.KindJSON}, `{"a":1}`},
+		{constant.MakeInt64(1), constant.Type{Kind: constant.KindJSON}, `1`},
+	}
+	for _, tc := range testCases {
+		result, err := constant.Convert(tc.value, tc.typ, loc)
+		if tc.expected == "" {
+			var convertErr *constant.ConvertError
+			require.ErrorAs(t, err, &convertErr, "%s to %s", tc.value, tc.typ)
+			require.Equal(t, tc.typ.String(), convertErr.To)
+			continue
+		}
+		require.NoError(t, err, "%s to %s", tc.value, tc.typ)
+		if tc.value != constant.Null {
+			require.Equal(t, tc.typ.Kind, result.Kind())
+		}
+		require.Equal(t, tc.expected, result.String(), "%s to %s", tc.value, tc.typ)
+	}
+
+	ts, err := constant.Convert(str("2020-01-02"), timestamp, loc)
+	require.NoError(t, err)
+	tm, _ := constant.AsTimestamp(ts)
+	require.Equal(t, loc, tm.Location())
+}
+
+func TestParseInterval(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected constant.Interval
+	}{
+		{"1 day", constant.Interval{Days: 1}},
+		{"2 Years 3 mons", constant.Interval{Months: 27}},
+		{"-1.5 hours", constant.Interval{Microseconds: -5400e6}},
+		{"3days 12:00", constant.Interval{Days: 3, Microseconds: 12 * 3600e6}},
+		{"-00:00:01.25", constant.Interval{Microseconds: -1250000}},
+		{"1 week 2 ms ago", constant.Interval{Days: -7, Microseconds: -2000}},
+		{"1.5 months", constant.Interval{Months: 1, Days: 15}},
+	}
+	for _, tc := range testCases {
+		i, err := constant.ParseInterval(tc.input)
+		require.NoError(t, err, tc.input)
+		require.Equal(t, tc.expected, i, tc.input)
+	}
+	for _, input := range []string{"", "1", "1 fortnight", "1:2:3:4", "x days"} {
+		_, err := constant.ParseInterval(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestKindSet(t *testing.T) {
+	s := constant.KindSetOf(constant.KindInt, constant.KindBytes)
+	require.True(t, s.Has(constant.KindInt))
+	require.False(t, s.Has(constant.KindFloat))
+	require.Equal(t, "Bytes|Int", s.String())
+	require.Equal(t, "Bytes", s.Without(constant.KindInt).String())
+	require.Equal(t, "None", constant.KindSet(0).String())
+	require.Equal(t, "Any", constant.AnyKind.String())
+	require.True(t, constant.AnyKind.Has(constant.KindJSON))
+}
@@ -0,0 +1,170 @@
+package dbgen
+
+import (
+	"github.com/gozssky/dbgen/constant"
+	"github.com/gozssky/dbgen/template"
+)
+
+// DatumCompiled is a compiled expression that can be evaluated to a Datum,
+// which does not allocate for numbers.
+type DatumCompiled interface {
+	Compiled
+	// EvalDatum evaluates the expression like Eval.
+	EvalDatum(state *State) (constant.Datum, error)
+}
+
+// datumFunction is a pure function with fast paths for some kinds of
+// datums. callDatum reports false if there is none for args.
+type datumFunction interface {
+	callDatum(args []constant.Datum) (constant.Datum, bool)
+}
+
+// EvalDatums evaluates a row like Eval, appending the values as datums to
+// dst[:0]. The Generator evaluates the columns one at a time instead, to
+// wrap their errors in a RowError.
+func (r Row) EvalDatums(state *State, dst []constant.Datum) ([]constant.Datum, error) {
+	dst = dst[:0]
+	for _, compiled := range r {
+		datum, err := evalDatum(compiled, state)
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, datum)
+	}
+	return dst, nil
+}
+
+// evalDatum evaluates an expression to a datum, falling back to converting
+// its value.
+func evalDatum(compiled Compiled, state *State) (constant.Datum, error) {
+	if c, ok := compiled.(DatumCompiled); ok {
+		return c.EvalDatum(state)
+	}
+	value, err := compiled.Eval(state)
+	if err != nil {
+		return constant.NullDatum, err
+	}
+	return constant.DatumOf(value), nil
+}
+
+func (*RowNum) EvalDatum(state *State) (constant.Datum, error) {
+	return constant.MakeInt64Datum(state.RowNum), nil
+}
+
+func (*SubRowNum) EvalDatum(state *State) (constant.Datum, error) {
+	return constant.MakeInt64Datum(state.SubRowNum), nil
+}
+
+func (c *Constant) EvalDatum(_ *State) (constant.Datum, error) {
+	return constant.DatumOf(c.Value), nil
+}
+
+func (g *GetVariable) EvalDatum(state *State) (constant.Datum, error) {
+	return constant.DatumOf(state.CompileCtx.Variables[g.Index].B), nil
+}
+
+func (f *FunctionCall) EvalDatum(state *State) (constant.Datum, error) {
+	fn, ok := f.Fn.(datumFunction)
+	if !ok {
+		value, err := f.Eval(state)
+		if err != nil {
+			return constant.NullDatum, err
+		}
+		return constant.DatumOf(value), nil
+	}
+	if f.datums == nil {
+		f.datums = make([]constant.Datum, len(f.Args))
+	}
+	for i, arg := range f.Args {
+		var err error
+		if f.datums[i], err = evalDatum(arg, state); err != nil {
+			return constant.NullDatum, err
+		}
+	}
+	if result, ok := fn.callDatum(f.datums); ok {
+		return result, nil
+	}
+	for i, datum := range f.datums {
+		f.values[i] = datum.Value()
+	}
+	value, err := f.Fn.Call(state, f.values)
+	if err != nil {
+		return constant.NullDatum, err
+	}
+	return constant.DatumOf(value), nil
+}
+
+func (a ArithFunc) callDatum(args []constant.Datum) (constant.Datum, bool) {
+	x, y := args[0], args[1]
+	if i, ok := x.Int64(); ok {
+		if j, ok := y.Int64(); ok && a.FastOp != template.OpFloatDiv {
+			var result int64
+			switch a.FastOp {
+			case template.OpAdd:
+				result, ok = addInt64(i, j)
+			case template.OpSub:
+				result, ok = subInt64(i, j)
+			case template.OpMul:
+				result, ok = mulInt64(i, j)
+			default:
+				return constant.NullDatum, false
+			}
+			return constant.MakeInt64Datum(result), ok
+		}
+	}
+	f, ok := datumFloat(x)
+	if !ok {
+		return constant.NullDatum, false
+	}
+	g, ok := datumFloat(y)
+	if !ok {
+		return constant.NullDatum, false
+	}
+	switch a.FastOp {
+	case template.OpAdd:
+		return constant.MakeFloatDatum(f + g), true
+	case template.OpSub:
+		return constant.MakeFloatDatum(f - g), true
+	case template.OpMul:
+		return constant.MakeFloatDatum(f * g), true
+	case template.OpFloatDiv:
+		return constant.MakeFloatDatum(f / g), g != 0
+	default:
+		return constant.NullDatum, false
+	}
+}
+
+// datumFloat returns the number of an inline integer or float datum.
+func datumFloat(d constant.Datum) (float64, bool) {
+	if i, ok := d.Int64(); ok {
+		return float64(i), true
+	}
+	return d.Float()
+}
+
+func (c CompareFunc) callDatum(args []constant.Datum) (constant.Datum, bool) {
+	var cmp int
+	if i, ok := args[0].Int64(); ok {
+		j, ok := args[1].Int64()
+		if !ok {
+			return constant.NullDatum, false
+		}
+		cmp = compareOrdered(i, j)
+	} else if f, ok := args[0].Float(); ok {
+		g, ok := args[1].Float()
+		if !ok {
+			return constant.NullDatum, false
+		}
+		cmp = compareOrdered(f, g)
+	} else {
+		return constant.NullDatum, false
+	}
+	switch cmp {
+	case -1:
+		return constant.MakeBoolDatum(c.LT), true
+	case 0:
+		return constant.MakeBoolDatum(c.EQ), true
+	default:
+		return constant.MakeBoolDatum(c.GT), true
+	}
+}
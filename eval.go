@@ -1,7 +1,11 @@
 package dbgen
 
 import (
+	"encoding/binary"
 	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
 	"math/rand"
 	"regexp"
 	"time"
@@ -28,6 +32,9 @@ type Table struct {
 	Name    *template.QName
 	Content string
 	Columns []template.Name
+	// Exprs are the source expressions of the columns, used to locate
+	// errors.
+	Exprs   []template.Expr
 	Row     Row
 	Derived []lo.Tuple2[int, Compiled]
 }
@@ -41,8 +48,14 @@ type CompileContext struct {
 	// LoadLocation is the function used to load the Location with the given name.
 	LoadLocation func(name string) (*time.Location, error)
 	// The global variables.
-	Variables []lo.Tuple2[string, constant.Value]
-	tzCache   map[string]*time.Location
+	Variables  []lo.Tuple2[string, constant.Value]
+	tzCache    map[string]*time.Location
+	regexCache map[string]*regexp.Regexp
+	// lambdaScope maps the parameters of the lambdas being compiled to
+	// their slots in lambdaArgs.
+	lambdaScope []lo.Tuple2[string, int]
+	// lambdaArgs are the arguments of the lambdas being evaluated.
+	lambdaArgs []constant.Value
 }
 
 func NewCompileContext() *CompileContext {
@@ -51,6 +64,7 @@ func NewCompileContext() *CompileContext {
 		CurrentTimestamp: time.Now().UTC(),
 		LoadLocation:     time.LoadLocation,
 		tzCache:          make(map[string]*time.Location),
+		regexCache:       make(map[string]*regexp.Regexp),
 	}
 }
 
@@ -66,7 +80,31 @@ func (ctx *CompileContext) ParseTimeZone(tz string) (*time.Location, error) {
 	return loc, nil
 }
 
+// maxRegexCacheSize bounds the number of regular expressions cached by
+// CompileRegexp, in case the patterns are generated per row.
+const maxRegexCacheSize = 1024
+
+// CompileRegexp compiles a regular expression, reusing the result of previous
+// compilations of the same pattern.
+func (ctx *CompileContext) CompileRegexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := ctx.regexCache[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(ctx.regexCache) < maxRegexCacheSize {
+		ctx.regexCache[pattern] = re
+	}
+	return re, nil
+}
+
+// CompileTemplate type checks and compiles a template.
 func (ctx *CompileContext) CompileTemplate(t *template.Template) (*Template, error) {
+	if err := CheckTypes(t); err != nil {
+		return nil, err
+	}
 	row, err := ctx.CompileRow(t.GlobalExprs)
 	if err != nil {
 		return nil, err
@@ -108,6 +146,7 @@ func (ctx *CompileContext) CompileTable(t *template.Table) (*Table, error) {
 		Columns: lo.Map(t.Columns, func(col *template.Column, _ int) template.Name {
 			return col.Name
 		}),
+		Exprs:   exprs,
 		Row:     row,
 		Derived: derived,
 	}, nil
@@ -152,7 +191,11 @@ func (ctx *CompileContext) CompileExpr(expr template.Expr) (Compiled, error) {
 			index = len(ctx.Variables)
 			ctx.Variables = append(ctx.Variables, lo.T2(expr.Name, constant.Null))
 		}
-		return &SetVariable{Index: index}, nil
+		value, err := ctx.CompileExpr(expr.Value)
+		if err != nil {
+			return nil, err
+		}
+		return &SetVariable{Index: index, Value: value}, nil
 	case *template.UnaryExpr:
 		fn, ok := UnaryFuncs[expr.Op]
 		if !ok {
@@ -168,6 +211,9 @@ func (ctx *CompileContext) CompileExpr(expr template.Expr) (Compiled, error) {
 	case *template.ParenExpr:
 		return ctx.CompileExpr(expr.Expr)
 	case *template.FuncExpr:
+		if fn, ok := LambdaFuncs[expr.Name.UniqueName()]; ok {
+			return ctx.compileLambdaFunction(expr, fn)
+		}
 		fn, ok := GenericFuncs[expr.Name.UniqueName()]
 		if !ok {
 			return nil, fmt.Errorf("unknown function: %s", expr.Name)
@@ -175,6 +221,13 @@ func (ctx *CompileContext) CompileExpr(expr template.Expr) (Compiled, error) {
 		if fn.NumArgs() >= 0 && len(expr.Args) != fn.NumArgs() {
 			return nil, fmt.Errorf("wrong number of arguments for function %s: expected %d, got %d", expr.Name, fn.NumArgs(), len(expr.Args))
 		}
+		if expr.Unit != 0 {
+			unitFn, ok := fn.(unitFunction)
+			if !ok {
+				return nil, fmt.Errorf("function %s does not support the USING clause", expr.Name)
+			}
+			fn = unitFn.WithUnit(expr.Unit)
+		}
 		return ctx.compileRawFunction(fn, expr.Args...)
 	case *template.CaseValueWhen:
 		return ctx.compileCaseValueWhen(expr)
@@ -186,9 +239,13 @@ func (ctx *CompileContext) CompileExpr(expr template.Expr) (Compiled, error) {
 			fn = &TimestampFunc{}
 		}
 		return ctx.compileRawFunction(fn, expr.Value)
+	case *template.Date:
+		return ctx.compileRawFunction(&DateFunc{}, expr.Value)
+	case *template.Time:
+		return ctx.compileRawFunction(&TimeFunc{}, expr.Value)
 	case *template.Interval:
 		fn := BinaryFuncs[template.OpMul]
-		unit := constant.MakeInterval(time.Duration(expr.Unit))
+		unit := constant.MakeCalendarInterval(expr.Unit.Interval())
 		return ctx.compileRawFunction(fn, expr.Value, &template.Constant{Value: unit})
 	case *template.Array:
 		fn := &ArrayFunc{}
@@ -196,12 +253,40 @@ func (ctx *CompileContext) CompileExpr(expr template.Expr) (Compiled, error) {
 	case *template.Subscript:
 		fn := &SubscriptFunc{}
 		return ctx.compileRawFunction(fn, expr.Base, expr.Index)
+	case *template.Slice:
+		fn := &ArraySliceFunc{}
+		return ctx.compileRawFunction(fn, expr.Base, expr.From, expr.To)
 	case *template.Substring:
 		fn := &SubstringFunc{Unit: expr.Unit}
 		return ctx.compileRawFunction(fn, expr.Input, expr.From, expr.For)
 	case *template.Overlay:
 		fn := &OverlayFunc{Unit: expr.Unit}
 		return ctx.compileRawFunction(fn, expr.Input, expr.Placing, expr.From, expr.For)
+	case *template.Position:
+		fn := &PositionFunc{Unit: expr.Unit}
+		return ctx.compileRawFunction(fn, expr.Needle, expr.Haystack)
+	case *template.Extract:
+		field, err := parseDateField(expr.Field)
+		if err != nil {
+			return nil, err
+		}
+		fn := &ExtractFunc{Field: field}
+		return ctx.compileRawFunction(fn, expr.Value)
+	case *template.AtTimeZone:
+		fn := &AtTimeZoneFunc{}
+		return ctx.compileRawFunction(fn, expr.Value, expr.Zone)
+	case *template.Cast:
+		fn := &CastFunc{Type: expr.Type}
+		return ctx.compileRawFunction(fn, expr.Value)
+	case *template.Lambda:
+		return nil, fmt.Errorf("lambda %s is only allowed as an argument of a higher-order function", expr)
+	case *template.LambdaParam:
+		for i := len(ctx.lambdaScope) - 1; i >= 0; i-- {
+			if ctx.lambdaScope[i].A == expr.Name.N {
+				return &LambdaParam{Index: ctx.lambdaScope[i].B}, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown lambda parameter: %s", expr.Name)
 	default:
 		return nil, fmt.Errorf("unknown expression: %T", expr)
 	}
@@ -227,13 +312,98 @@ func (ctx *CompileContext) compileRawFunction(fn Function, args ...template.Expr
 		for _, arg := range compiledArgs {
 			constArgs = append(constArgs, arg.(*Constant).Value)
 		}
-		compiled, err := fn.Compile(ctx, constArgs)
+		switch fn := fn.(type) {
+		case PureFunction:
+			value, err := fn.Call(&State{CompileCtx: ctx}, constArgs)
+			if err != nil {
+				return nil, err
+			}
+			return &Constant{value}, nil
+		case BindFunction:
+			return fn.Compile(ctx, constArgs)
+		}
+	}
+	if prep, ok := fn.(PrepareFunction); ok {
+		consts := make(Arguments, len(compiledArgs))
+		for i, arg := range compiledArgs {
+			if c, ok := arg.(*Constant); ok {
+				consts[i] = c.Value
+			}
+		}
+		prepared, err := prep.Prepare(ctx, consts)
 		if err != nil {
 			return nil, err
 		}
-		return compiled, nil
+		if prepared != nil {
+			fn = prepared
+		}
+	}
+	switch fn := fn.(type) {
+	case LazyFunction:
+		return fn.CompileLazy(ctx, compiledArgs)
+	case PureFunction:
+		return &FunctionCall{Fn: fn, Args: compiledArgs, values: make(Arguments, len(compiledArgs))}, nil
+	case BindFunction:
+		return &RawFunction{Fn: fn, Args: compiledArgs, values: make(Arguments, len(compiledArgs))}, nil
+	default:
+		return nil, fmt.Errorf("function %T can be neither called nor bound", fn)
 	}
-	return &RawFunction{Fn: fn, Args: compiledArgs}, nil
+}
+
+// compileLambdaFunction compiles a call of a higher-order function, whose
+// last argument is a lambda.
+func (ctx *CompileContext) compileLambdaFunction(expr *template.FuncExpr, fn LambdaFunction) (Compiled, error) {
+	if len(expr.Args) != fn.NumArgs() {
+		return nil, fmt.Errorf("wrong number of arguments for function %s: expected %d, got %d", expr.Name, fn.NumArgs(), len(expr.Args))
+	}
+	lambdaExpr, ok := expr.Args[len(expr.Args)-1].(*template.Lambda)
+	if !ok {
+		return nil, fmt.Errorf("the last argument of function %s must be a lambda", expr.Name)
+	}
+	if len(lambdaExpr.Params) != fn.NumParams() {
+		return nil, fmt.Errorf("wrong number of lambda parameters for function %s: expected %d, got %d", expr.Name, fn.NumParams(), len(lambdaExpr.Params))
+	}
+	lambda, err := ctx.compileLambda(lambdaExpr)
+	if err != nil {
+		return nil, err
+	}
+	isConst := true
+	compiledArgs := make([]Compiled, 0, len(expr.Args)-1)
+	for _, arg := range expr.Args[:len(expr.Args)-1] {
+		compiled, err := ctx.CompileExpr(arg)
+		if err != nil {
+			return nil, err
+		}
+		compiledArgs = append(compiledArgs, compiled)
+		isConst = isConst && IsConstant(compiled)
+	}
+	if isConst {
+		constArgs := make([]constant.Value, 0, len(compiledArgs))
+		for _, arg := range compiledArgs {
+			constArgs = append(constArgs, arg.(*Constant).Value)
+		}
+		return fn.Compile(ctx, constArgs, lambda)
+	}
+	return &RawLambdaFunction{Fn: fn, Args: compiledArgs, Lambda: lambda, values: make(Arguments, len(compiledArgs))}, nil
+}
+
+// compileLambda binds each parameter of a lambda to a new slot in
+// lambdaArgs and compiles its body.
+func (ctx *CompileContext) compileLambda(expr *template.Lambda) (*Lambda, error) {
+	lambda := &Lambda{Params: make([]int, 0, len(expr.Params))}
+	for _, param := range expr.Params {
+		slot := len(ctx.lambdaArgs)
+		ctx.lambdaArgs = append(ctx.lambdaArgs, constant.Null)
+		ctx.lambdaScope = append(ctx.lambdaScope, lo.T2(param.N, slot))
+		lambda.Params = append(lambda.Params, slot)
+	}
+	body, err := ctx.CompileExpr(expr.Body)
+	ctx.lambdaScope = ctx.lambdaScope[:len(ctx.lambdaScope)-len(expr.Params)]
+	if err != nil {
+		return nil, err
+	}
+	lambda.Body = body
+	return lambda, nil
 }
 
 func (ctx *CompileContext) compileCaseValueWhen(expr *template.CaseValueWhen) (Compiled, error) {
@@ -247,8 +417,6 @@ func (ctx *CompileContext) compileCaseValueWhen(expr *template.CaseValueWhen) (C
 		if err != nil {
 			return nil, err
 		}
-	} else {
-		value = &Constant{constant.Null}
 	}
 	whens := make([]*When, 0, len(expr.Whens))
 	for _, when := range expr.Whens {
@@ -275,16 +443,22 @@ func (ctx *CompileContext) compileCaseValueWhen(expr *template.CaseValueWhen) (C
 	isConstWhen := func(w *When) bool {
 		return IsConstant(w.Cond) && IsConstant(w.Then)
 	}
-	if IsConstant(value) && lo.EveryBy(whens, isConstWhen) && IsConstant(else_) {
-		value, err := compiled.Eval(nil)
-		if err != nil {
-			return nil, err
-		}
-		return &Constant{Value: value}, nil
+	if (value == nil || IsConstant(value)) && lo.EveryBy(whens, isConstWhen) && IsConstant(else_) {
+		return ctx.evalConstant(compiled)
 	}
 	return compiled, nil
 }
 
+// evalConstant evaluates a compiled expression whose operands are all
+// constants.
+func (ctx *CompileContext) evalConstant(compiled Compiled) (Compiled, error) {
+	value, err := compiled.Eval(&State{CompileCtx: ctx})
+	if err != nil {
+		return nil, err
+	}
+	return &Constant{Value: value}, nil
+}
+
 // Row represents a row of compiled values.
 type Row []Compiled
 
@@ -300,6 +474,80 @@ func (r Row) Eval(state *State) ([]constant.Value, error) {
 	return result, nil
 }
 
+// EvalRow evaluates a row of the table like Row.Eval, wrapping the errors
+// of its columns in a RowError.
+func (t *Table) EvalRow(state *State) ([]constant.Value, error) {
+	result := make([]constant.Value, 0, len(t.Row))
+	for i := range t.Row {
+		value, err := t.evalColumn(state, i)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// evalColumn evaluates the i-th column of a row, wrapping its error in a
+// RowError.
+func (t *Table) evalColumn(state *State, i int) (constant.Value, error) {
+	value, err := t.Row[i].Eval(state)
+	if err != nil {
+		return nil, t.rowError(state, i, err)
+	}
+	return value, nil
+}
+
+// evalColumnDatum evaluates the i-th column of a row like evalColumn, but
+// to a datum.
+func (t *Table) evalColumnDatum(state *State, i int) (constant.Datum, error) {
+	datum, err := evalDatum(t.Row[i], state)
+	if err != nil {
+		return constant.NullDatum, t.rowError(state, i, err)
+	}
+	return datum, nil
+}
+
+// rowError wraps the error of the i-th column of a row.
+func (t *Table) rowError(state *State, i int, err error) *RowError {
+	return &RowError{
+		Table:     t.Name,
+		Column:    t.Columns[i],
+		RowNum:    state.RowNum,
+		SubRowNum: state.SubRowNum,
+		Expr:      t.Exprs[i],
+		Cause:     err,
+	}
+}
+
+// RowError is an error evaluating a column of a table.
+//
+// Its position is that of the top-level expression of the column, not of
+// the sub-expression which failed, since compiled expressions do not keep
+// their source positions.
+type RowError struct {
+	Table     *template.QName
+	Column    template.Name
+	RowNum    int64
+	SubRowNum int64
+	// Expr is the source expression of the column.
+	Expr  template.Expr
+	Cause error
+}
+
+func (e *RowError) Error() string {
+	var at string
+	if pos := e.Expr.Position(); pos.IsValid() {
+		at = fmt.Sprintf(" on line %d at column %d", pos.Line, pos.Column)
+	}
+	return fmt.Sprintf("error in column %s of table %s at row %d (subrow %d)%s, evaluating {{ %s }}: %v",
+		e.Column, e.Table, e.RowNum, e.SubRowNum, at, e.Expr, e.Cause)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Cause
+}
+
 // Compiled is a compiled expression.
 type Compiled interface {
 	// Eval evaluates a compiled expression and updates the state. Returns the evaluated value.
@@ -314,11 +562,33 @@ type (
 	SubRowNum struct{}
 	// Constant is a evaluated constant.
 	Constant struct{ Value constant.Value }
-	// RawFunction is a function that has not been compiled.
+	// FunctionCall is a call of a pure function.
+	FunctionCall struct {
+		Fn   PureFunction
+		Args []Compiled
+		// values holds the evaluated arguments, reused across rows.
+		values Arguments
+		// datums holds the arguments evaluated by EvalDatum.
+		datums []constant.Datum
+	}
+	// RawFunction is a function that is bound again on every row because some
+	// of its arguments are not constant.
 	RawFunction struct {
-		Fn   Function
+		Fn   BindFunction
 		Args []Compiled
+		// values holds the evaluated arguments, reused across rows.
+		values Arguments
 	}
+	// RawLambdaFunction is a higher-order function that has not been compiled.
+	RawLambdaFunction struct {
+		Fn     LambdaFunction
+		Args   []Compiled
+		Lambda *Lambda
+		// values holds the evaluated arguments, reused across rows.
+		values Arguments
+	}
+	// LambdaParam is a reference to a lambda parameter.
+	LambdaParam struct{ Index int }
 	// GetVariable is a local variable reference.
 	GetVariable struct{ Index int }
 	// SetVariable assigns a value to a local variable.
@@ -326,19 +596,42 @@ type (
 		Index int
 		Value Compiled
 	}
-	// CaseValueWhen is a `CASE _ WHEN` expression.
+	// CaseValueWhen is a `CASE _ WHEN` expression. Value is nil for a
+	// searched `CASE WHEN` expression, whose conditions are booleans.
 	CaseValueWhen struct {
 		Value Compiled
 		Whens []*When
 		Else  Compiled
 	}
+	// Logical is a short-circuit `AND` or, if Or is set, `OR` expression.
+	Logical struct {
+		Left  Compiled
+		Right Compiled
+		Or    bool
+	}
+	// Coalesce returns the first non-NULL argument.
+	Coalesce struct{ Args []Compiled }
 	// RandRegex is a regex-based random string.
 	RandRegex struct {
 		Regex *regexp.Regexp
 	}
-	RandUniformU64   struct{}
-	RandUniformI64   struct{}
-	RandUniformF64   struct{}
+	// RandUniformU64 is a random unsigned integer in a range.
+	RandUniformU64 struct {
+		Min uint64
+		// Span is the number of possible values, or 0 for all of them.
+		Span uint64
+	}
+	// RandUniformI64 is a random signed integer in a range.
+	RandUniformI64 struct {
+		Min int64
+		// Span is the number of possible values, or 0 for all of them.
+		Span uint64
+	}
+	// RandUniformF64 is a random float in a range.
+	RandUniformF64 struct {
+		Min, Max  float64
+		Inclusive bool
+	}
 	RandZipf         struct{}
 	RandLogNormal    struct{}
 	RandBool         struct{}
@@ -372,22 +665,66 @@ func (c *Constant) Eval(_ *State) (constant.Value, error) {
 	return c.Value, nil
 }
 
+func (f *FunctionCall) Eval(state *State) (constant.Value, error) {
+	if err := evalArgs(state, f.Args, f.values); err != nil {
+		return nil, err
+	}
+	return f.Fn.Call(state, f.values)
+}
+
 func (r *RawFunction) Eval(state *State) (constant.Value, error) {
-	args := make([]constant.Value, 0, len(r.Args))
-	for _, arg := range r.Args {
-		value, err := arg.Eval(state)
-		if err != nil {
-			return nil, err
-		}
-		args = append(args, value)
+	if err := evalArgs(state, r.Args, r.values); err != nil {
+		return nil, err
 	}
-	c, err := r.Fn.Compile(state.CompileCtx, args)
+	c, err := r.Fn.Compile(state.CompileCtx, r.values)
 	if err != nil {
 		return nil, err
 	}
 	return c.Eval(state)
 }
 
+func (r *RawLambdaFunction) Eval(state *State) (constant.Value, error) {
+	if err := evalArgs(state, r.Args, r.values); err != nil {
+		return nil, err
+	}
+	c, err := r.Fn.Compile(state.CompileCtx, r.values, r.Lambda)
+	if err != nil {
+		return nil, err
+	}
+	return c.Eval(state)
+}
+
+// evalArgs evaluates arguments into values, which has the same length.
+func evalArgs(state *State, args []Compiled, values Arguments) error {
+	for i, arg := range args {
+		value, err := arg.Eval(state)
+		if err != nil {
+			return err
+		}
+		values[i] = value
+	}
+	return nil
+}
+
+func (p *LambdaParam) Eval(state *State) (constant.Value, error) {
+	return state.CompileCtx.lambdaArgs[p.Index], nil
+}
+
+// Lambda is a compiled lambda expression. Its parameters are bound to the
+// slots Params of the lambda arguments.
+type Lambda struct {
+	Params []int
+	Body   Compiled
+}
+
+// Call evaluates the body of the lambda with the given arguments.
+func (l *Lambda) Call(state *State, args ...constant.Value) (constant.Value, error) {
+	for i, slot := range l.Params {
+		state.CompileCtx.lambdaArgs[slot] = args[i]
+	}
+	return l.Body.Eval(state)
+}
+
 func (g *GetVariable) Eval(state *State) (constant.Value, error) {
 	return state.CompileCtx.Variables[g.Index].B, nil
 }
@@ -401,24 +738,118 @@ func (s *SetVariable) Eval(state *State) (constant.Value, error) {
 	return value, nil
 }
 
-func (c *CaseValueWhen) Eval(_ *State) (constant.Value, error) {
-	panic("unimplemented")
+func (c *CaseValueWhen) Eval(state *State) (constant.Value, error) {
+	var value constant.Value
+	if c.Value != nil {
+		var err error
+		if value, err = c.Value.Eval(state); err != nil {
+			return nil, err
+		}
+	}
+	for _, when := range c.Whens {
+		var matched bool
+		if c.Value == nil {
+			cond, isNull, err := evalBool(when.Cond, state)
+			if err != nil {
+				return nil, err
+			}
+			matched = cond && !isNull
+		} else {
+			cond, err := when.Cond.Eval(state)
+			if err != nil {
+				return nil, err
+			}
+			cmp, isNull, err := sessionCmp(value, cond, state.CompileCtx.TimeZone)
+			if err != nil {
+				return nil, err
+			}
+			matched = cmp == 0 && !isNull
+		}
+		if matched {
+			return when.Then.Eval(state)
+		}
+	}
+	if c.Else == nil {
+		return constant.Null, nil
+	}
+	return c.Else.Eval(state)
+}
+
+// Eval implements the three-valued logic of SQL: the result is NULL unless
+// an operand decides it.
+func (l *Logical) Eval(state *State) (constant.Value, error) {
+	left, leftNull, err := evalBool(l.Left, state)
+	if err != nil {
+		return nil, err
+	}
+	if !leftNull && left == l.Or {
+		return constant.MakeBool(left), nil
+	}
+	right, rightNull, err := evalBool(l.Right, state)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case !rightNull && right == l.Or:
+		return constant.MakeBool(right), nil
+	case leftNull || rightNull:
+		return constant.Null, nil
+	default:
+		return constant.MakeBool(!l.Or), nil
+	}
+}
+
+func (c *Coalesce) Eval(state *State) (constant.Value, error) {
+	for _, arg := range c.Args {
+		value, err := arg.Eval(state)
+		if err != nil {
+			return nil, err
+		}
+		if value != constant.Null {
+			return value, nil
+		}
+	}
+	return constant.Null, nil
+}
+
+// evalBool evaluates a boolean expression, reporting NULL as isNull.
+func evalBool(compiled Compiled, state *State) (_ bool, isNull bool, _ error) {
+	value, err := compiled.Eval(state)
+	if err != nil {
+		return false, false, err
+	}
+	if value == constant.Null {
+		return false, true, nil
+	}
+	b, err := constant.AsBool(value)
+	return b, false, err
 }
 
 func (r *RandRegex) Eval(state *State) (constant.Value, error) {
 	panic("unimplemented")
 }
 
-func (*RandUniformU64) Eval(state *State) (constant.Value, error) {
-	panic("unimplemented")
+func (r *RandUniformU64) Eval(state *State) (constant.Value, error) {
+	n := r.Min + randSpan(state.Rng, r.Span)
+	return constant.MakeInt(new(big.Int).SetUint64(n)), nil
 }
 
-func (*RandUniformI64) Eval(state *State) (constant.Value, error) {
-	panic("unimplemented")
+func (r *RandUniformI64) Eval(state *State) (constant.Value, error) {
+	// The offset wraps around like the two's complement of the result.
+	return constant.MakeInt64(r.Min + int64(randSpan(state.Rng, r.Span))), nil
 }
 
-func (*RandUniformF64) Eval(state *State) (constant.Value, error) {
-	panic("unimplemented")
+func (r *RandUniformF64) Eval(state *State) (constant.Value, error) {
+	if r.Inclusive {
+		u := float64(state.Rng.Uint64()>>11) / (1<<53 - 1)
+		return constant.MakeFloat(r.Min + u*(r.Max-r.Min)), nil
+	}
+	x := r.Min + randFloat64(state.Rng)*(r.Max-r.Min)
+	if x >= r.Max {
+		// Rounding may reach the excluded bound.
+		x = math.Nextafter(r.Max, r.Min)
+	}
+	return constant.MakeFloat(x), nil
 }
 
 func (*RandZipf) Eval(state *State) (constant.Value, error) {
@@ -450,5 +881,82 @@ func (*RandShuffle) Eval(state *State) (constant.Value, error) {
 }
 
 func (*RandUuid) Eval(state *State) (constant.Value, error) {
-	panic("unimplemented")
+	var uuid [16]byte
+	randRead(state.Rng, uuid[:])
+	uuid[6] = uuid[6]&0x0f | 0x40 // version 4
+	uuid[8] = uuid[8]&0x3f | 0x80 // variant 10
+	return constant.MakeBytes(formatUuid(uuid)), nil
+}
+
+// randUint64n returns a uniformly distributed random number in [0, n).
+// It panics if n is zero.
+func randUint64n(rng rand.Source64, n uint64) uint64 {
+	if n == 0 {
+		panic("invalid argument to randUint64n")
+	}
+	hi, lo := bits.Mul64(rng.Uint64(), n)
+	if lo < n {
+		threshold := -n % n
+		for lo < threshold {
+			hi, lo = bits.Mul64(rng.Uint64(), n)
+		}
+	}
+	return hi
+}
+
+// randSpan returns a uniformly distributed random number in [0, n), or any
+// uint64 if n is zero.
+func randSpan(rng rand.Source64, n uint64) uint64 {
+	if n == 0 {
+		return rng.Uint64()
+	}
+	return randUint64n(rng, n)
+}
+
+// randBigIntn returns a uniformly distributed random number in [0, n).
+// It panics if n <= 0.
+func randBigIntn(rng rand.Source64, n *big.Int) *big.Int {
+	if n.Sign() <= 0 {
+		panic("invalid argument to randBigIntn")
+	}
+	if n.IsUint64() {
+		return new(big.Int).SetUint64(randUint64n(rng, n.Uint64()))
+	}
+	bitLen := n.BitLen()
+	buf := make([]byte, (bitLen+7)/8)
+	result := new(big.Int)
+	for {
+		randRead(rng, buf)
+		buf[0] &= byte(0xff >> (8*len(buf) - bitLen))
+		if result.SetBytes(buf).Cmp(n) < 0 {
+			return result
+		}
+	}
+}
+
+// randIntn returns a uniformly distributed random number in [0, n).
+// It panics if n <= 0.
+func randIntn(rng rand.Source64, n int) int {
+	if n <= 0 {
+		panic("invalid argument to randIntn")
+	}
+	return int(randUint64n(rng, uint64(n)))
+}
+
+// randFloat64 returns a uniformly distributed random number in [0, 1).
+func randFloat64(rng rand.Source64) float64 {
+	return float64(rng.Uint64()>>11) / (1 << 53)
+}
+
+// randRead fills b with random bytes.
+func randRead(rng rand.Source64, b []byte) {
+	var buf [8]byte
+	for len(b) >= 8 {
+		binary.LittleEndian.PutUint64(b, rng.Uint64())
+		b = b[8:]
+	}
+	if len(b) > 0 {
+		binary.LittleEndian.PutUint64(buf[:], rng.Uint64())
+		copy(b, buf[:])
+	}
 }
@@ -1,6 +1,10 @@
 package dbgen_test
 
 import (
+	"errors"
+	"io"
+	"math/rand"
+	"runtime"
 	"testing"
 
 	"github.com/gozssky/dbgen"
@@ -36,3 +40,318 @@ func TestEval(t *testing.T) {
 		})
 	}
 }
+
+func TestEvalReusesArgs(t *testing.T) {
+	ctx := dbgen.NewCompileContext()
+	expr, err := template.ParseExpr("array[rownum, rownum * 2]")
+	require.NoError(t, err)
+	compiled, err := ctx.CompileExpr(expr)
+	require.NoError(t, err)
+	state := &dbgen.State{CompileCtx: ctx}
+	var results []constant.Value
+	for i := int64(1); i <= 2; i++ {
+		state.RowNum = i
+		result, err := compiled.Eval(state)
+		require.NoError(t, err)
+		results = append(results, result)
+	}
+	require.Equal(t, "[1, 2]", results[0].String())
+	require.Equal(t, "[2, 4]", results[1].String())
+}
+
+// rowColumns mix pure and impure expressions evaluated by the rows tests.
+var rowColumns = []string{
+	"rownum",
+	"rownum * 2 + 1",
+	"rownum - 3",
+	"rownum * 1.5",
+	"rownum / 4",
+	"2.5 / rownum",
+	"rownum * 4611686018427387904",
+	"rownum - 9223372036854775807 - 2",
+	"rownum < 3",
+	"rownum * 0.5 >= 1.0",
+	"mod(rownum, 3) = 0",
+	"'a' || rownum",
+	"subrownum",
+	"@x := rownum * 10",
+	"@x + 1",
+	"rand.uuid()",
+	"CASE WHEN rownum > 2 THEN 'big' END",
+	"coalesce(NULL, rownum + 0.5)",
+}
+
+// compileRowColumns compiles rowColumns with a new context.
+func compileRowColumns(t *testing.T) (dbgen.Row, *dbgen.State) {
+	exprs := make([]template.Expr, 0, len(rowColumns))
+	for _, column := range rowColumns {
+		expr, err := template.ParseExpr(column)
+		require.NoError(t, err)
+		exprs = append(exprs, expr)
+	}
+	ctx := dbgen.NewCompileContext()
+	row, err := ctx.CompileRow(exprs)
+	require.NoError(t, err)
+	return row, &dbgen.State{
+		RowNum:     1,
+		SubRowNum:  1,
+		Rng:        rand.NewSource(1).(rand.Source64),
+		CompileCtx: ctx,
+	}
+}
+
+// evalRowColumns evaluates n rows of rowColumns with Row.Eval.
+func evalRowColumns(t *testing.T, n int) [][]constant.Value {
+	row, state := compileRowColumns(t)
+	var rows [][]constant.Value
+	for i := 0; i < n; i++ {
+		values, err := row.Eval(state)
+		require.NoError(t, err)
+		rows = append(rows, values)
+		state.RowNum++
+	}
+	return rows
+}
+
+func TestRowEvalBatch(t *testing.T) {
+	expected := evalRowColumns(t, 5)
+	row, state := compileRowColumns(t)
+	vectors, err := row.EvalBatch(state, 5)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), state.RowNum)
+	require.Equal(t, dbgen.VectorInt64, vectors[1].Kind)
+	require.Equal(t, dbgen.VectorFloat64, vectors[3].Kind)
+	require.Equal(t, dbgen.VectorValues, vectors[6].Kind)
+	for i, vector := range vectors {
+		require.Equal(t, 5, vector.Len())
+		for j := 0; j < 5; j++ {
+			require.Equal(t, expected[j][i], vector.Value(j), rowColumns[i])
+		}
+	}
+}
+
+func TestIsPure(t *testing.T) {
+	when := []*dbgen.When{{Cond: &dbgen.RowNum{}, Then: &dbgen.Constant{Value: constant.MakeInt64(1)}}}
+	require.True(t, dbgen.IsPure(&dbgen.CaseValueWhen{Whens: when}))
+	require.True(t, dbgen.IsPure(&dbgen.CaseValueWhen{Whens: when, Else: &dbgen.SubRowNum{}}))
+	require.False(t, dbgen.IsPure(&dbgen.CaseValueWhen{Whens: when, Else: &dbgen.RandUuid{}}))
+
+	// CASE without ELSE is compiled to a pure expression too.
+	state := newTestState(1)
+	compiled := compileTestExpr(t, state.CompileCtx, "CASE WHEN rownum > 1 THEN rownum END")
+	require.True(t, dbgen.IsPure(compiled))
+}
+
+func TestRowEvalDatums(t *testing.T) {
+	expected := evalRowColumns(t, 5)
+	row, state := compileRowColumns(t)
+	var datums []constant.Datum
+	for i := 0; i < 5; i++ {
+		var err error
+		datums, err = row.EvalDatums(state, datums)
+		require.NoError(t, err)
+		require.Len(t, datums, len(rowColumns))
+		for j, datum := range datums {
+			require.Equal(t, expected[i][j], datum.Value(), rowColumns[j])
+		}
+		state.RowNum++
+	}
+}
+
+func TestTableEvalRowError(t *testing.T) {
+	tmpl, err := template.Parse(`
+CREATE TABLE s.t (
+    id INT {{ rownum }},
+    v  INT {{ 10 /
+              (rownum - 2) }}
+);`)
+	require.NoError(t, err)
+	ctx := dbgen.NewCompileContext()
+	compiled, err := ctx.CompileTemplate(tmpl)
+	require.NoError(t, err)
+	table := compiled.Tables[0]
+	state := &dbgen.State{RowNum: 1, SubRowNum: 1, CompileCtx: ctx}
+	row, err := table.EvalRow(state)
+	require.NoError(t, err)
+	require.Equal(t, "-10", row[1].String())
+
+	state.RowNum = 2
+	_, err = table.EvalRow(state)
+	var rowErr *dbgen.RowError
+	require.True(t, errors.As(err, &rowErr))
+	require.Equal(t, "s.t", rowErr.Table.String())
+	require.Equal(t, "v", rowErr.Column.String())
+	require.Equal(t, int64(2), rowErr.RowNum)
+	require.Equal(t, int64(1), rowErr.SubRowNum)
+	require.Equal(t, "10 / (rownum - 2)", rowErr.Expr.String())
+	require.Equal(t, template.Pos{Line: 4, Column: 18}, rowErr.Expr.Position())
+	var opErr *constant.BinaryOpError
+	require.True(t, errors.As(err, &opErr))
+	require.Contains(t, err.Error(), "error in column v of table s.t at row 2 (subrow 1) on line 4 at column 18, evaluating {{ 10 / (rownum - 2) }}: ")
+}
+
+const benchTemplate = `
+CREATE TABLE users (
+    id         BIGINT       {{ rownum }},
+    uuid       CHAR(36)     {{ rand.uuid() }},
+    login      VARCHAR(40)  {{ 'user_' || lpad(rownum::text, 8, '0') }},
+    name       VARCHAR(40)  {{ initcap(lower('USER ' || rownum)) }},
+    score      DECIMAL      {{ round(rownum * 1.5 + 3, 2) }},
+    parity     VARCHAR(4)   {{ CASE WHEN mod(rownum, 2) = 0 THEN 'even' ELSE 'odd' END }},
+    created_at TIMESTAMP    {{ TIMESTAMP '2020-01-01 00:00:00' + INTERVAL rownum SECOND }},
+    tags       TEXT         {{ array_to_string(array[upper('a'), 'b', rownum::text], ',') }},
+    manager_id BIGINT       {{ coalesce(NULL, greatest(rownum - 1, 1)) }}
+);`
+
+const benchNumericTemplate = `
+CREATE TABLE measurements (
+    id       BIGINT   {{ rownum }},
+    bucket   BIGINT   {{ rownum * 7 + 3 }},
+    value    DOUBLE   {{ rownum * 0.25 - 100 }},
+    ratio    DOUBLE   {{ rownum / 1000 }},
+    is_large BOOLEAN  {{ rownum * 3 > 1000000 }},
+    delta    BIGINT   {{ rownum - 42 }}
+);`
+
+func compileBenchRow(b *testing.B, input string) (dbgen.Row, *dbgen.State) {
+	tmpl, err := template.Parse(input)
+	require.NoError(b, err)
+	ctx := dbgen.NewCompileContext()
+	compiled, err := ctx.CompileTemplate(tmpl)
+	require.NoError(b, err)
+	return compiled.Tables[0].Row, &dbgen.State{
+		Rng:        rand.NewSource(1).(rand.Source64),
+		CompileCtx: ctx,
+	}
+}
+
+func benchmarkEvalRow(b *testing.B, input string) {
+	row, state := compileBenchRow(b, input)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		state.RowNum = int64(i + 1)
+		if _, err := row.Eval(state); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkEvalBatch evaluates b.N rows in batches of 1024 rows, so ns/op is
+// per row like benchmarkEvalRow.
+func benchmarkEvalBatch(b *testing.B, input string) {
+	const batchSize = 1024
+	row, state := compileBenchRow(b, input)
+	b.ReportAllocs()
+	b.ResetTimer()
+	state.RowNum = 1
+	for remaining := b.N; remaining > 0; remaining -= batchSize {
+		n := batchSize
+		if remaining < n {
+			n = remaining
+		}
+		if _, err := row.EvalBatch(state, n); err != nil {
+			b.Fatal(err)
+		}
+		state.RowNum += int64(n)
+	}
+}
+
+func BenchmarkEvalRow(b *testing.B) {
+	benchmarkEvalRow(b, benchTemplate)
+}
+
+func BenchmarkEvalBatch(b *testing.B) {
+	benchmarkEvalBatch(b, benchTemplate)
+}
+
+func BenchmarkEvalRowNumeric(b *testing.B) {
+	benchmarkEvalRow(b, benchNumericTemplate)
+}
+
+func BenchmarkEvalBatchNumeric(b *testing.B) {
+	benchmarkEvalBatch(b, benchNumericTemplate)
+}
+
+// BenchmarkGenerateCSV generates b.N rows of a table with Generator.Generate
+// and writes them with a CSVWriter, once through Emit and once through
+// EmitDatums. The numeric table is evaluated in batches, the other one row by
+// row. Run it with -benchtime 10000000x for a 10M-row run. gcs is the number
+// of garbage collections of the run.
+func BenchmarkGenerateCSV(b *testing.B) {
+	for _, bench := range []struct {
+		name  string
+		input string
+	}{
+		{"Numeric", benchNumericTemplate},
+		{"Mixed", benchTemplate},
+	} {
+		b.Run(bench.name+"/Value", func(b *testing.B) {
+			g, w := newBenchGenerator(b, bench.input)
+			g.Emit = func(_ *dbgen.Table, values []constant.Value) error {
+				for i, value := range values {
+					if i > 0 {
+						_ = w.WriteValueSeparator()
+					}
+					_ = w.WriteValue(value)
+				}
+				return w.WriteRowSeparator()
+			}
+			measureGC(b, func() {
+				if err := g.Generate(1, int64(b.N)); err != nil {
+					b.Fatal(err)
+				}
+			})
+		})
+		b.Run(bench.name+"/Datum", func(b *testing.B) {
+			g, w := newBenchGenerator(b, bench.input)
+			g.EmitDatums = func(_ *dbgen.Table, datums []constant.Datum) error {
+				for i, datum := range datums {
+					if i > 0 {
+						_ = w.WriteValueSeparator()
+					}
+					_ = w.WriteDatum(datum)
+				}
+				return w.WriteRowSeparator()
+			}
+			measureGC(b, func() {
+				if err := g.Generate(1, int64(b.N)); err != nil {
+					b.Fatal(err)
+				}
+			})
+		})
+	}
+}
+
+// newBenchGenerator returns a generator of a template, and a CSVWriter
+// discarding its output.
+func newBenchGenerator(b *testing.B, input string) (*dbgen.Generator, *dbgen.CSVWriter) {
+	tmpl, err := template.Parse(input)
+	require.NoError(b, err)
+	ctx := dbgen.NewCompileContext()
+	compiled, err := ctx.CompileTemplate(tmpl)
+	require.NoError(b, err)
+	g := &dbgen.Generator{
+		Template: compiled,
+		State: &dbgen.State{
+			Rng:        rand.NewSource(1).(rand.Source64),
+			CompileCtx: ctx,
+		},
+	}
+	require.NoError(b, g.Init())
+	return g, dbgen.NewCSVWriter(io.Discard)
+}
+
+// measureGC runs a benchmark and reports its garbage collections.
+func measureGC(b *testing.B, run func()) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	b.ReportAllocs()
+	b.ResetTimer()
+	run()
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.NumGC-before.NumGC), "gcs")
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+}
@@ -0,0 +1,4 @@
+package dbgen
+
+// IsPure exports isPure for the tests.
+var IsPure = isPure
@@ -0,0 +1,163 @@
+// Package fake provides the embedded dictionaries used to generate
+// realistic-looking personal, address and company data.
+package fake
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+)
+
+//go:embed data
+var data embed.FS
+
+// DefaultLocale is the locale used when none is specified.
+const DefaultLocale = "en_US"
+
+// Entry is a dictionary word together with its Latin transliteration.
+type Entry struct {
+	// Text is the word in the locale's native script.
+	Text string
+	// Latin is the ASCII form of the word, used to build e-mail addresses.
+	Latin string
+}
+
+// Locale is the set of dictionaries of a single locale.
+type Locale struct {
+	Name            string
+	FirstNames      []Entry
+	LastNames       []Entry
+	Cities          []string
+	Streets         []string
+	CompanyWords    []string
+	CompanySuffixes []string
+	CompanyFormats  []string
+	AddressFormats  []string
+	PhoneFormats    []string
+	EmailDomains    []string
+}
+
+var (
+	locales   = make(map[string]*Locale)
+	localesMu sync.Mutex
+
+	loadShared   sync.Once
+	loremWords   []string
+	countryCodes []string
+)
+
+// LookupLocale returns the dictionaries of the locale with the given name,
+// e.g. "en_US". Dictionaries are loaded lazily and cached.
+func LookupLocale(name string) (*Locale, error) {
+	localesMu.Lock()
+	defer localesMu.Unlock()
+	if loc, ok := locales[name]; ok {
+		return loc, nil
+	}
+	loc := &Locale{Name: name}
+	if err := loc.load(); err != nil {
+		return nil, err
+	}
+	locales[name] = loc
+	return loc, nil
+}
+
+func (loc *Locale) load() error {
+	dir := path.Join("data", loc.Name)
+	if strings.ContainsAny(loc.Name, "./") || !strings.Contains(loc.Name, "_") {
+		return fmt.Errorf("unknown locale: %s", loc.Name)
+	}
+	if _, err := data.ReadDir(dir); err != nil {
+		return fmt.Errorf("unknown locale: %s", loc.Name)
+	}
+	var err error
+	readEntries := func(name string) []Entry {
+		if err != nil {
+			return nil
+		}
+		var lines []string
+		lines, err = readLines(path.Join(dir, name))
+		entries := make([]Entry, 0, len(lines))
+		for _, line := range lines {
+			text, latin, ok := strings.Cut(line, "\t")
+			if !ok {
+				latin = Transliterate(text)
+			}
+			entries = append(entries, Entry{Text: text, Latin: latin})
+		}
+		return entries
+	}
+	readList := func(name string) []string {
+		if err != nil {
+			return nil
+		}
+		var lines []string
+		lines, err = readLines(path.Join(dir, name))
+		return lines
+	}
+	loc.FirstNames = readEntries("first_names.txt")
+	loc.LastNames = readEntries("last_names.txt")
+	loc.Cities = readList("cities.txt")
+	loc.Streets = readList("streets.txt")
+	loc.CompanyWords = readList("company_words.txt")
+	loc.CompanySuffixes = readList("company_suffixes.txt")
+	loc.CompanyFormats = readList("company_formats.txt")
+	loc.AddressFormats = readList("address_formats.txt")
+	loc.PhoneFormats = readList("phone_formats.txt")
+	loc.EmailDomains = readList("email_domains.txt")
+	return err
+}
+
+// LoremWords returns the lorem ipsum dictionary.
+func LoremWords() []string {
+	loadShared.Do(loadSharedLists)
+	return loremWords
+}
+
+// CountryCodes returns the list of ISO 3166-1 alpha-2 country codes.
+func CountryCodes() []string {
+	loadShared.Do(loadSharedLists)
+	return countryCodes
+}
+
+func loadSharedLists() {
+	var err error
+	if loremWords, err = readLines("data/lorem.txt"); err != nil {
+		panic(err)
+	}
+	if countryCodes, err = readLines("data/country_codes.txt"); err != nil {
+		panic(err)
+	}
+}
+
+func readLines(name string) ([]string, error) {
+	content, err := data.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty dictionary: %s", name)
+	}
+	return lines, scanner.Err()
+}
+
+var transliterations = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss",
+	"Ä", "Ae", "Ö", "Oe", "Ü", "Ue",
+)
+
+// Transliterate converts the German special characters of s to ASCII.
+func Transliterate(s string) string {
+	return transliterations.Replace(s)
+}
@@ -2,10 +2,16 @@ package dbgen
 
 import (
 	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"strings"
 	"time"
@@ -18,25 +24,164 @@ import (
 // Arguments is a list of arguments to a function.
 type Arguments []constant.Value
 
-// Function is a function that can be compiled.
+// Function is a SQL function. It is either a PureFunction or a
+// BindFunction.
 type Function interface {
 	// NumArgs returns the number of arguments the function accepts.
 	// If the function accepts a variable number of arguments, it returns -1.
 	NumArgs() int
-	// Compile compiles or evaluates the function.
+	// Signature returns the kinds of the arguments and of the result, used
+	// to type check templates.
+	Signature() Signature
+}
+
+// Signature declares the kinds of the arguments and of the result of a
+// function. NULL is always accepted and may always be returned, so it is
+// left out of the sets.
+type Signature struct {
+	// Args are the kinds accepted by each argument. The last one also
+	// applies to any further arguments. If it is empty, the arguments are
+	// not checked.
+	Args []constant.KindSet
+	// Result is the set of possible kinds of the result.
+	Result constant.KindSet
+}
+
+// anySignature accepts and returns any kind. It is the signature of the
+// functions which do not declare one.
+var anySignature = Signature{Result: constant.AnyKind}
+
+func signature(result constant.KindSet, args ...constant.KindSet) Signature {
+	return Signature{Args: args, Result: result}
+}
+
+var (
+	boolKinds     = constant.KindSetOf(constant.KindBool)
+	bytesKinds    = constant.KindSetOf(constant.KindBytes)
+	intKinds      = constant.KindSetOf(constant.KindInt)
+	floatKinds    = constant.KindSetOf(constant.KindFloat)
+	arrayKinds    = constant.KindSetOf(constant.KindArray)
+	numericKinds  = constant.KindSetOf(constant.KindInt, constant.KindFloat, constant.KindDecimal)
+	temporalKinds = constant.KindSetOf(constant.KindTimestamp, constant.KindDate, constant.KindTime, constant.KindInterval)
+)
+
+// PureFunction is a function whose result only depends on its arguments. It
+// is called once at compile time if all arguments are constant, and on every
+// row otherwise.
+type PureFunction interface {
+	Function
+	// Call evaluates the function. The arguments are reused between calls,
+	// so the result must not retain them.
+	Call(state *State, args Arguments) (constant.Value, error)
+}
+
+// BindFunction is a function whose result also depends on the state, such as
+// a random function. It is bound to its arguments at compile time, which
+// validates them and returns the expression evaluated on every row. If some
+// arguments are not constant, it is bound again on every row.
+type BindFunction interface {
+	Function
+	// Compile binds the function to its arguments.
 	Compile(ctx *CompileContext, args Arguments) (Compiled, error)
 }
 
+// LazyFunction is a function whose arguments are evaluated on demand, so an
+// argument that does not affect the result is skipped with its side effects.
+// Call is still used to fold calls with constant arguments.
+type LazyFunction interface {
+	PureFunction
+	// CompileLazy compiles the function with unevaluated arguments.
+	CompileLazy(ctx *CompileContext, args []Compiled) (Compiled, error)
+}
+
+// PrepareFunction is a pure function with arguments, such as a pattern, that
+// are expensive to process and usually constant. Prepare processes them once
+// at compile time, which also reports their errors before generation.
+type PrepareFunction interface {
+	PureFunction
+	// Prepare returns the function to call on every row, given the constant
+	// arguments. The arguments which are not constant are nil. It returns
+	// nil if the arguments cannot be prepared, in which case Call is used.
+	Prepare(ctx *CompileContext, consts Arguments) (PureFunction, error)
+}
+
+// callPrepared calls a function by preparing all its arguments. It returns
+// NULL if they cannot be prepared, which only happens for NULL arguments.
+func callPrepared(f PrepareFunction, state *State, args Arguments) (constant.Value, error) {
+	prepared, err := f.Prepare(state.CompileCtx, args)
+	if err != nil || prepared == nil {
+		return constant.Null, err
+	}
+	return prepared.Call(state, args)
+}
+
+// LambdaFunction is a higher-order function, whose last argument is a lambda.
+type LambdaFunction interface {
+	// NumArgs returns the number of arguments, including the lambda.
+	NumArgs() int
+	// NumParams returns the number of parameters of the lambda.
+	NumParams() int
+	// Compile compiles or evaluates the function. The arguments exclude the
+	// lambda.
+	Compile(ctx *CompileContext, args Arguments, lambda *Lambda) (Compiled, error)
+}
+
+var LambdaFuncs = map[string]LambdaFunction{
+	"array_map":      ArrayMapFunc{},
+	"array_filter":   ArrayFilterFunc{},
+	"array_reduce":   ArrayReduceFunc{},
+	"array_generate": ArrayGenerateFunc{},
+}
+
 var GenericFuncs = map[string]Function{
 	"generate_series":        GenerateSeriesFunc{},
 	"encode.hex":             EncodeFunc{Encoding: HexEncoding},
+	"encode.base32":          EncodeFunc{Encoding: Base32Encoding},
 	"encode.base64":          EncodeFunc{Encoding: Base64Encoding},
+	"encode.base64url":       EncodeFunc{Encoding: Base64URLEncoding},
+	"encode.percent":         EncodeFunc{Encoding: PercentEncoding},
 	"decode.hex":             DecodeFunc{Encoding: HexEncoding},
+	"decode.base32":          DecodeFunc{Encoding: Base32Encoding},
 	"decode.base64":          DecodeFunc{Encoding: Base64Encoding},
+	"decode.base64url":       DecodeFunc{Encoding: Base64URLEncoding},
+	"decode.percent":         DecodeFunc{Encoding: PercentEncoding},
+	"hash.md5":               HashFunc{New: md5.New},
+	"hash.sha1":              HashFunc{New: sha1.New},
+	"hash.sha256":            HashFunc{New: sha256.New},
+	"hash.sha512":            HashFunc{New: sha512.New},
+	"hash.crc32":             HashFunc{New: newCrc32, Int: true},
+	"hash.crc64":             HashFunc{New: newCrc64, Int: true},
+	"hash.fnv64":             HashFunc{New: newFnv64, Int: true},
+	"hash.murmur3":           Murmur3Func{},
 	"debug.panic":            PanicFunc{},
 	"least":                  LeastFunc{},
 	"greatest":               GreatestFunc{},
 	"round":                  RoundFunc{},
+	"floor":                  RoundFunc{Mode: RoundFloor},
+	"ceil":                   RoundFunc{Mode: RoundCeil},
+	"trunc":                  RoundFunc{Mode: RoundTrunc},
+	"abs":                    AbsFunc{},
+	"sign":                   SignFunc{},
+	"sqrt":                   FloatFunc{Op: sqrtOp},
+	"cbrt":                   FloatFunc{Op: floatOp(math.Cbrt)},
+	"exp":                    FloatFunc{Op: floatOp(math.Exp)},
+	"ln":                     FloatFunc{Op: logOp(math.Log)},
+	"log10":                  FloatFunc{Op: logOp(math.Log10)},
+	"log":                    LogFunc{},
+	"power":                  PowerFunc{},
+	"sin":                    FloatFunc{Op: floatOp(math.Sin)},
+	"cos":                    FloatFunc{Op: floatOp(math.Cos)},
+	"tan":                    FloatFunc{Op: floatOp(math.Tan)},
+	"asin":                   FloatFunc{Op: unitRangeOp(math.Asin)},
+	"acos":                   FloatFunc{Op: unitRangeOp(math.Acos)},
+	"atan":                   FloatFunc{Op: floatOp(math.Atan)},
+	"atan2":                  Atan2Func{},
+	"degrees":                FloatFunc{Op: floatOp(degreesOp)},
+	"radians":                FloatFunc{Op: floatOp(radiansOp)},
+	"pi":                     PiFunc{},
+	"width_bucket":           WidthBucketFunc{},
+	"gcd":                    GcdFunc{},
+	"lcm":                    GcdFunc{Lcm: true},
 	"div":                    ArithFunc{Op: constant.Div},
 	"mod":                    ArithFunc{Op: constant.Mod},
 	"coalesce":               CoalesceFunc{},
@@ -49,12 +194,80 @@ var GenericFuncs = map[string]Function{
 	"rand.bool":              RandBoolFunc{},
 	"rand.finite_f32":        RandFiniteF32Func{},
 	"rand.finite_f64":        RandFiniteF64Func{},
+	"rand.decimal":           RandDecimalFunc{},
 	"rand.u31_timestamp":     RandU31TimestampFunc{},
+	"rand.timestamp":         RandTimestampFunc{},
+	"rand.date":              RandDateFunc{},
+	"date_trunc":             DateTruncFunc{},
+	"to_char":                ToCharFunc{},
+	"to_timestamp":           ToTimestampFunc{},
+	"epoch":                  ExtractFunc{Field: DateFieldEpoch},
+	"make_timestamp":         MakeTimestampFunc{},
+	"day_of_week":            ExtractFunc{Field: DateFieldDayOfWeek},
 	"rand.uuid":              RandUuidFunc{},
+	"uuid.v7":                UuidV7Func{},
+	"ulid":                   UlidFunc{},
+	"snowflake":              SnowflakeFunc{},
+	"ksuid":                  KsuidFunc{},
 	"rand.regex":             RandRegexFunc{},
 	"rand.shuffle":           RandShuffleFunc{},
+	"rand.ipv4":              RandIPFunc{Prefix: allIPv4},
+	"rand.ipv4_in":           RandIPv4InFunc{},
+	"rand.ipv6":              RandIPFunc{Prefix: allIPv6},
+	"rand.mac":               RandMACFunc{},
+	"rand.hostname":          RandHostnameFunc{},
+	"rand.user_agent":        RandUserAgentFunc{},
 	"char_length":            CharLengthFunc{},
 	"octet_length":           OctetLengthFunc{},
+	"lower":                  LowerFunc{},
+	"upper":                  UpperFunc{},
+	"initcap":                InitcapFunc{},
+	"trim":                   TrimFunc{Left: true, Right: true},
+	"ltrim":                  TrimFunc{Left: true},
+	"rtrim":                  TrimFunc{Right: true},
+	"lpad":                   PadFunc{Left: true},
+	"rpad":                   PadFunc{},
+	"replace":                ReplaceFunc{},
+	"repeat":                 RepeatFunc{},
+	"reverse":                ReverseFunc{},
+	"split_part":             SplitPartFunc{},
+	"string_to_array":        StringToArrayFunc{},
+	"array_to_string":        ArrayToStringFunc{},
+	"format":                 FormatFunc{},
+	"regexp_like":            RegexpLikeFunc{},
+	"regexp_replace":         RegexpReplaceFunc{},
+	"regexp_substr":          RegexpSubstrFunc{},
+	"regexp_matches":         RegexpMatchesFunc{},
+	"cardinality":            CardinalityFunc{},
+	"array_length":           ArrayLengthFunc{},
+	"array_append":           ArrayAppendFunc{},
+	"array_prepend":          ArrayPrependFunc{},
+	"array_cat":              ArrayCatFunc{},
+	"array_slice":            ArraySliceFunc{},
+	"array_position":         ArrayPositionFunc{},
+	"array_contains":         ArrayContainsFunc{},
+	"array_distinct":         ArrayDistinctFunc{},
+	"array_sort":             ArraySortFunc{},
+	"array_sum":              ArraySumFunc{},
+	"array_min":              ArrayExtremumFunc{Order: -1},
+	"array_max":              ArrayExtremumFunc{Order: 1},
+	"rand.subset":            RandSubsetFunc{},
+	"json":                   JSONFunc{},
+	"to_json":                ToJSONFunc{},
+	"json_object":            JSONObjectFunc{},
+	"json_array":             JSONArrayFunc{},
+	"json_build_from_array":  JSONBuildFromArrayFunc{},
+	"json_extract":           JSONExtractFunc{},
+	"fake.first_name":        FakeFunc{Field: FakeFirstName},
+	"fake.last_name":         FakeFunc{Field: FakeLastName},
+	"fake.email":             FakeFunc{Field: FakeEmail},
+	"fake.phone":             FakeFunc{Field: FakePhone},
+	"fake.street_address":    FakeFunc{Field: FakeStreetAddress},
+	"fake.city":              FakeFunc{Field: FakeCity},
+	"fake.company":           FakeFunc{Field: FakeCompany},
+	"fake.country_code":      FakeCountryCodeFunc{},
+	"fake.lorem_words":       FakeLoremWordsFunc{},
+	"fake.lorem_sentence":    FakeLoremSentenceFunc{},
 }
 
 var UnaryFuncs = map[template.Op]Function{
@@ -74,36 +287,56 @@ var BinaryFuncs = map[template.Op]Function{
 	template.OpBitAnd:   BitwiseFunc{Op: template.OpBitAnd},
 	template.OpBitOr:    BitwiseFunc{Op: template.OpBitOr},
 	template.OpBitXor:   BitwiseFunc{Op: template.OpBitXor},
-	template.OpAdd:      ArithFunc{Op: constant.Add},
-	template.OpSub:      ArithFunc{Op: constant.Sub},
-	template.OpMul:      ArithFunc{Op: constant.Mul},
-	template.OpFloatDiv: ArithFunc{Op: constant.FloatDiv},
+	template.OpAdd:      ArithFunc{Op: constant.Add, FastOp: template.OpAdd},
+	template.OpSub:      ArithFunc{Op: constant.Sub, FastOp: template.OpSub},
+	template.OpMul:      ArithFunc{Op: constant.Mul, FastOp: template.OpMul},
+	template.OpFloatDiv: ArithFunc{Op: constant.FloatDiv, FastOp: template.OpFloatDiv},
 	template.OpConcat:   ConcatFunc{},
+	template.OpAnd:      LogicalAndFunc{},
+	template.OpOr:       LogicalOrFunc{},
 }
 
 type noArg struct{}
 
 func (noArg) NumArgs() int { return 0 }
 
+func (noArg) Signature() Signature { return anySignature }
+
 type oneArg struct{}
 
 func (oneArg) NumArgs() int { return 1 }
 
+func (oneArg) Signature() Signature { return anySignature }
+
 type twoArgs struct{}
 
 func (twoArgs) NumArgs() int { return 2 }
 
+func (twoArgs) Signature() Signature { return anySignature }
+
+type threeArgs struct{}
+
+func (threeArgs) NumArgs() int { return 3 }
+
+func (threeArgs) Signature() Signature { return anySignature }
+
 type varArgs struct{}
 
 func (varArgs) NumArgs() int { return -1 }
 
+func (varArgs) Signature() Signature { return anySignature }
+
 // ArrayFunc constructs a array.
 type ArrayFunc struct {
 	varArgs
 }
 
-func (ArrayFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
-	return &Constant{constant.MakeArray(args)}, nil
+func (ArrayFunc) Signature() Signature {
+	return signature(arrayKinds)
+}
+
+func (ArrayFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	return constant.MakeArray(append([]constant.Value(nil), args...)), nil
 }
 
 // SubscriptFunc subscript a array.
@@ -111,7 +344,11 @@ type SubscriptFunc struct {
 	twoArgs
 }
 
-func (SubscriptFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+func (SubscriptFunc) Signature() Signature {
+	return signature(constant.AnyKind, arrayKinds, intKinds)
+}
+
+func (SubscriptFunc) Call(_ *State, args Arguments) (constant.Value, error) {
 	base, err := constant.AsArray(args[0])
 	if err != nil {
 		return nil, err
@@ -120,16 +357,16 @@ func (SubscriptFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error
 		return nil, fmt.Errorf("subscript must be an integer, got %s", args[1].Kind())
 	}
 	if !constant.IsInt64(args[1]) {
-		return &Constant{constant.Null}, nil
+		return constant.Null, nil
 	}
 	index, err := constant.AsInt64(args[1])
 	if err != nil {
 		return nil, err
 	}
 	if index <= 0 || index > int64(len(base)) {
-		return &Constant{constant.Null}, nil
+		return constant.Null, nil
 	}
-	return &Constant{base[index-1]}, nil
+	return base[index-1], nil
 }
 
 // GenerateSeriesFunc implements the `generate_series` SQL function.
@@ -137,7 +374,7 @@ type GenerateSeriesFunc struct {
 	varArgs
 }
 
-func (GenerateSeriesFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+func (GenerateSeriesFunc) Call(_ *State, args Arguments) (constant.Value, error) {
 	if len(args) < 2 {
 		return nil, fmt.Errorf("generate_series requires at least 2 arguments, got %d", len(args))
 	}
@@ -177,7 +414,7 @@ func (GenerateSeriesFunc) Compile(_ *CompileContext, args Arguments) (Compiled,
 			return nil, err
 		}
 	}
-	return &Constant{constant.MakeArray(result)}, nil
+	return constant.MakeArray(result), nil
 }
 
 // Encoding is an interface for encoding and decoding byte slices.
@@ -195,10 +432,19 @@ type Encoding interface {
 }
 
 var (
-	HexEncoding    Encoding = hexEncoding{}
-	Base64Encoding Encoding = base64.StdEncoding
+	HexEncoding       Encoding = hexEncoding{}
+	Base32Encoding    Encoding = base32.StdEncoding
+	Base64Encoding    Encoding = base64.StdEncoding
+	Base64URLEncoding Encoding = base64.RawURLEncoding
+	PercentEncoding   Encoding = percentEncoding{}
 )
 
+// appendEncoding is an Encoding whose encoded length depends on the content
+// rather than only on the length of the input.
+type appendEncoding interface {
+	AppendEncode(dst, src []byte) []byte
+}
+
 type hexEncoding struct{}
 
 func (hexEncoding) Encode(dst, src []byte) {
@@ -217,20 +463,76 @@ func (hexEncoding) DecodedLen(n int) int {
 	return hex.DecodedLen(n)
 }
 
+// percentEncoding is the URL percent-encoding of RFC 3986. All bytes except
+// the unreserved characters are encoded.
+type percentEncoding struct{}
+
+func isUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func (percentEncoding) AppendEncode(dst, src []byte) []byte {
+	const upperhex = "0123456789ABCDEF"
+	for _, c := range src {
+		if isUnreserved(c) {
+			dst = append(dst, c)
+		} else {
+			dst = append(dst, '%', upperhex[c>>4], upperhex[c&15])
+		}
+	}
+	return dst
+}
+
+func (e percentEncoding) Encode(dst, src []byte) {
+	copy(dst, e.AppendEncode(nil, src))
+}
+
+func (percentEncoding) EncodedLen(n int) int {
+	return n * 3
+}
+
+func (percentEncoding) Decode(dst, src []byte) (int, error) {
+	n := 0
+	for i := 0; i < len(src); i++ {
+		if src[i] != '%' {
+			dst[n] = src[i]
+			n++
+			continue
+		}
+		if i+2 >= len(src) {
+			return n, fmt.Errorf("invalid percent-encoding at offset %d", i)
+		}
+		if _, err := hex.Decode(dst[n:n+1], src[i+1:i+3]); err != nil {
+			return n, fmt.Errorf("invalid percent-encoding at offset %d", i)
+		}
+		n++
+		i += 2
+	}
+	return n, nil
+}
+
+func (percentEncoding) DecodedLen(n int) int {
+	return n
+}
+
 // EncodeFunc implements the `encode.*` SQL function.
 type EncodeFunc struct {
 	oneArg
 	Encoding Encoding
 }
 
-func (enc EncodeFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+func (enc EncodeFunc) Call(_ *State, args Arguments) (constant.Value, error) {
 	src, err := constant.AsBytes(args[0])
 	if err != nil {
 		return nil, err
 	}
+	if e, ok := enc.Encoding.(appendEncoding); ok {
+		return constant.MakeBytes(e.AppendEncode(make([]byte, 0, len(src)), src)), nil
+	}
 	dst := make([]byte, enc.Encoding.EncodedLen(len(src)))
 	enc.Encoding.Encode(dst, src)
-	return &Constant{constant.MakeBytes(dst)}, nil
+	return constant.MakeBytes(dst), nil
 }
 
 // DecodeFunc implements the `decode.*` SQL function.
@@ -239,7 +541,7 @@ type DecodeFunc struct {
 	Encoding Encoding
 }
 
-func (dec DecodeFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+func (dec DecodeFunc) Call(_ *State, args Arguments) (constant.Value, error) {
 	src, err := constant.AsBytes(args[0])
 	if err != nil {
 		return nil, err
@@ -249,14 +551,14 @@ func (dec DecodeFunc) Compile(_ *CompileContext, args Arguments) (Compiled, erro
 	if err != nil {
 		return nil, err
 	}
-	return &Constant{constant.MakeBytes(dst[:n])}, nil
+	return constant.MakeBytes(dst[:n]), nil
 }
 
 type PanicFunc struct {
 	varArgs
 }
 
-func (PanicFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+func (PanicFunc) Call(_ *State, args Arguments) (constant.Value, error) {
 	var sb strings.Builder
 	sb.WriteString("runtime panic: ")
 	for i, arg := range args {
@@ -269,12 +571,17 @@ type NegFunc struct {
 	oneArg
 }
 
-func (NegFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
+func (NegFunc) Signature() Signature {
+	kinds := numericKinds | constant.KindSetOf(constant.KindInterval)
+	return signature(kinds, kinds)
+}
+
+func (NegFunc) Call(_ *State, args Arguments) (constant.Value, error) {
 	result, err := constant.Neg(args[0])
 	if err != nil {
 		return nil, err
 	}
-	return &Constant{result}, nil
+	return result, nil
 }
 
 // CompareFunc implements the value comparison (`<`, `=`, `>`, `<=`, `<>`, `>=`) SQL functions.
@@ -288,28 +595,49 @@ type CompareFunc struct {
 	GT bool
 }
 
-func (c CompareFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
-	cmp, isNull, err := constant.Cmp(args[0], args[1])
+func (CompareFunc) Signature() Signature {
+	return signature(boolKinds)
+}
+
+func (c CompareFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	cmp, isNull, err := sessionCmp(args[0], args[1], state.CompileCtx.TimeZone)
 	if err != nil {
 		return nil, err
 	}
 	if isNull {
-		return &Constant{constant.Null}, nil
+		return constant.Null, nil
 	}
 	switch cmp {
 	case -1:
-		return &Constant{constant.MakeBool(c.LT)}, nil
+		return constant.MakeBool(c.LT), nil
 	case 0:
-		return &Constant{constant.MakeBool(c.EQ)}, nil
+		return constant.MakeBool(c.EQ), nil
 	default:
-		return &Constant{constant.MakeBool(c.GT)}, nil
+		return constant.MakeBool(c.GT), nil
 	}
 }
 
+// sessionCmp compares two values like constant.Cmp, except that a date
+// compared with a timestamp is midnight in loc, the session time zone, as
+// when it is cast to a timestamp.
+func sessionCmp(a, b constant.Value, loc *time.Location) (_ int, isNull bool, _ error) {
+	var err error
+	switch {
+	case a.Kind() == constant.KindDate && b.Kind() == constant.KindTimestamp:
+		a, err = constant.Convert(a, constant.Type{Kind: constant.KindTimestamp}, loc)
+	case a.Kind() == constant.KindTimestamp && b.Kind() == constant.KindDate:
+		b, err = constant.Convert(b, constant.Type{Kind: constant.KindTimestamp}, loc)
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return constant.Cmp(a, b)
+}
+
 // IsFunc implements the 'IS' SQL function.
 type IsFunc struct{}
 
-func (IsFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
+func (IsFunc) Call(_ *State, args Arguments) (constant.Value, error) {
 	panic("unimplemented")
 }
 
@@ -318,7 +646,11 @@ type IsNotFunc struct {
 	oneArg
 }
 
-func (IsNotFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
+func (IsNotFunc) Signature() Signature {
+	return signature(boolKinds)
+}
+
+func (IsNotFunc) Call(_ *State, args Arguments) (constant.Value, error) {
 	panic("unimplemented")
 }
 
@@ -327,7 +659,11 @@ type NotFunc struct {
 	oneArg
 }
 
-func (NotFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
+func (NotFunc) Signature() Signature {
+	return signature(boolKinds, boolKinds)
+}
+
+func (NotFunc) Call(_ *State, args Arguments) (constant.Value, error) {
 	panic("unimplemented")
 }
 
@@ -336,7 +672,11 @@ type BitNotFunc struct {
 	oneArg
 }
 
-func (BitNotFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
+func (BitNotFunc) Signature() Signature {
+	return signature(intKinds, intKinds)
+}
+
+func (BitNotFunc) Call(_ *State, args Arguments) (constant.Value, error) {
 	panic("unimplemented")
 }
 
@@ -346,20 +686,24 @@ type BitwiseFunc struct {
 	Op template.Op
 }
 
-func (b BitwiseFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+func (BitwiseFunc) Signature() Signature {
+	return signature(intKinds, intKinds, intKinds)
+}
+
+func (b BitwiseFunc) Call(_ *State, args Arguments) (constant.Value, error) {
 	if args[0] == constant.Null || args[1] == constant.Null {
-		return &Constant{constant.Null}, nil
+		return constant.Null, nil
 	}
 	if constant.IsInt64(args[0]) && constant.IsInt64(args[1]) {
 		x, _ := constant.AsInt64(args[0])
 		y, _ := constant.AsInt64(args[1])
 		switch b.Op {
 		case template.OpBitAnd:
-			return &Constant{constant.MakeInt64(x & y)}, nil
+			return constant.MakeInt64(x & y), nil
 		case template.OpBitOr:
-			return &Constant{constant.MakeInt64(x | y)}, nil
+			return constant.MakeInt64(x | y), nil
 		case template.OpBitXor:
-			return &Constant{constant.MakeInt64(x ^ y)}, nil
+			return constant.MakeInt64(x ^ y), nil
 		default:
 			return nil, fmt.Errorf("unknown bitwise operator: %v", b.Op)
 		}
@@ -374,42 +718,122 @@ func (b BitwiseFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error
 	}
 	switch b.Op {
 	case template.OpBitAnd:
-		return &Constant{constant.MakeInt(new(big.Int).And(x, y))}, nil
+		return constant.MakeInt(new(big.Int).And(x, y)), nil
 	case template.OpBitOr:
-		return &Constant{constant.MakeInt(new(big.Int).Or(x, y))}, nil
+		return constant.MakeInt(new(big.Int).Or(x, y)), nil
 	case template.OpBitXor:
-		return &Constant{constant.MakeInt(new(big.Int).Xor(x, y))}, nil
+		return constant.MakeInt(new(big.Int).Xor(x, y)), nil
 	default:
 		return nil, fmt.Errorf("unknown bitwise operator: %v", b.Op)
 	}
 }
 
-// LogicalAndFunc implements the 'AND' SQL function.
-type LogicalAndFunc struct{}
+// LogicalAndFunc implements the 'AND' SQL function. The right operand is
+// not evaluated if the left one is FALSE.
+type LogicalAndFunc struct {
+	twoArgs
+}
 
-func (LogicalAndFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (LogicalAndFunc) Signature() Signature {
+	return signature(boolKinds, boolKinds, boolKinds)
 }
 
-// LogicalOrFunc implements the 'OR' SQL function.
-type LogicalOrFunc struct{}
+func (LogicalAndFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	return (&Logical{Left: &Constant{args[0]}, Right: &Constant{args[1]}}).Eval(nil)
+}
 
-func (LogicalOrFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (LogicalAndFunc) CompileLazy(_ *CompileContext, args []Compiled) (Compiled, error) {
+	return compileLogical(&Logical{Left: args[0], Right: args[1]})
+}
+
+// LogicalOrFunc implements the 'OR' SQL function. The right operand is not
+// evaluated if the left one is TRUE.
+type LogicalOrFunc struct {
+	twoArgs
+}
+
+func (LogicalOrFunc) Signature() Signature {
+	return signature(boolKinds, boolKinds, boolKinds)
+}
+
+func (LogicalOrFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	return (&Logical{Left: &Constant{args[0]}, Right: &Constant{args[1]}, Or: true}).Eval(nil)
+}
+
+func (LogicalOrFunc) CompileLazy(_ *CompileContext, args []Compiled) (Compiled, error) {
+	return compileLogical(&Logical{Left: args[0], Right: args[1], Or: true})
+}
+
+// compileLogical folds a logical operation whose left operand is a constant
+// that decides the result.
+func compileLogical(l *Logical) (Compiled, error) {
+	if !IsConstant(l.Left) {
+		return l, nil
+	}
+	left, isNull, err := evalBool(l.Left, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !isNull && left == l.Or {
+		return &Constant{constant.MakeBool(left)}, nil
+	}
+	return l, nil
 }
 
 // ArithFunc implements the arithmetic (`+`, `-`, `*`, `/`, div, mod) SQL functions.
 type ArithFunc struct {
 	twoArgs
 	Op func(constant.Value, constant.Value) (constant.Value, error)
+	// FastOp is the operator of Op, if it has fast paths for unboxed numbers.
+	FastOp template.Op
 }
 
-func (a ArithFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
-	result, err := a.Op(args[0], args[1])
+func (ArithFunc) Signature() Signature {
+	return signature(numericKinds|temporalKinds, numericKinds|temporalKinds, numericKinds|temporalKinds)
+}
+
+func (a ArithFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	x, y := args[0], args[1]
+	if x.Kind() == constant.KindDate || y.Kind() == constant.KindDate {
+		var err error
+		if x, y, err = dateTimeOperands(x, y, state.CompileCtx.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+	result, err := a.Op(x, y)
 	if err != nil {
 		return nil, err
 	}
-	return &Constant{result}, nil
+	return result, nil
+}
+
+// dateTimeOperands converts a date combined with a time of day or an interval
+// to a timestamp at midnight in loc, the session time zone, as a cast does,
+// and the time of day to an interval. Other operands are returned as is.
+func dateTimeOperands(x, y constant.Value, loc *time.Location) (constant.Value, constant.Value, error) {
+	if x.Kind() != constant.KindDate {
+		if y.Kind() != constant.KindDate {
+			return x, y, nil
+		}
+		y, x, err := dateTimeOperands(y, x, loc)
+		return x, y, err
+	}
+	switch y.Kind() {
+	case constant.KindTime:
+		d, err := constant.AsTimeOfDay(y)
+		if err != nil {
+			return nil, nil, err
+		}
+		y = constant.MakeInterval(d)
+	case constant.KindInterval:
+	default:
+		return x, y, nil
+	}
+	x, err := constant.Convert(x, constant.Type{Kind: constant.KindTimestamp}, loc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return x, y, nil
 }
 
 // GreatestFunc implements the 'greatest' SQL function.
@@ -417,8 +841,8 @@ type GreatestFunc struct {
 	varArgs
 }
 
-func (GreatestFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (GreatestFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	return extremum(args, 1, state.CompileCtx.TimeZone)
 }
 
 // LeastFunc implements the 'least' SQL function.
@@ -426,69 +850,225 @@ type LeastFunc struct {
 	varArgs
 }
 
-func (LeastFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (LeastFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	return extremum(args, -1, state.CompileCtx.TimeZone)
 }
 
-// RoundFunc implements the 'round' SQL function.
+// extremum returns the greatest (order = 1) or least (order = -1) non-NULL
+// argument, or NULL if all arguments are NULL. Dates are compared with
+// timestamps in loc, the session time zone.
+func extremum(args Arguments, order int, loc *time.Location) (constant.Value, error) {
+	result := constant.Null
+	for _, arg := range args {
+		if arg == constant.Null {
+			continue
+		}
+		if result == constant.Null {
+			result = arg
+			continue
+		}
+		cmp, _, err := sessionCmp(arg, result, loc)
+		if err != nil {
+			return nil, err
+		}
+		if cmp == order {
+			result = arg
+		}
+	}
+	return result, nil
+}
+
+// RoundFunc implements the 'round', 'floor', 'ceil' and 'trunc' SQL
+// functions. The optional second argument is the number of decimal digits to
+// keep, which may be negative.
 type RoundFunc struct {
-	oneArg
+	varArgs
+	Mode RoundMode
 }
 
-func (RoundFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (f RoundFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("rounding functions require 1 or 2 arguments, got %d", len(args))
+	}
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	digits := int64(0)
+	if len(args) == 2 {
+		var err error
+		if digits, err = constant.AsInt64(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	result, err := roundValue(args[0], digits, f.Mode)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-// CoalesceFunc implements the 'coalesce' SQL function.
+// CoalesceFunc implements the 'coalesce' SQL function. Arguments after the
+// first non-NULL one are not evaluated.
 type CoalesceFunc struct {
 	varArgs
 }
 
-func (CoalesceFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (CoalesceFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	for _, arg := range args {
+		if arg != constant.Null {
+			return arg, nil
+		}
+	}
+	return constant.Null, nil
+}
+
+func (CoalesceFunc) CompileLazy(_ *CompileContext, args []Compiled) (Compiled, error) {
+	// Constant NULLs are skipped, and a constant non-NULL argument ends the
+	// list.
+	var kept []Compiled
+	for _, arg := range args {
+		if c, ok := arg.(*Constant); ok {
+			if c.Value == constant.Null {
+				continue
+			}
+			if len(kept) == 0 {
+				return c, nil
+			}
+			kept = append(kept, c)
+			break
+		}
+		kept = append(kept, arg)
+	}
+	if len(kept) == 0 {
+		return &Constant{constant.Null}, nil
+	}
+	return &Coalesce{Args: kept}, nil
 }
 
 // LastFunc is a function that returns the last value in a list of arguments.
 type LastFunc struct{}
 
-func (LastFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
+func (LastFunc) Call(_ *State, args Arguments) (constant.Value, error) {
 	panic("unimplemented")
 }
 
-// RandRangeFunc implements the 'rand.range' SQL function.
+// RandRangeFunc implements the 'rand.range' SQL function. It returns a
+// uniformly distributed random integer in [lower, upper).
 type RandRangeFunc struct {
 	twoArgs
 }
 
-func (RandRangeFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (RandRangeFunc) Signature() Signature {
+	return signature(intKinds, intKinds, intKinds)
+}
+
+func (RandRangeFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	return compileRandRange("rand.range", args, false)
 }
 
 // RandRangeInclusiveFunc implements the 'rand.range_inclusive' SQL function.
+// It returns a uniformly distributed random integer in [lower, upper].
 type RandRangeInclusiveFunc struct {
 	twoArgs
 }
 
-func (RandRangeInclusiveFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (RandRangeInclusiveFunc) Signature() Signature {
+	return signature(intKinds, intKinds, intKinds)
+}
+
+func (RandRangeInclusiveFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	return compileRandRange("rand.range_inclusive", args, true)
 }
 
-// RandUniformFunc implements the 'rand.uniform' SQL function.
+// compileRandRange compiles a random integer between two bounds, which must
+// both be signed or both be unsigned 64-bit integers.
+func compileRandRange(name string, args Arguments, inclusive bool) (Compiled, error) {
+	if hasNull(args) {
+		return &Constant{constant.Null}, nil
+	}
+	low, err := constant.AsInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	high, err := constant.AsInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if !inclusive {
+		high = new(big.Int).Sub(high, big.NewInt(1))
+	}
+	if low.Cmp(high) > 0 {
+		if inclusive {
+			return nil, fmt.Errorf("%s requires lower <= upper, got %s and %s", name, args[0], args[1])
+		}
+		return nil, fmt.Errorf("%s requires lower < upper, got %s and %s", name, args[0], args[1])
+	}
+	span := new(big.Int).Sub(high, low)
+	span.Add(span, big.NewInt(1))
+	// A span of 2^64 does not fit, and is represented as 0.
+	spanU64 := span.Uint64()
+	switch {
+	case low.IsInt64() && high.IsInt64():
+		return &RandUniformI64{Min: low.Int64(), Span: spanU64}, nil
+	case low.IsUint64() && high.IsUint64():
+		return &RandUniformU64{Min: low.Uint64(), Span: spanU64}, nil
+	default:
+		return nil, fmt.Errorf("%s bounds out of range, got %s and %s", name, args[0], args[1])
+	}
+}
+
+// RandUniformFunc implements the 'rand.uniform' SQL function. It returns a
+// uniformly distributed random float in [lower, upper).
 type RandUniformFunc struct {
 	twoArgs
 }
 
-func (RandUniformFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (RandUniformFunc) Signature() Signature {
+	return signature(floatKinds, numericKinds, numericKinds)
+}
+
+func (RandUniformFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	return compileRandUniform("rand.uniform", args, false)
 }
 
-// RandUniformInclusiveFunc implements the 'rand.uniform_inclusive' SQL function.
+// RandUniformInclusiveFunc implements the 'rand.uniform_inclusive' SQL
+// function. It returns a uniformly distributed random float in
+// [lower, upper].
 type RandUniformInclusiveFunc struct {
 	twoArgs
 }
 
-func (RandUniformInclusiveFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (RandUniformInclusiveFunc) Signature() Signature {
+	return signature(floatKinds, numericKinds, numericKinds)
+}
+
+func (RandUniformInclusiveFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	return compileRandUniform("rand.uniform_inclusive", args, true)
+}
+
+// compileRandUniform compiles a random float between two finite bounds.
+func compileRandUniform(name string, args Arguments, inclusive bool) (Compiled, error) {
+	if hasNull(args) {
+		return &Constant{constant.Null}, nil
+	}
+	low, err := constant.AsFloat(args[0])
+	if err != nil {
+		return nil, err
+	}
+	high, err := constant.AsFloat(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if math.IsInf(high-low, 0) || math.IsNaN(high-low) {
+		return nil, fmt.Errorf("%s requires a finite range, got %s and %s", name, args[0], args[1])
+	}
+	if inclusive && low > high {
+		return nil, fmt.Errorf("%s requires lower <= upper, got %s and %s", name, args[0], args[1])
+	}
+	if !inclusive && low >= high {
+		return nil, fmt.Errorf("%s requires lower < upper, got %s and %s", name, args[0], args[1])
+	}
+	return &RandUniformF64{Min: low, Max: high, Inclusive: inclusive}, nil
 }
 
 // RandZipfFunc implements the 'rand.zipf' SQL function.
@@ -496,6 +1076,10 @@ type RandZipfFunc struct {
 	twoArgs
 }
 
+func (RandZipfFunc) Signature() Signature {
+	return signature(intKinds, intKinds, numericKinds)
+}
+
 func (RandZipfFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
 	panic("unimplemented")
 }
@@ -505,6 +1089,10 @@ type RandLogNormalFunc struct {
 	twoArgs
 }
 
+func (RandLogNormalFunc) Signature() Signature {
+	return signature(floatKinds, numericKinds, numericKinds)
+}
+
 func (RandLogNormalFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
 	panic("unimplemented")
 }
@@ -514,6 +1102,10 @@ type RandBoolFunc struct {
 	noArg
 }
 
+func (RandBoolFunc) Signature() Signature {
+	return signature(boolKinds)
+}
+
 func (RandBoolFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
 	panic("unimplemented")
 }
@@ -550,8 +1142,8 @@ type RandUuidFunc struct {
 	noArg
 }
 
-func (RandUuidFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (RandUuidFunc) Compile(_ *CompileContext, _ Arguments) (Compiled, error) {
+	return &RandUuid{}, nil
 }
 
 // RandRegexFunc implements the 'rand.regex' SQL function.
@@ -578,17 +1170,71 @@ type SubstringFunc struct {
 	Unit template.StringUnit
 }
 
-func (SubstringFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (f SubstringFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := substringRange(args[1], args[2])
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeBytes(unitSlice(input, f.Unit, start, end)), nil
+}
+
+// substringRange computes the 0-based, half-open unit range selected by
+// the 1-based FROM and FOR arguments of the 'substring' SQL function.
+// A NULL argument means the clause is absent.
+func substringRange(from, for_ constant.Value) (start, end int64, err error) {
+	start, end = 0, math.MaxInt64
+	if from != constant.Null {
+		if start, err = constant.AsInt64(from); err != nil {
+			return 0, 0, err
+		}
+		start--
+	}
+	if for_ != constant.Null {
+		length, err := constant.AsInt64(for_)
+		if err != nil {
+			return 0, 0, err
+		}
+		if length < 0 {
+			return 0, 0, fmt.Errorf("negative substring length not allowed: %d", length)
+		}
+		if end = start + length; end < start {
+			end = math.MaxInt64
+		}
+	}
+	return start, end, nil
 }
 
 // CharLengthFunc implements the 'char_length' SQL function.
 type CharLengthFunc struct {
 	oneArg
+	Unit template.StringUnit
 }
 
-func (CharLengthFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (CharLengthFunc) Signature() Signature {
+	return signature(intKinds, bytesKinds)
+}
+
+func (f CharLengthFunc) WithUnit(unit template.StringUnit) Function {
+	f.Unit = unit
+	return f
+}
+
+func (f CharLengthFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeInt64(int64(unitLen(input, f.Unit))), nil
 }
 
 // OctetLengthFunc implements the 'octet_length' SQL function.
@@ -596,8 +1242,19 @@ type OctetLengthFunc struct {
 	oneArg
 }
 
-func (OctetLengthFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (OctetLengthFunc) Signature() Signature {
+	return signature(intKinds, bytesKinds)
+}
+
+func (OctetLengthFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeInt64(int64(len(input))), nil
 }
 
 // OverlayFunc implements the 'overlay' SQL function.
@@ -606,8 +1263,33 @@ type OverlayFunc struct {
 	Unit template.StringUnit
 }
 
-func (OverlayFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (f OverlayFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null || args[1] == constant.Null || args[2] == constant.Null {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	placing, err := constant.AsBytes(args[1])
+	if err != nil {
+		return nil, err
+	}
+	from, err := constant.AsInt64(args[2])
+	if err != nil {
+		return nil, err
+	}
+	length := int64(unitLen(placing, f.Unit))
+	if args[3] != constant.Null {
+		if length, err = constant.AsInt64(args[3]); err != nil {
+			return nil, err
+		}
+	}
+	result := make([]byte, 0, len(input)+len(placing))
+	result = append(result, unitSlice(input, f.Unit, 0, from-1)...)
+	result = append(result, placing...)
+	result = append(result, unitSlice(input, f.Unit, from-1+length, math.MaxInt64)...)
+	return constant.MakeBytes(result), nil
 }
 
 // ConcatFunc implements the '||' SQL function.
@@ -615,27 +1297,58 @@ type ConcatFunc struct {
 	twoArgs
 }
 
-func (ConcatFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	panic("unimplemented")
+func (ConcatFunc) Signature() Signature {
+	return signature(bytesKinds | arrayKinds)
+}
+
+func (ConcatFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null || args[1] == constant.Null {
+		return constant.Null, nil
+	}
+	if args[0].Kind() == constant.KindArray && args[1].Kind() == constant.KindArray {
+		a, _ := constant.AsArray(args[0])
+		b, _ := constant.AsArray(args[1])
+		result := make([]constant.Value, 0, len(a)+len(b))
+		result = append(append(result, a...), b...)
+		return constant.MakeArray(result), nil
+	}
+	a, b := stringify(args[0]), stringify(args[1])
+	result := make([]byte, 0, len(a)+len(b))
+	result = append(append(result, a...), b...)
+	return constant.MakeBytes(result), nil
 }
 
 const timestampFormat = "2006-01-02 15:04:05.999"
 
-// TimestampFunc implements the 'timestamp' SQL function.
-type TimestampFunc struct {
+// CastFunc implements the SQL CAST expression.
+type CastFunc struct {
 	oneArg
+	Type constant.Type
 }
 
-func (TimestampFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
-	input, err := constant.AsBytes(args[0])
-	if err != nil {
-		return nil, err
-	}
-	t, err := time.ParseInLocation(timestampFormat, string(input), ctx.TimeZone)
+func (f CastFunc) Signature() Signature {
+	return signature(constant.KindSetOf(f.Type.Kind))
+}
+
+func (f CastFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	v, err := constant.Convert(args[0], f.Type, state.CompileCtx.TimeZone)
 	if err != nil {
 		return nil, err
 	}
-	return &Constant{constant.MakeTimestamp(t)}, nil
+	return v, nil
+}
+
+// TimestampFunc implements the 'timestamp' SQL function.
+type TimestampFunc struct {
+	oneArg
+}
+
+func (TimestampFunc) Signature() Signature {
+	return signature(constant.KindSetOf(constant.KindTimestamp))
+}
+
+func (TimestampFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	return CastFunc{Type: constant.Type{Kind: constant.KindTimestamp}}.Call(state, args)
 }
 
 // TimestampWithTimeZoneFunc implements the 'timestamp with time zone' SQL function.
@@ -643,14 +1356,18 @@ type TimestampWithTimeZoneFunc struct {
 	oneArg
 }
 
-func (TimestampWithTimeZoneFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
+func (TimestampWithTimeZoneFunc) Signature() Signature {
+	return signature(constant.KindSetOf(constant.KindTimestamp))
+}
+
+func (TimestampWithTimeZoneFunc) Call(state *State, args Arguments) (constant.Value, error) {
 	input, err := constant.AsBytes(args[0])
 	if err != nil {
-		return nil, err
+		return TimestampFunc{}.Call(state, args)
 	}
-	tz := ctx.TimeZone
+	tz := state.CompileCtx.TimeZone
 	if tzIdx := bytes.IndexFunc(input, unicode.IsLetter); tzIdx != -1 {
-		tz, err = ctx.ParseTimeZone(string(input[tzIdx:]))
+		tz, err = state.CompileCtx.ParseTimeZone(string(input[tzIdx:]))
 		if err != nil {
 			return nil, err
 		}
@@ -660,5 +1377,34 @@ func (TimestampWithTimeZoneFunc) Compile(ctx *CompileContext, args Arguments) (C
 	if err != nil {
 		return nil, err
 	}
-	return &Constant{constant.MakeTimestamp(t)}, nil
+	return constant.MakeTimestamp(t), nil
+}
+
+// DateFunc implements the 'date' SQL function, which converts a string or a
+// timestamp to a date.
+type DateFunc struct {
+	oneArg
+}
+
+func (DateFunc) Signature() Signature {
+	return signature(constant.KindSetOf(constant.KindDate))
+}
+
+func (DateFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	return CastFunc{Type: constant.Type{Kind: constant.KindDate}}.Call(state, args)
+}
+
+// TimeFunc implements the 'time' SQL function, which converts a string or a
+// timestamp to a time of day. The seconds may be omitted and may have a
+// fraction.
+type TimeFunc struct {
+	oneArg
+}
+
+func (TimeFunc) Signature() Signature {
+	return signature(constant.KindSetOf(constant.KindTime))
+}
+
+func (TimeFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	return CastFunc{Type: constant.Type{Kind: constant.KindTime}}.Call(state, args)
 }
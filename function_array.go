@@ -0,0 +1,520 @@
+package dbgen
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gozssky/dbgen/constant"
+)
+
+// arrayOrEmpty returns the elements of an array, treating NULL as an empty
+// array.
+func arrayOrEmpty(v constant.Value) ([]constant.Value, error) {
+	if v == constant.Null {
+		return nil, nil
+	}
+	return constant.AsArray(v)
+}
+
+// notDistinct reports whether two values are equal like IS NOT DISTINCT
+// FROM: NULL equals NULL, and values of incomparable kinds are distinct.
+func notDistinct(a, b constant.Value) bool {
+	if a == constant.Null || b == constant.Null {
+		return a == b
+	}
+	cmp, _, err := constant.Cmp(a, b)
+	return err == nil && cmp == 0
+}
+
+// CardinalityFunc implements the 'cardinality' SQL function, which returns
+// the number of elements of an array.
+type CardinalityFunc struct {
+	oneArg
+}
+
+func (CardinalityFunc) Signature() Signature {
+	return signature(intKinds, arrayKinds)
+}
+
+func (CardinalityFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	elems, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeInt64(int64(len(elems))), nil
+}
+
+// ArrayLengthFunc implements the 'array_length' SQL function. Like in
+// PostgreSQL, it returns NULL for an empty array. The optional dimension
+// must be 1.
+type ArrayLengthFunc struct {
+	varArgs
+}
+
+func (ArrayLengthFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("array_length requires 1 or 2 arguments, got %d", len(args))
+	}
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	elems, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 2 {
+		dim, err := constant.AsInt64(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if dim != 1 {
+			return constant.Null, nil
+		}
+	}
+	if len(elems) == 0 {
+		return constant.Null, nil
+	}
+	return constant.MakeInt64(int64(len(elems))), nil
+}
+
+// ArrayAppendFunc implements the 'array_append' SQL function.
+type ArrayAppendFunc struct {
+	twoArgs
+}
+
+func (ArrayAppendFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	elems, err := arrayOrEmpty(args[0])
+	if err != nil {
+		return nil, err
+	}
+	result := make([]constant.Value, 0, len(elems)+1)
+	result = append(append(result, elems...), args[1])
+	return constant.MakeArray(result), nil
+}
+
+// ArrayPrependFunc implements the 'array_prepend' SQL function. As in
+// PostgreSQL, the element comes first: array_prepend(elem, array).
+type ArrayPrependFunc struct {
+	twoArgs
+}
+
+func (ArrayPrependFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	elems, err := arrayOrEmpty(args[1])
+	if err != nil {
+		return nil, err
+	}
+	result := make([]constant.Value, 0, len(elems)+1)
+	result = append(append(result, args[0]), elems...)
+	return constant.MakeArray(result), nil
+}
+
+// ArrayCatFunc implements the 'array_cat' SQL function. A NULL array is
+// treated as empty, unless both arrays are NULL.
+type ArrayCatFunc struct {
+	twoArgs
+}
+
+func (ArrayCatFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null && args[1] == constant.Null {
+		return constant.Null, nil
+	}
+	a, err := arrayOrEmpty(args[0])
+	if err != nil {
+		return nil, err
+	}
+	b, err := arrayOrEmpty(args[1])
+	if err != nil {
+		return nil, err
+	}
+	result := make([]constant.Value, 0, len(a)+len(b))
+	result = append(append(result, a...), b...)
+	return constant.MakeArray(result), nil
+}
+
+// ArraySliceFunc implements the 'array_slice' SQL function and the
+// `array[from:to]` syntax. The bounds are 1-based and inclusive, and a NULL
+// bound means the start or the end of the array.
+type ArraySliceFunc struct {
+	threeArgs
+}
+
+func (ArraySliceFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	elems, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	from, to := int64(1), int64(len(elems))
+	if args[1] != constant.Null {
+		if from, err = constant.AsInt64(args[1]); err != nil {
+			return nil, err
+		}
+	}
+	if args[2] != constant.Null {
+		if to, err = constant.AsInt64(args[2]); err != nil {
+			return nil, err
+		}
+	}
+	if from < 1 {
+		from = 1
+	}
+	if to > int64(len(elems)) {
+		to = int64(len(elems))
+	}
+	if from > to {
+		return constant.MakeArray(nil), nil
+	}
+	return constant.MakeArray(elems[from-1 : to]), nil
+}
+
+// ArrayPositionFunc implements the 'array_position' SQL function, which
+// returns the 1-based index of the first element not distinct from the
+// value, or NULL if there is none.
+type ArrayPositionFunc struct {
+	twoArgs
+}
+
+func (ArrayPositionFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	elems, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	for i, elem := range elems {
+		if notDistinct(elem, args[1]) {
+			return constant.MakeInt64(int64(i + 1)), nil
+		}
+	}
+	return constant.Null, nil
+}
+
+// ArrayContainsFunc implements the 'array_contains' SQL function, which
+// reports whether an array has an element not distinct from the value.
+type ArrayContainsFunc struct {
+	twoArgs
+}
+
+func (ArrayContainsFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	elems, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, elem := range elems {
+		if notDistinct(elem, args[1]) {
+			return constant.MakeBool(true), nil
+		}
+	}
+	return constant.MakeBool(false), nil
+}
+
+// ArrayDistinctFunc implements the 'array_distinct' SQL function, which
+// removes duplicate elements, keeping the first occurrence of each.
+type ArrayDistinctFunc struct {
+	oneArg
+}
+
+func (ArrayDistinctFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	elems, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	var result []constant.Value
+outer:
+	for _, elem := range elems {
+		for _, seen := range result {
+			if notDistinct(elem, seen) {
+				continue outer
+			}
+		}
+		result = append(result, elem)
+	}
+	return constant.MakeArray(result), nil
+}
+
+// ArraySortFunc implements the 'array_sort' SQL function, which sorts the
+// elements in ascending order with NULLs last.
+type ArraySortFunc struct {
+	oneArg
+}
+
+func (ArraySortFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	elems, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	result := append([]constant.Value(nil), elems...)
+	var cmpErr error
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i] == constant.Null || result[j] == constant.Null {
+			return result[j] == constant.Null && result[i] != constant.Null
+		}
+		cmp, _, err := constant.Cmp(result[i], result[j])
+		if err != nil && cmpErr == nil {
+			cmpErr = err
+		}
+		return cmp < 0
+	})
+	if cmpErr != nil {
+		return nil, cmpErr
+	}
+	return constant.MakeArray(result), nil
+}
+
+// ArraySumFunc implements the 'array_sum' SQL function, which adds the
+// non-NULL elements. The sum of no elements is NULL.
+type ArraySumFunc struct {
+	oneArg
+}
+
+func (ArraySumFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	elems, err := arrayOrEmpty(args[0])
+	if err != nil {
+		return nil, err
+	}
+	sum := constant.Null
+	for _, elem := range elems {
+		switch {
+		case elem == constant.Null:
+		case sum == constant.Null:
+			sum = elem
+		default:
+			if sum, err = constant.Add(sum, elem); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sum, nil
+}
+
+// ArrayExtremumFunc implements the 'array_min' (Order = -1) and 'array_max'
+// (Order = 1) SQL functions, which ignore NULL elements.
+type ArrayExtremumFunc struct {
+	oneArg
+	Order int
+}
+
+func (f ArrayExtremumFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	elems, err := arrayOrEmpty(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return extremum(elems, f.Order, state.CompileCtx.TimeZone)
+}
+
+// RandSubsetFunc implements the 'rand.subset' SQL function, which keeps
+// each element of an array with probability p, preserving their order.
+type RandSubsetFunc struct {
+	twoArgs
+}
+
+func (RandSubsetFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	if args[0] == constant.Null {
+		return &Constant{constant.Null}, nil
+	}
+	elems, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	p, err := constant.AsFloat(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if !(p >= 0 && p <= 1) {
+		return nil, fmt.Errorf("rand.subset probability must be between 0 and 1, got %s", args[1])
+	}
+	return &RandSubset{Elems: elems, P: p}, nil
+}
+
+// ArrayMapFunc implements the 'array_map' SQL function, which applies a
+// lambda to each element of an array.
+type ArrayMapFunc struct {
+	twoArgs
+}
+
+func (ArrayMapFunc) NumParams() int { return 1 }
+
+func (ArrayMapFunc) Compile(_ *CompileContext, args Arguments, lambda *Lambda) (Compiled, error) {
+	if args[0] == constant.Null {
+		return &Constant{constant.Null}, nil
+	}
+	elems, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &ArrayMap{Elems: elems, Lambda: lambda}, nil
+}
+
+// ArrayFilterFunc implements the 'array_filter' SQL function, which keeps
+// the elements of an array for which a lambda returns TRUE.
+type ArrayFilterFunc struct {
+	twoArgs
+}
+
+func (ArrayFilterFunc) NumParams() int { return 1 }
+
+func (ArrayFilterFunc) Compile(_ *CompileContext, args Arguments, lambda *Lambda) (Compiled, error) {
+	if args[0] == constant.Null {
+		return &Constant{constant.Null}, nil
+	}
+	elems, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &ArrayFilter{Elems: elems, Lambda: lambda}, nil
+}
+
+// ArrayReduceFunc implements the 'array_reduce' SQL function. Starting from
+// the initial value, it calls a lambda `(acc, x) -> ...` with the result so
+// far and each element in turn.
+type ArrayReduceFunc struct {
+	threeArgs
+}
+
+func (ArrayReduceFunc) NumParams() int { return 2 }
+
+func (ArrayReduceFunc) Compile(_ *CompileContext, args Arguments, lambda *Lambda) (Compiled, error) {
+	if args[0] == constant.Null {
+		return &Constant{constant.Null}, nil
+	}
+	elems, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &ArrayReduce{Elems: elems, Init: args[1], Lambda: lambda}, nil
+}
+
+// ArrayGenerateFunc implements the 'array_generate' SQL function, which
+// builds an array of n elements by calling a lambda with the 1-based index
+// of each element.
+type ArrayGenerateFunc struct {
+	twoArgs
+}
+
+func (ArrayGenerateFunc) NumParams() int { return 1 }
+
+func (ArrayGenerateFunc) Compile(_ *CompileContext, args Arguments, lambda *Lambda) (Compiled, error) {
+	if args[0] == constant.Null {
+		return &Constant{constant.Null}, nil
+	}
+	n, err := constant.AsInt64(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("array_generate length must not be negative, got %d", n)
+	}
+	return &ArrayGenerate{N: n, Lambda: lambda}, nil
+}
+
+// Compiled expression types.
+type (
+	// RandSubset is a random subset of an array.
+	RandSubset struct {
+		Elems []constant.Value
+		// P is the probability of keeping each element.
+		P float64
+	}
+	// ArrayMap is an 'array_map' call.
+	ArrayMap struct {
+		Elems  []constant.Value
+		Lambda *Lambda
+	}
+	// ArrayFilter is an 'array_filter' call.
+	ArrayFilter struct {
+		Elems  []constant.Value
+		Lambda *Lambda
+	}
+	// ArrayReduce is an 'array_reduce' call.
+	ArrayReduce struct {
+		Elems  []constant.Value
+		Init   constant.Value
+		Lambda *Lambda
+	}
+	// ArrayGenerate is an 'array_generate' call.
+	ArrayGenerate struct {
+		N      int64
+		Lambda *Lambda
+	}
+)
+
+func (r *RandSubset) Eval(state *State) (constant.Value, error) {
+	var result []constant.Value
+	for _, elem := range r.Elems {
+		if randFloat64(state.Rng) < r.P {
+			result = append(result, elem)
+		}
+	}
+	return constant.MakeArray(result), nil
+}
+
+func (a *ArrayMap) Eval(state *State) (constant.Value, error) {
+	result := make([]constant.Value, 0, len(a.Elems))
+	for _, elem := range a.Elems {
+		value, err := a.Lambda.Call(state, elem)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return constant.MakeArray(result), nil
+}
+
+func (a *ArrayFilter) Eval(state *State) (constant.Value, error) {
+	var result []constant.Value
+	for _, elem := range a.Elems {
+		cond, err := a.Lambda.Call(state, elem)
+		if err != nil {
+			return nil, err
+		}
+		if cond == constant.Null {
+			continue
+		}
+		keep, err := constant.AsBool(cond)
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			result = append(result, elem)
+		}
+	}
+	return constant.MakeArray(result), nil
+}
+
+func (a *ArrayReduce) Eval(state *State) (constant.Value, error) {
+	acc := a.Init
+	for _, elem := range a.Elems {
+		var err error
+		if acc, err = a.Lambda.Call(state, acc, elem); err != nil {
+			return nil, err
+		}
+	}
+	return acc, nil
+}
+
+func (a *ArrayGenerate) Eval(state *State) (constant.Value, error) {
+	var result []constant.Value
+	for i := int64(1); i <= a.N; i++ {
+		value, err := a.Lambda.Call(state, constant.MakeInt64(i))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return constant.MakeArray(result), nil
+}
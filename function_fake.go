@@ -0,0 +1,232 @@
+package dbgen
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gozssky/dbgen/constant"
+	"github.com/gozssky/dbgen/fake"
+)
+
+// FakeField specifies the kind of value generated by a locale-aware 'fake.*' function.
+type FakeField int
+
+const (
+	FakeFirstName FakeField = iota + 1
+	FakeLastName
+	FakeEmail
+	FakePhone
+	FakeStreetAddress
+	FakeCity
+	FakeCompany
+)
+
+// FakeFunc implements the locale-aware 'fake.*' SQL functions. The functions
+// accept an optional locale argument, e.g. `fake.city('de_DE')`, which
+// defaults to en_US.
+type FakeFunc struct {
+	varArgs
+	Field FakeField
+}
+
+func (f FakeFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("fake functions require at most 1 argument, got %d", len(args))
+	}
+	name := fake.DefaultLocale
+	if len(args) == 1 {
+		locale, err := constant.AsBytes(args[0])
+		if err != nil {
+			return nil, err
+		}
+		name = string(locale)
+	}
+	loc, err := fake.LookupLocale(name)
+	if err != nil {
+		return nil, err
+	}
+	return &Fake{Field: f.Field, Locale: loc}, nil
+}
+
+// FakeCountryCodeFunc implements the 'fake.country_code' SQL function.
+type FakeCountryCodeFunc struct {
+	noArg
+}
+
+func (FakeCountryCodeFunc) Compile(_ *CompileContext, _ Arguments) (Compiled, error) {
+	return &FakeWord{Words: fake.CountryCodes()}, nil
+}
+
+// FakeLoremWordsFunc implements the 'fake.lorem_words' SQL function.
+type FakeLoremWordsFunc struct {
+	oneArg
+}
+
+func (FakeLoremWordsFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	n, err := constant.AsInt64(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("number of lorem words must be non-negative, got %d", n)
+	}
+	return &FakeLoremWords{Count: n}, nil
+}
+
+// FakeLoremSentenceFunc implements the 'fake.lorem_sentence' SQL function.
+type FakeLoremSentenceFunc struct {
+	noArg
+}
+
+func (FakeLoremSentenceFunc) Compile(_ *CompileContext, _ Arguments) (Compiled, error) {
+	return &FakeLoremSentence{}, nil
+}
+
+// Compiled expression types.
+type (
+	// Fake is a random value taken from the dictionaries of a locale.
+	Fake struct {
+		Field  FakeField
+		Locale *fake.Locale
+	}
+	// FakeWord is a random word from a fixed list.
+	FakeWord struct {
+		Words []string
+	}
+	// FakeLoremWords is a fixed number of random lorem ipsum words.
+	FakeLoremWords struct {
+		Count int64
+	}
+	// FakeLoremSentence is a random lorem ipsum sentence.
+	FakeLoremSentence struct{}
+)
+
+func (f *Fake) Eval(state *State) (constant.Value, error) {
+	rng, loc := state.Rng, f.Locale
+	switch f.Field {
+	case FakeFirstName:
+		return constant.MakeBytes([]byte(pick(rng, loc.FirstNames).Text)), nil
+	case FakeLastName:
+		return constant.MakeBytes([]byte(pick(rng, loc.LastNames).Text)), nil
+	case FakeEmail:
+		return constant.MakeBytes(fakeEmail(rng, loc)), nil
+	case FakePhone:
+		return constant.MakeBytes(fillFakeFormat(rng, loc, pick(rng, loc.PhoneFormats))), nil
+	case FakeStreetAddress:
+		return constant.MakeBytes(fillFakeFormat(rng, loc, pick(rng, loc.AddressFormats))), nil
+	case FakeCity:
+		return constant.MakeBytes([]byte(pick(rng, loc.Cities))), nil
+	case FakeCompany:
+		return constant.MakeBytes(fillFakeFormat(rng, loc, pick(rng, loc.CompanyFormats))), nil
+	default:
+		return nil, fmt.Errorf("unknown fake field: %d", f.Field)
+	}
+}
+
+func (f *FakeWord) Eval(state *State) (constant.Value, error) {
+	return constant.MakeBytes([]byte(pick(state.Rng, f.Words))), nil
+}
+
+func (f *FakeLoremWords) Eval(state *State) (constant.Value, error) {
+	return constant.MakeBytes(appendLoremWords(nil, state.Rng, f.Count)), nil
+}
+
+func (*FakeLoremSentence) Eval(state *State) (constant.Value, error) {
+	const minWords, maxWords = 4, 12
+	n := minWords + randIntn(state.Rng, maxWords-minWords+1)
+	sentence := appendLoremWords(nil, state.Rng, int64(n))
+	r, size := utf8.DecodeRune(sentence)
+	sentence = append(utf8.AppendRune(nil, unicode.ToUpper(r)), sentence[size:]...)
+	return constant.MakeBytes(append(sentence, '.')), nil
+}
+
+// pick returns a random element of list.
+func pick[T any](rng rand.Source64, list []T) T {
+	return list[randIntn(rng, len(list))]
+}
+
+func appendLoremWords(dst []byte, rng rand.Source64, n int64) []byte {
+	words := fake.LoremWords()
+	for i := int64(0); i < n; i++ {
+		if i > 0 {
+			dst = append(dst, ' ')
+		}
+		dst = append(dst, pick(rng, words)...)
+	}
+	return dst
+}
+
+// fakeEmail generates an e-mail address from the Latin form of a random name.
+func fakeEmail(rng rand.Source64, loc *fake.Locale) []byte {
+	first := emailLocalPart(pick(rng, loc.FirstNames).Latin)
+	last := emailLocalPart(pick(rng, loc.LastNames).Latin)
+	var local string
+	switch randIntn(rng, 3) {
+	case 0:
+		local = first + "." + last
+	case 1:
+		if first == "" {
+			first = "x"
+		}
+		local = first[:1] + last + fmt.Sprint(randIntn(rng, 100))
+	default:
+		local = first + fmt.Sprint(randIntn(rng, 1000))
+	}
+	return []byte(local + "@" + pick(rng, loc.EmailDomains))
+}
+
+func emailLocalPart(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return unicode.ToLower(r)
+		default:
+			return -1
+		}
+	}, name)
+}
+
+// fillFakeFormat expands a dictionary format string. A '#' is replaced by a
+// random digit, a '%' by a random non-zero digit, and a `{name}` placeholder
+// by a random word from the corresponding dictionary.
+func fillFakeFormat(rng rand.Source64, loc *fake.Locale, format string) []byte {
+	buf := make([]byte, 0, len(format)*2)
+	for i := 0; i < len(format); i++ {
+		switch c := format[i]; c {
+		case '#':
+			buf = append(buf, '0'+byte(randIntn(rng, 10)))
+		case '%':
+			buf = append(buf, '1'+byte(randIntn(rng, 9)))
+		case '{':
+			end := strings.IndexByte(format[i:], '}')
+			if end == -1 {
+				return append(buf, format[i:]...)
+			}
+			switch format[i+1 : i+end] {
+			case "last_name":
+				buf = append(buf, pick(rng, loc.LastNames).Text...)
+			case "first_name":
+				buf = append(buf, pick(rng, loc.FirstNames).Text...)
+			case "street":
+				buf = append(buf, pick(rng, loc.Streets)...)
+			case "city":
+				buf = append(buf, pick(rng, loc.Cities)...)
+			case "word":
+				buf = append(buf, pick(rng, loc.CompanyWords)...)
+			case "suffix":
+				buf = append(buf, pick(rng, loc.CompanySuffixes)...)
+			default:
+				buf = append(buf, format[i:i+end+1]...)
+			}
+			i += end
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,111 @@
+package dbgen
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"hash/fnv"
+	"math/big"
+	"math/bits"
+
+	"github.com/gozssky/dbgen/constant"
+)
+
+// HashFunc implements the `hash.*` SQL functions. Non-string arguments are
+// hashed by their textual representation, so `hash.md5(123)` equals
+// `hash.md5('123')`.
+type HashFunc struct {
+	oneArg
+	New func() hash.Hash
+	// Int returns the digest as an unsigned integer instead of bytes.
+	Int bool
+}
+
+func (f HashFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	h := f.New()
+	h.Write(stringify(args[0]))
+	sum := h.Sum(nil)
+	if f.Int {
+		return constant.MakeInt(new(big.Int).SetBytes(sum)), nil
+	}
+	return constant.MakeBytes(sum), nil
+}
+
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+func newCrc32() hash.Hash { return crc32.NewIEEE() }
+func newCrc64() hash.Hash { return crc64.New(crc64Table) }
+func newFnv64() hash.Hash { return fnv.New64a() }
+
+// Murmur3Func implements the 'hash.murmur3' SQL function. It computes the
+// 32-bit MurmurHash3 (x86 variant) with an optional seed, which defaults to 0.
+type Murmur3Func struct {
+	varArgs
+}
+
+func (Murmur3Func) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("hash.murmur3 requires 1 or 2 arguments, got %d", len(args))
+	}
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	var seed uint32
+	if len(args) == 2 {
+		s, err := constant.AsInt64(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if s < 0 || s > 1<<32-1 {
+			return nil, fmt.Errorf("murmur3 seed must be in [0, 4294967295], got %d", s)
+		}
+		seed = uint32(s)
+	}
+	return constant.MakeInt64(int64(murmur3Sum32(stringify(args[0]), seed))), nil
+}
+
+// murmur3Sum32 computes the 32-bit MurmurHash3 of data.
+func murmur3Sum32(data []byte, seed uint32) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+	h := seed
+	n := len(data)
+	for ; len(data) >= 4; data = data[4:] {
+		k := binary.LittleEndian.Uint32(data)
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+	var k uint32
+	switch len(data) {
+	case 3:
+		k ^= uint32(data[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(data[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(data[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(n)
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
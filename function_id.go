@@ -0,0 +1,197 @@
+package dbgen
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/gozssky/dbgen/constant"
+)
+
+// UuidV7Func implements the 'uuid.v7' SQL function.
+type UuidV7Func struct {
+	oneArg
+}
+
+func (UuidV7Func) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	ms, err := unixMilli48(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &RandUuidV7{UnixMilli: ms}, nil
+}
+
+// UlidFunc implements the 'ulid' SQL function.
+type UlidFunc struct {
+	oneArg
+}
+
+func (UlidFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	ms, err := unixMilli48(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return &RandUlid{UnixMilli: ms}, nil
+}
+
+// ksuidEpoch is the KSUID epoch, 2014-05-13 16:53:20 UTC, in Unix seconds.
+const ksuidEpoch = 1400000000
+
+// KsuidFunc implements the 'ksuid' SQL function.
+type KsuidFunc struct {
+	oneArg
+}
+
+func (KsuidFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	t, err := constant.AsTimestamp(args[0])
+	if err != nil {
+		return nil, err
+	}
+	secs := t.Unix() - ksuidEpoch
+	if secs < 0 || secs > 1<<32-1 {
+		return nil, fmt.Errorf("timestamp %s is out of range for ksuid", t.Format(timestampFormat))
+	}
+	return &RandKsuid{Timestamp: uint32(secs)}, nil
+}
+
+// snowflakeEpoch is the Twitter snowflake epoch, 2010-11-04 01:42:54.657 UTC, in Unix milliseconds.
+const snowflakeEpoch = 1288834974657
+
+// SnowflakeFunc implements the 'snowflake' SQL function.
+type SnowflakeFunc struct {
+	varArgs
+}
+
+func (SnowflakeFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args) != 3 {
+		return nil, fmt.Errorf("snowflake requires 3 arguments, got %d", len(args))
+	}
+	t, err := constant.AsTimestamp(args[0])
+	if err != nil {
+		return nil, err
+	}
+	ms := t.UnixMilli() - snowflakeEpoch
+	if ms < 0 || ms >= 1<<41 {
+		return nil, fmt.Errorf("timestamp %s is out of range for snowflake", t.Format(timestampFormat))
+	}
+	node, err := constant.AsInt64(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if node < 0 || node >= 1<<10 {
+		return nil, fmt.Errorf("snowflake node id must be in [0, 1023], got %d", node)
+	}
+	seq, err := constant.AsInt64(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if seq < 0 || seq >= 1<<12 {
+		return nil, fmt.Errorf("snowflake sequence must be in [0, 4095], got %d", seq)
+	}
+	return constant.MakeInt64(ms<<22 | node<<12 | seq), nil
+}
+
+// unixMilli48 converts a timestamp into the 48-bit millisecond timestamp
+// used by UUIDv7 and ULID.
+func unixMilli48(v constant.Value) (uint64, error) {
+	t, err := constant.AsTimestamp(v)
+	if err != nil {
+		return 0, err
+	}
+	ms := t.UnixMilli()
+	if ms < 0 || ms >= 1<<48 {
+		return 0, fmt.Errorf("timestamp %s is out of range for a 48-bit unix timestamp", t.Format(timestampFormat))
+	}
+	return uint64(ms), nil
+}
+
+// Compiled expression types.
+type (
+	// RandUuidV7 is a random time-ordered UUID (version 7).
+	RandUuidV7 struct {
+		UnixMilli uint64
+	}
+	// RandUlid is a random ULID.
+	RandUlid struct {
+		UnixMilli uint64
+	}
+	// RandKsuid is a random KSUID.
+	RandKsuid struct {
+		Timestamp uint32
+	}
+)
+
+func (r *RandUuidV7) Eval(state *State) (constant.Value, error) {
+	var uuid [16]byte
+	binary.BigEndian.PutUint64(uuid[:8], r.UnixMilli<<16)
+	randRead(state.Rng, uuid[6:])
+	uuid[6] = uuid[6]&0x0f | 0x70 // version 7
+	uuid[8] = uuid[8]&0x3f | 0x80 // variant 10
+	return constant.MakeBytes(formatUuid(uuid)), nil
+}
+
+func (r *RandUlid) Eval(state *State) (constant.Value, error) {
+	var ulid [16]byte
+	binary.BigEndian.PutUint64(ulid[:8], r.UnixMilli<<16)
+	randRead(state.Rng, ulid[6:])
+	return constant.MakeBytes(encodeCrockford(ulid)), nil
+}
+
+func (r *RandKsuid) Eval(state *State) (constant.Value, error) {
+	var ksuid [20]byte
+	binary.BigEndian.PutUint32(ksuid[:4], r.Timestamp)
+	randRead(state.Rng, ksuid[4:])
+	return constant.MakeBytes(encodeBase62(ksuid)), nil
+}
+
+// formatUuid formats a UUID in the canonical 8-4-4-4-12 form.
+func formatUuid(uuid [16]byte) []byte {
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+	return buf
+}
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// encodeCrockford encodes a 128-bit value into 26 Crockford base32 digits.
+func encodeCrockford(id [16]byte) []byte {
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	buf := make([]byte, 26)
+	for i := len(buf) - 1; i >= 0; i-- {
+		buf[i] = crockfordAlphabet[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return buf
+}
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// encodeBase62 encodes a 160-bit value into 27 base62 digits.
+func encodeBase62(id [20]byte) []byte {
+	var words [5]uint32
+	for i := range words {
+		words[i] = binary.BigEndian.Uint32(id[i*4:])
+	}
+	buf := make([]byte, 27)
+	for i := len(buf) - 1; i >= 0; i-- {
+		// Long division of the 160-bit number by 62.
+		var rem uint64
+		for j := range words {
+			cur := rem<<32 | uint64(words[j])
+			words[j] = uint32(cur / 62)
+			rem = cur % 62
+		}
+		buf[i] = base62Alphabet[rem]
+	}
+	return buf
+}
@@ -0,0 +1,273 @@
+package dbgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gozssky/dbgen/constant"
+)
+
+// asJSON converts a JSON value or a string containing JSON text to JSON.
+func asJSON(v constant.Value) (constant.Value, error) {
+	if v.Kind() == constant.KindJSON {
+		return v, nil
+	}
+	text, err := constant.AsBytes(v)
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeJSON(text)
+}
+
+// appendJSONObject appends a JSON object built from keys and values. Keys
+// are converted to strings and must not be NULL.
+func appendJSONObject(dst []byte, keys, values []constant.Value) ([]byte, error) {
+	dst = append(dst, '{')
+	for i, key := range keys {
+		if key == constant.Null {
+			return nil, fmt.Errorf("JSON object keys must not be NULL")
+		}
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		var err error
+		if dst, err = constant.AppendJSON(dst, constant.MakeBytes(stringify(key))); err != nil {
+			return nil, err
+		}
+		dst = append(dst, ':')
+		if dst, err = constant.AppendJSON(dst, values[i]); err != nil {
+			return nil, err
+		}
+	}
+	return append(dst, '}'), nil
+}
+
+// JSONFunc implements the 'json' SQL function, which parses JSON text.
+type JSONFunc struct {
+	oneArg
+}
+
+func (JSONFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	v, err := asJSON(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ToJSONFunc implements the 'to_json' SQL function.
+type ToJSONFunc struct {
+	oneArg
+}
+
+func (ToJSONFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	v, err := constant.ToJSON(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// JSONObjectFunc implements the 'json_object' SQL function, which builds a
+// JSON object from alternating keys and values.
+type JSONObjectFunc struct {
+	varArgs
+}
+
+func (JSONObjectFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("json_object requires an even number of arguments, got %d", len(args))
+	}
+	keys := make([]constant.Value, 0, len(args)/2)
+	values := make([]constant.Value, 0, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		keys = append(keys, args[i])
+		values = append(values, args[i+1])
+	}
+	text, err := appendJSONObject(nil, keys, values)
+	if err != nil {
+		return nil, err
+	}
+	return constant.MustMakeJSON(text), nil
+}
+
+// JSONArrayFunc implements the 'json_array' SQL function, which builds a
+// JSON array from its arguments.
+type JSONArrayFunc struct {
+	varArgs
+}
+
+func (JSONArrayFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	v, err := constant.ToJSON(constant.MakeArray(args))
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// JSONBuildFromArrayFunc implements the 'json_build_from_array' SQL
+// function. With one array, it builds a JSON array of its elements. With an
+// array of keys and an array of values of the same length, it builds a JSON
+// object.
+type JSONBuildFromArrayFunc struct {
+	varArgs
+}
+
+func (JSONBuildFromArrayFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("json_build_from_array requires 1 or 2 arguments, got %d", len(args))
+	}
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	first, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 1 {
+		v, err := constant.ToJSON(constant.MakeArray(first))
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	values, err := constant.AsArray(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if len(first) != len(values) {
+		return nil, fmt.Errorf("json_build_from_array requires arrays of the same length, got %d and %d", len(first), len(values))
+	}
+	text, err := appendJSONObject(nil, first, values)
+	if err != nil {
+		return nil, err
+	}
+	return constant.MustMakeJSON(text), nil
+}
+
+// JSONExtractFunc implements the 'json_extract' SQL function. The path
+// starts with `$` followed by object members `.name` or `."name"` and array
+// elements `[index]`, e.g. `$.items[0].id`. The result is NULL if the path
+// does not exist.
+type JSONExtractFunc struct {
+	twoArgs
+}
+
+func (f JSONExtractFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	return callPrepared(f, state, args)
+}
+
+func (JSONExtractFunc) Prepare(_ *CompileContext, consts Arguments) (PureFunction, error) {
+	if consts[1] == nil || consts[1] == constant.Null {
+		return nil, nil
+	}
+	pathText, err := constant.AsBytes(consts[1])
+	if err != nil {
+		return nil, err
+	}
+	path, err := parseJSONPath(string(pathText))
+	if err != nil {
+		return nil, err
+	}
+	return jsonExtract{path: path}, nil
+}
+
+// jsonExtract is json_extract with a parsed path.
+type jsonExtract struct {
+	twoArgs
+	path []jsonPathStep
+}
+
+func (f jsonExtract) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	doc, err := asJSON(args[0])
+	if err != nil {
+		return nil, err
+	}
+	text, _ := constant.AsJSON(doc)
+	for _, step := range f.path {
+		var ok bool
+		if text, ok = step.lookup(text); !ok {
+			return constant.Null, nil
+		}
+	}
+	return constant.MustMakeJSON(text), nil
+}
+
+// jsonPathStep is an object member name or an array index.
+type jsonPathStep struct {
+	name  string
+	index int
+	// isIndex reports whether the step is an array index.
+	isIndex bool
+}
+
+// lookup returns the member or element of a JSON document.
+func (s jsonPathStep) lookup(doc []byte) ([]byte, bool) {
+	if s.isIndex {
+		var elems []json.RawMessage
+		if json.Unmarshal(doc, &elems) != nil || s.index >= len(elems) {
+			return nil, false
+		}
+		return elems[s.index], true
+	}
+	var members map[string]json.RawMessage
+	if json.Unmarshal(doc, &members) != nil {
+		return nil, false
+	}
+	member, ok := members[s.name]
+	return member, ok
+}
+
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	invalid := func() error { return fmt.Errorf("invalid JSON path: %s", path) }
+	if !strings.HasPrefix(path, "$") {
+		return nil, invalid()
+	}
+	var steps []jsonPathStep
+	for rest := path[1:]; rest != ""; {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			if strings.HasPrefix(rest, `"`) {
+				end := strings.IndexByte(rest[1:], '"')
+				if end < 0 {
+					return nil, invalid()
+				}
+				steps = append(steps, jsonPathStep{name: rest[1 : end+1]})
+				rest = rest[end+2:]
+				continue
+			}
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, invalid()
+			}
+			steps = append(steps, jsonPathStep{name: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, invalid()
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(rest[1:end]))
+			if err != nil || index < 0 {
+				return nil, invalid()
+			}
+			steps = append(steps, jsonPathStep{index: index, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			return nil, invalid()
+		}
+	}
+	return steps, nil
+}
@@ -0,0 +1,538 @@
+package dbgen
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/gozssky/dbgen/constant"
+)
+
+// RoundMode specifies how a number is rounded to an integer.
+type RoundMode int
+
+const (
+	// RoundHalfAway rounds to the nearest integer, and halfway cases away from zero.
+	RoundHalfAway RoundMode = iota
+	// RoundFloor rounds towards negative infinity.
+	RoundFloor
+	// RoundCeil rounds towards positive infinity.
+	RoundCeil
+	// RoundTrunc rounds towards zero.
+	RoundTrunc
+)
+
+// roundValue rounds a number to the given number of decimal digits, which
+// may be negative to round to the left of the decimal point. Integers stay
+// integers and floats stay floats.
+func roundValue(v constant.Value, digits int64, mode RoundMode) (constant.Value, error) {
+	switch v.Kind() {
+	case constant.KindInt:
+		if digits >= 0 {
+			return v, nil
+		}
+		if digits < -maxRoundDigits {
+			return nil, fmt.Errorf("rounding digits out of range: %d", digits)
+		}
+		i, _ := constant.AsInt(v)
+		return constant.MakeInt(roundInt(i, -digits, mode)), nil
+	case constant.KindFloat:
+		f, _ := constant.AsFloat(v)
+		return constant.MakeFloat(roundFloat(f, digits, mode)), nil
+	case constant.KindDecimal:
+		// Like in PostgreSQL, the scale of the result is the number of
+		// digits, so round(1.5, 2) is 1.50.
+		d, _ := constant.AsDecimal(v)
+		if digits < -maxRoundDigits || digits > constant.MaxDecimalScale {
+			return nil, fmt.Errorf("rounding digits out of range: %d", digits)
+		}
+		scale := int(digits)
+		if scale < 0 {
+			scale = 0
+		}
+		if int(digits) >= d.Scale {
+			return constant.MakeDecimal(constant.Decimal{Unscaled: d.Rescale(scale), Scale: scale}), nil
+		}
+		exp := int64(d.Scale) - digits
+		rounded := roundInt(d.Unscaled, exp, mode)
+		unscaled := rounded.Quo(rounded, new(big.Int).Exp(bigTen, big.NewInt(int64(d.Scale-scale)), nil))
+		return constant.MakeDecimal(constant.Decimal{Unscaled: unscaled, Scale: scale}), nil
+	default:
+		return nil, &constant.ConvertError{From: v, To: "number"}
+	}
+}
+
+// maxRoundDigits bounds the number of digits integers are rounded to.
+const maxRoundDigits = 1000
+
+var bigTen = big.NewInt(10)
+
+// roundInt rounds i to a multiple of 10^exp.
+func roundInt(i *big.Int, exp int64, mode RoundMode) *big.Int {
+	// A multiple of 10^exp larger than twice |i| rounds to zero (or ±10^exp).
+	if exp > int64(len(i.String())) {
+		switch {
+		case mode == RoundFloor && i.Sign() < 0:
+			return new(big.Int).Neg(new(big.Int).Exp(bigTen, big.NewInt(exp), nil))
+		case mode == RoundCeil && i.Sign() > 0:
+			return new(big.Int).Exp(bigTen, big.NewInt(exp), nil)
+		default:
+			return new(big.Int)
+		}
+	}
+	unit := new(big.Int).Exp(bigTen, big.NewInt(exp), nil)
+	q, r := new(big.Int).QuoRem(i, unit, new(big.Int))
+	switch mode {
+	case RoundHalfAway:
+		if new(big.Int).Lsh(new(big.Int).Abs(r), 1).Cmp(unit) >= 0 {
+			q.Add(q, big.NewInt(int64(i.Sign())))
+		}
+	case RoundFloor:
+		if r.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		}
+	case RoundCeil:
+		if r.Sign() > 0 {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Mul(q, unit)
+}
+
+// roundFloat rounds f to the given number of decimal digits.
+func roundFloat(f float64, digits int64, mode RoundMode) float64 {
+	op := math.Round
+	switch mode {
+	case RoundFloor:
+		op = math.Floor
+	case RoundCeil:
+		op = math.Ceil
+	case RoundTrunc:
+		op = math.Trunc
+	}
+	switch {
+	case digits == 0 || math.IsNaN(f) || math.IsInf(f, 0):
+		return op(f)
+	case digits > 0:
+		if digits > 340 {
+			return f
+		}
+		scale := math.Pow10(int(digits))
+		scaled := f * scale
+		if math.IsInf(scaled, 0) || scaled == op(scaled) {
+			// f has no more digits than requested.
+			return f
+		}
+		return op(scaled) / scale
+	default:
+		if digits < -340 {
+			return math.Copysign(0, f)
+		}
+		scale := math.Pow10(int(-digits))
+		return op(f/scale) * scale
+	}
+}
+
+// AbsFunc implements the 'abs' SQL function.
+type AbsFunc struct {
+	oneArg
+}
+
+func (AbsFunc) Signature() Signature {
+	return signature(numericKinds, numericKinds)
+}
+
+func (AbsFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	result, err := constant.Abs(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SignFunc implements the 'sign' SQL function.
+type SignFunc struct {
+	oneArg
+}
+
+func (SignFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	switch args[0].Kind() {
+	case constant.KindNull:
+		return constant.Null, nil
+	case constant.KindInt, constant.KindDecimal:
+		return constant.MakeInt64(int64(constant.Sign(args[0]))), nil
+	case constant.KindFloat:
+		f, _ := constant.AsFloat(args[0])
+		if math.IsNaN(f) {
+			return args[0], nil
+		}
+		return constant.MakeFloat(float64(constant.Sign(args[0]))), nil
+	default:
+		return nil, &constant.ConvertError{From: args[0], To: "number"}
+	}
+}
+
+// FloatFunc implements SQL functions operating on a single floating-point
+// number, such as 'sqrt', 'ln' and the trigonometric functions.
+type FloatFunc struct {
+	oneArg
+	Op func(float64) (float64, error)
+}
+
+func (FloatFunc) Signature() Signature {
+	return signature(floatKinds, numericKinds)
+}
+
+func (f FloatFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	x, err := constant.AsFloat(args[0])
+	if err != nil {
+		return nil, err
+	}
+	result, err := f.Op(x)
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeFloat(result), nil
+}
+
+// floatOp adapts a float function that is defined on all inputs.
+func floatOp(op func(float64) float64) func(float64) (float64, error) {
+	return func(x float64) (float64, error) {
+		return op(x), nil
+	}
+}
+
+var (
+	errSqrtNegative   = errors.New("cannot take square root of a negative number")
+	errLogZero        = errors.New("cannot take logarithm of zero")
+	errLogNegative    = errors.New("cannot take logarithm of a negative number")
+	errOutOfRange     = errors.New("input is out of range")
+	errPowerUndefined = errors.New("a negative number raised to a non-integer power yields a complex result")
+)
+
+func sqrtOp(x float64) (float64, error) {
+	if x < 0 {
+		return 0, errSqrtNegative
+	}
+	return math.Sqrt(x), nil
+}
+
+// logOp returns a logarithm function that rejects non-positive inputs.
+func logOp(log func(float64) float64) func(float64) (float64, error) {
+	return func(x float64) (float64, error) {
+		switch {
+		case x == 0:
+			return 0, errLogZero
+		case x < 0:
+			return 0, errLogNegative
+		}
+		return log(x), nil
+	}
+}
+
+// unitRangeOp returns a function that rejects inputs outside [-1, 1].
+func unitRangeOp(op func(float64) float64) func(float64) (float64, error) {
+	return func(x float64) (float64, error) {
+		if x < -1 || x > 1 {
+			return 0, errOutOfRange
+		}
+		return op(x), nil
+	}
+}
+
+func degreesOp(x float64) float64 {
+	return x * (180 / math.Pi)
+}
+
+func radiansOp(x float64) float64 {
+	return x * (math.Pi / 180)
+}
+
+// Atan2Func implements the 'atan2' SQL function.
+type Atan2Func struct {
+	twoArgs
+}
+
+func (Atan2Func) Call(_ *State, args Arguments) (constant.Value, error) {
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	y, err := constant.AsFloat(args[0])
+	if err != nil {
+		return nil, err
+	}
+	x, err := constant.AsFloat(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeFloat(math.Atan2(y, x)), nil
+}
+
+// LogFunc implements the 'log' SQL function. With one argument it is the
+// base-10 logarithm, with two arguments `log(base, x)`.
+type LogFunc struct {
+	varArgs
+}
+
+func (LogFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("log requires 1 or 2 arguments, got %d", len(args))
+	}
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	x, err := constant.AsFloat(args[len(args)-1])
+	if err != nil {
+		return nil, err
+	}
+	result, err := logOp(math.Log10)(x)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 2 {
+		base, err := constant.AsFloat(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if base == 1 {
+			return nil, constant.ErrDivideByZero
+		}
+		logBase, err := logOp(math.Log10)(base)
+		if err != nil {
+			return nil, err
+		}
+		result /= logBase
+	}
+	return constant.MakeFloat(result), nil
+}
+
+// maxPowerBits bounds the size of integer powers.
+const maxPowerBits = 1 << 16
+
+// PowerFunc implements the 'power' SQL function. An integer raised to a
+// non-negative integer power is an integer, promoted to arbitrary precision
+// on overflow. Otherwise the result is a float.
+type PowerFunc struct {
+	twoArgs
+}
+
+func (PowerFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	if args[0].Kind() == constant.KindInt && args[1].Kind() == constant.KindInt && constant.Sign(args[1]) >= 0 {
+		base, _ := constant.AsInt(args[0])
+		exp, _ := constant.AsInt(args[1])
+		// |base| <= 1 keeps the result small for any exponent.
+		if base.CmpAbs(big.NewInt(1)) > 0 && (!exp.IsInt64() || exp.Int64() > maxPowerBits/int64(base.BitLen()-1)) {
+			return nil, fmt.Errorf("power result is too large: %s ^ %s", base, exp)
+		}
+		if !exp.IsInt64() {
+			// Only reachable for base in {-1, 0, 1}, with a positive exponent.
+			if base.Sign() == 0 {
+				return constant.MakeInt64(0), nil
+			}
+			exp = new(big.Int).And(exp, big.NewInt(1))
+		}
+		return constant.MakeInt(new(big.Int).Exp(base, exp, nil)), nil
+	}
+	base, err := constant.AsFloat(args[0])
+	if err != nil {
+		return nil, err
+	}
+	exp, err := constant.AsFloat(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if base < 0 && exp != math.Trunc(exp) {
+		return nil, errPowerUndefined
+	}
+	if base == 0 && exp < 0 {
+		return nil, constant.ErrDivideByZero
+	}
+	return constant.MakeFloat(math.Pow(base, exp)), nil
+}
+
+// PiFunc implements the 'pi' SQL function.
+type PiFunc struct {
+	noArg
+}
+
+func (PiFunc) Compile(_ *CompileContext, _ Arguments) (Compiled, error) {
+	return &Constant{constant.MakeFloat(math.Pi)}, nil
+}
+
+// WidthBucketFunc implements the 'width_bucket' SQL function. It returns the
+// 1-based bucket of the operand in a histogram of `count` equal-width buckets
+// spanning [low, high), 0 below the range and count+1 above it.
+type WidthBucketFunc struct {
+	varArgs
+}
+
+func (WidthBucketFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args) != 4 {
+		return nil, fmt.Errorf("width_bucket requires 4 arguments, got %d", len(args))
+	}
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	var bounds [3]float64
+	for i := range bounds {
+		f, err := constant.AsFloat(args[i])
+		if err != nil {
+			return nil, err
+		}
+		bounds[i] = f
+	}
+	operand, low, high := bounds[0], bounds[1], bounds[2]
+	count, err := constant.AsInt64(args[3])
+	if err != nil {
+		return nil, err
+	}
+	if count <= 0 || count == math.MaxInt64 {
+		return nil, fmt.Errorf("count must be greater than zero, got %d", count)
+	}
+	if low == high {
+		return nil, fmt.Errorf("lower bound cannot equal upper bound")
+	}
+	if math.IsNaN(operand) || math.IsNaN(low) || math.IsNaN(high) || math.IsInf(low, 0) || math.IsInf(high, 0) {
+		return nil, fmt.Errorf("operand, lower bound, and upper bound cannot be NaN or infinite")
+	}
+
+	var bucket int64
+	if low < high {
+		switch {
+		case operand < low:
+			bucket = 0
+		case operand >= high:
+			bucket = count + 1
+		default:
+			bucket = int64(float64(count)*((operand-low)/(high-low))) + 1
+		}
+	} else {
+		switch {
+		case operand > low:
+			bucket = 0
+		case operand <= high:
+			bucket = count + 1
+		default:
+			bucket = int64(float64(count)*((low-operand)/(low-high))) + 1
+		}
+	}
+	// Guard against rounding pushing the result past the last bucket.
+	if bucket > count+1 {
+		bucket = count + 1
+	}
+	return constant.MakeInt64(bucket), nil
+}
+
+// GcdFunc implements the 'gcd' and 'lcm' SQL functions.
+type GcdFunc struct {
+	twoArgs
+	// Lcm computes the least common multiple instead.
+	Lcm bool
+}
+
+func (f GcdFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	a, err := constant.AsInt(args[0])
+	if err != nil {
+		return nil, err
+	}
+	b, err := constant.AsInt(args[1])
+	if err != nil {
+		return nil, err
+	}
+	a, b = new(big.Int).Abs(a), new(big.Int).Abs(b)
+	gcd := new(big.Int).GCD(nil, nil, a, b)
+	if !f.Lcm {
+		return constant.MakeInt(gcd), nil
+	}
+	if gcd.Sign() == 0 {
+		return constant.MakeInt64(0), nil
+	}
+	lcm := new(big.Int).Quo(a, gcd)
+	return constant.MakeInt(lcm.Mul(lcm, b)), nil
+}
+
+// RandDecimalFunc implements the 'rand.decimal' SQL function. It returns a
+// uniformly distributed random decimal between min and max inclusive, with
+// the given number of digits after the decimal point.
+type RandDecimalFunc struct {
+	threeArgs
+}
+
+func (RandDecimalFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	if hasNull(args) {
+		return &Constant{constant.Null}, nil
+	}
+	scale, err := constant.AsInt64(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if scale < 0 || scale > constant.MaxDecimalScale {
+		return nil, fmt.Errorf("rand.decimal scale out of range: %d", scale)
+	}
+	low, err := unscaledBound(args[0], int(scale), RoundCeil)
+	if err != nil {
+		return nil, err
+	}
+	high, err := unscaledBound(args[1], int(scale), RoundFloor)
+	if err != nil {
+		return nil, err
+	}
+	if low.Cmp(high) > 0 {
+		return nil, fmt.Errorf("rand.decimal requires min <= max, got %s and %s", args[0], args[1])
+	}
+	span := new(big.Int).Sub(high, low)
+	return &RandDecimal{Min: low, Span: span.Add(span, big.NewInt(1)), Scale: int(scale)}, nil
+}
+
+// unscaledBound converts a number to an unscaled integer at the scale,
+// rounding with the given mode. Floats are converted through their shortest
+// decimal representation, so that 99.99 is exact.
+func unscaledBound(v constant.Value, scale int, mode RoundMode) (*big.Int, error) {
+	var d constant.Decimal
+	if v.Kind() == constant.KindFloat {
+		f, _ := constant.AsFloat(v)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, &constant.ConvertError{From: v, To: "Decimal"}
+		}
+		var err error
+		if d, err = constant.ParseDecimal(strconv.FormatFloat(f, 'g', -1, 64)); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if d, err = constant.AsDecimal(v); err != nil {
+			return nil, err
+		}
+	}
+	if d.Scale <= scale {
+		return d.Rescale(scale), nil
+	}
+	exp := int64(d.Scale - scale)
+	rounded := roundInt(d.Unscaled, exp, mode)
+	return rounded.Quo(rounded, new(big.Int).Exp(bigTen, big.NewInt(exp), nil)), nil
+}
+
+// RandDecimal is a random decimal in a range.
+type RandDecimal struct {
+	// Min is the unscaled minimum.
+	Min *big.Int
+	// Span is the number of possible values.
+	Span  *big.Int
+	Scale int
+}
+
+func (r *RandDecimal) Eval(state *State) (constant.Value, error) {
+	unscaled := randBigIntn(state.Rng, r.Span)
+	return constant.MakeDecimal(constant.Decimal{Unscaled: unscaled.Add(unscaled, r.Min), Scale: r.Scale}), nil
+}
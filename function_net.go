@@ -0,0 +1,220 @@
+package dbgen
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/gozssky/dbgen/constant"
+)
+
+// NetFormat specifies how a network identifier is represented.
+type NetFormat int
+
+const (
+	// NetFormatText renders the identifier in its canonical text form.
+	NetFormatText NetFormat = iota
+	// NetFormatBinary renders the identifier as raw bytes in network order,
+	// suitable for databases that store INET or MAC addresses as bytes.
+	NetFormatBinary
+)
+
+func parseNetFormat(args Arguments) (NetFormat, error) {
+	switch len(args) {
+	case 0:
+		return NetFormatText, nil
+	case 1:
+		format, err := constant.AsBytes(args[0])
+		if err != nil {
+			return 0, err
+		}
+		switch strings.ToLower(string(format)) {
+		case "text":
+			return NetFormatText, nil
+		case "binary":
+			return NetFormatBinary, nil
+		default:
+			return 0, fmt.Errorf("unknown network identifier format: %s", format)
+		}
+	default:
+		return 0, fmt.Errorf("too many arguments for network identifier function, got %d", len(args))
+	}
+}
+
+var (
+	allIPv4 = netip.PrefixFrom(netip.IPv4Unspecified(), 0)
+	allIPv6 = netip.PrefixFrom(netip.IPv6Unspecified(), 0)
+)
+
+// RandIPFunc implements the 'rand.ipv4' and 'rand.ipv6' SQL functions.
+type RandIPFunc struct {
+	varArgs
+	Prefix netip.Prefix
+}
+
+func (f RandIPFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	format, err := parseNetFormat(args)
+	if err != nil {
+		return nil, err
+	}
+	return &RandIP{Prefix: f.Prefix, Format: format}, nil
+}
+
+// RandIPv4InFunc implements the 'rand.ipv4_in' SQL function.
+type RandIPv4InFunc struct {
+	varArgs
+}
+
+func (RandIPv4InFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("rand.ipv4_in requires at least 1 argument, got 0")
+	}
+	cidr, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := netip.ParsePrefix(string(cidr))
+	if err != nil {
+		return nil, err
+	}
+	if !prefix.Addr().Is4() {
+		return nil, fmt.Errorf("rand.ipv4_in requires an IPv4 prefix, got %s", prefix)
+	}
+	format, err := parseNetFormat(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	return &RandIP{Prefix: prefix.Masked(), Format: format}, nil
+}
+
+// RandMACFunc implements the 'rand.mac' SQL function.
+type RandMACFunc struct {
+	varArgs
+}
+
+func (RandMACFunc) Compile(_ *CompileContext, args Arguments) (Compiled, error) {
+	format, err := parseNetFormat(args)
+	if err != nil {
+		return nil, err
+	}
+	return &RandMAC{Format: format}, nil
+}
+
+// RandHostnameFunc implements the 'rand.hostname' SQL function.
+type RandHostnameFunc struct {
+	noArg
+}
+
+func (RandHostnameFunc) Compile(_ *CompileContext, _ Arguments) (Compiled, error) {
+	return &RandHostname{}, nil
+}
+
+// RandUserAgentFunc implements the 'rand.user_agent' SQL function.
+type RandUserAgentFunc struct {
+	noArg
+}
+
+func (RandUserAgentFunc) Compile(_ *CompileContext, _ Arguments) (Compiled, error) {
+	return &FakeWord{Words: userAgents}, nil
+}
+
+// Compiled expression types.
+type (
+	// RandIP is a random IP address inside a prefix.
+	RandIP struct {
+		Prefix netip.Prefix
+		Format NetFormat
+	}
+	// RandMAC is a random unicast MAC address.
+	RandMAC struct {
+		Format NetFormat
+	}
+	// RandHostname is a random fully qualified host name.
+	RandHostname struct{}
+)
+
+func (r *RandIP) Eval(state *State) (constant.Value, error) {
+	base := r.Prefix.Addr().As16()
+	var random [16]byte
+	binary.BigEndian.PutUint64(random[:8], state.Rng.Uint64())
+	binary.BigEndian.PutUint64(random[8:], state.Rng.Uint64())
+
+	// The bits of an IPv4 address are the last 32 bits of its 16-byte form.
+	fixedBits := r.Prefix.Bits()
+	if r.Prefix.Addr().Is4() {
+		fixedBits += 96
+	}
+	for i := range base {
+		switch {
+		case fixedBits >= 8:
+			fixedBits -= 8
+		case fixedBits > 0:
+			mask := byte(0xff) >> fixedBits
+			base[i] = base[i]&^mask | random[i]&mask
+			fixedBits = 0
+		default:
+			base[i] = random[i]
+		}
+	}
+
+	addr := netip.AddrFrom16(base)
+	if r.Prefix.Addr().Is4() {
+		addr = addr.Unmap()
+	}
+	if r.Format == NetFormatBinary {
+		return constant.MakeBytes(addr.AsSlice()), nil
+	}
+	return constant.MakeBytes([]byte(addr.String())), nil
+}
+
+func (r *RandMAC) Eval(state *State) (constant.Value, error) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], state.Rng.Uint64())
+	mac := buf[:6]
+	// Clear the multicast bit so that the address is a unicast one.
+	mac[0] &^= 0x01
+	if r.Format == NetFormatBinary {
+		return constant.MakeBytes(mac), nil
+	}
+	return constant.MakeBytes([]byte(net.HardwareAddr(mac).String())), nil
+}
+
+func (*RandHostname) Eval(state *State) (constant.Value, error) {
+	rng := state.Rng
+	hostname := fmt.Sprintf("%s-%02d.%s.%s",
+		pick(rng, hostRoles),
+		randIntn(rng, 100),
+		pick(rng, hostRegions),
+		pick(rng, hostDomains),
+	)
+	return constant.MakeBytes([]byte(hostname)), nil
+}
+
+var (
+	hostRoles   = []string{"web", "api", "db", "cache", "queue", "worker", "app", "auth", "mail", "proxy", "lb", "search", "metrics", "batch", "gateway"}
+	hostRegions = []string{"us-east-1", "us-west-2", "eu-west-1", "eu-central-1", "ap-south-1", "ap-northeast-1", "sa-east-1", "dc1", "dc2", "prod", "staging"}
+	hostDomains = []string{"example.com", "example.net", "example.org", "internal", "corp.local"}
+)
+
+var userAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.2; rv:121.0) Gecko/20100101 Firefox/121.0",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+	"Mozilla/5.0 (Linux; Android 13; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+	"curl/8.5.0",
+	"Wget/1.21.4",
+	"python-requests/2.31.0",
+	"Go-http-client/1.1",
+	"Googlebot/2.1 (+http://www.google.com/bot.html)",
+	"Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)",
+}
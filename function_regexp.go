@@ -0,0 +1,346 @@
+package dbgen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gozssky/dbgen/constant"
+)
+
+// regexpFlags are the options of the regular expression functions.
+type regexpFlags struct {
+	// prefix is the inline flag group prepended to the pattern.
+	prefix string
+	// global replaces all matches instead of only the first one.
+	global bool
+}
+
+// parseRegexpFlags parses the flags argument of the regular expression
+// functions. The supported flags are:
+//
+//   - 'i': case-insensitive matching
+//   - 'c': case-sensitive matching (the default)
+//   - 'm' or 'n': `^` and `$` match at line boundaries
+//   - 's': `.` matches newlines
+//   - 'g': replace all matches (regexp_replace only)
+func parseRegexpFlags(v constant.Value, allowGlobal bool) (regexpFlags, error) {
+	var flags regexpFlags
+	if v == nil || v == constant.Null {
+		return flags, nil
+	}
+	s, err := constant.AsBytes(v)
+	if err != nil {
+		return flags, err
+	}
+	var caseInsensitive, multiLine, dotAll bool
+	for _, c := range s {
+		switch c {
+		case 'i':
+			caseInsensitive = true
+		case 'c':
+			caseInsensitive = false
+		case 'm', 'n':
+			multiLine = true
+		case 's':
+			dotAll = true
+		case 'g':
+			if !allowGlobal {
+				return flags, fmt.Errorf("regular expression flag 'g' is only supported by regexp_replace")
+			}
+			flags.global = true
+		default:
+			return flags, fmt.Errorf("unknown regular expression flag: %q", c)
+		}
+	}
+	var inline strings.Builder
+	if caseInsensitive {
+		inline.WriteByte('i')
+	}
+	if multiLine {
+		inline.WriteByte('m')
+	}
+	if dotAll {
+		inline.WriteByte('s')
+	}
+	if inline.Len() > 0 {
+		flags.prefix = "(?" + inline.String() + ")"
+	}
+	return flags, nil
+}
+
+// compileRegexpArgs compiles the pattern argument of the regular expression
+// functions with the given flags.
+func compileRegexpArgs(ctx *CompileContext, pattern constant.Value, flags regexpFlags) (*regexp.Regexp, error) {
+	p, err := constant.AsBytes(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.CompileRegexp(flags.prefix + string(p))
+}
+
+// prepareRegexp compiles the pattern and flags arguments of a regular
+// expression function. It reports false if either is not constant, or if the
+// pattern is NULL. A negative flags index means the function has no flags.
+func prepareRegexp(ctx *CompileContext, consts Arguments, pattern, flags int, allowGlobal bool) (*regexp.Regexp, regexpFlags, bool, error) {
+	if consts[pattern] == nil || consts[pattern] == constant.Null {
+		return nil, regexpFlags{}, false, nil
+	}
+	var flagsArg constant.Value
+	if flags >= 0 && flags < len(consts) {
+		if consts[flags] == nil {
+			return nil, regexpFlags{}, false, nil
+		}
+		flagsArg = consts[flags]
+	}
+	rf, err := parseRegexpFlags(flagsArg, allowGlobal)
+	if err != nil {
+		return nil, rf, false, err
+	}
+	re, err := compileRegexpArgs(ctx, consts[pattern], rf)
+	if err != nil {
+		return nil, rf, false, err
+	}
+	return re, rf, true, nil
+}
+
+// RegexpLikeFunc implements the 'regexp_like' SQL function.
+type RegexpLikeFunc struct {
+	varArgs
+}
+
+func (f RegexpLikeFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	return callPrepared(f, state, args)
+}
+
+func (RegexpLikeFunc) Prepare(ctx *CompileContext, consts Arguments) (PureFunction, error) {
+	if len(consts) < 2 || len(consts) > 3 {
+		return nil, fmt.Errorf("regexp_like requires 2 or 3 arguments, got %d", len(consts))
+	}
+	re, _, ok, err := prepareRegexp(ctx, consts, 1, 2, false)
+	if !ok {
+		return nil, err
+	}
+	return regexpLike{re: re}, nil
+}
+
+// regexpLike is regexp_like with a compiled pattern.
+type regexpLike struct {
+	varArgs
+	re *regexp.Regexp
+}
+
+func (f regexpLike) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeBool(f.re.Match(input)), nil
+}
+
+// RegexpReplaceFunc implements the 'regexp_replace' SQL function.
+//
+// In the replacement string, `\1` to `\9` refer to the captured groups,
+// `\&` refers to the whole match and `\\` is a literal backslash. Only the
+// first match is replaced unless the 'g' flag is given.
+type RegexpReplaceFunc struct {
+	varArgs
+}
+
+func (f RegexpReplaceFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	return callPrepared(f, state, args)
+}
+
+func (RegexpReplaceFunc) Prepare(ctx *CompileContext, consts Arguments) (PureFunction, error) {
+	if len(consts) < 3 || len(consts) > 4 {
+		return nil, fmt.Errorf("regexp_replace requires 3 or 4 arguments, got %d", len(consts))
+	}
+	re, flags, ok, err := prepareRegexp(ctx, consts, 1, 3, true)
+	if !ok {
+		return nil, err
+	}
+	return regexpReplace{re: re, global: flags.global}, nil
+}
+
+// regexpReplace is regexp_replace with a compiled pattern.
+type regexpReplace struct {
+	varArgs
+	re     *regexp.Regexp
+	global bool
+}
+
+func (f regexpReplace) Call(_ *State, args Arguments) (constant.Value, error) {
+	if hasNull(args[:3]) {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	repl, err := constant.AsBytes(args[2])
+	if err != nil {
+		return nil, err
+	}
+
+	var matches [][]int
+	if f.global {
+		matches = f.re.FindAllSubmatchIndex(input, -1)
+	} else if m := f.re.FindSubmatchIndex(input); m != nil {
+		matches = [][]int{m}
+	}
+	result := make([]byte, 0, len(input))
+	last := 0
+	for _, m := range matches {
+		result = append(result, input[last:m[0]]...)
+		result = expandReplacement(result, repl, input, m)
+		last = m[1]
+	}
+	result = append(result, input[last:]...)
+	return constant.MakeBytes(result), nil
+}
+
+// expandReplacement appends the replacement string with the group
+// references substituted by the matched text.
+func expandReplacement(dst, repl, input []byte, match []int) []byte {
+	for i := 0; i < len(repl); i++ {
+		c := repl[i]
+		if c != '\\' || i+1 == len(repl) {
+			dst = append(dst, c)
+			continue
+		}
+		i++
+		switch next := repl[i]; {
+		case next == '&':
+			dst = append(dst, input[match[0]:match[1]]...)
+		case next >= '1' && next <= '9':
+			group := int(next - '0')
+			if 2*group+1 < len(match) && match[2*group] >= 0 {
+				dst = append(dst, input[match[2*group]:match[2*group+1]]...)
+			}
+		default:
+			dst = append(dst, next)
+		}
+	}
+	return dst
+}
+
+// RegexpSubstrFunc implements the 'regexp_substr' SQL function. It returns
+// the n-th (default first) match of the pattern, or NULL if there is none.
+type RegexpSubstrFunc struct {
+	varArgs
+}
+
+func (f RegexpSubstrFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	return callPrepared(f, state, args)
+}
+
+func (RegexpSubstrFunc) Prepare(ctx *CompileContext, consts Arguments) (PureFunction, error) {
+	if len(consts) < 2 || len(consts) > 3 {
+		return nil, fmt.Errorf("regexp_substr requires 2 or 3 arguments, got %d", len(consts))
+	}
+	re, _, ok, err := prepareRegexp(ctx, consts, 1, -1, false)
+	if !ok {
+		return nil, err
+	}
+	return regexpSubstr{re: re}, nil
+}
+
+// regexpSubstr is regexp_substr with a compiled pattern.
+type regexpSubstr struct {
+	varArgs
+	re *regexp.Regexp
+}
+
+func (f regexpSubstr) Call(_ *State, args Arguments) (constant.Value, error) {
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	n := int64(1)
+	if len(args) == 3 {
+		if n, err = constant.AsInt64(args[2]); err != nil {
+			return nil, err
+		}
+		if n <= 0 {
+			return nil, fmt.Errorf("regexp_substr occurrence must be positive, got %d", n)
+		}
+	}
+	limit := -1
+	if n < int64(len(input))+2 {
+		limit = int(n)
+	}
+	matches := f.re.FindAll(input, limit)
+	if int64(len(matches)) < n {
+		return constant.Null, nil
+	}
+	return constant.MakeBytes(matches[n-1]), nil
+}
+
+// RegexpMatchesFunc implements the 'regexp_matches' SQL function. It returns
+// the captured groups of the first match as an array, or the whole match if
+// the pattern has no groups. Unmatched groups are NULL. If the pattern does
+// not match, the result is NULL.
+type RegexpMatchesFunc struct {
+	varArgs
+}
+
+func (f RegexpMatchesFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	return callPrepared(f, state, args)
+}
+
+func (RegexpMatchesFunc) Prepare(ctx *CompileContext, consts Arguments) (PureFunction, error) {
+	if len(consts) < 2 || len(consts) > 3 {
+		return nil, fmt.Errorf("regexp_matches requires 2 or 3 arguments, got %d", len(consts))
+	}
+	re, _, ok, err := prepareRegexp(ctx, consts, 1, 2, false)
+	if !ok {
+		return nil, err
+	}
+	return regexpMatches{re: re}, nil
+}
+
+// regexpMatches is regexp_matches with a compiled pattern.
+type regexpMatches struct {
+	varArgs
+	re *regexp.Regexp
+}
+
+func (f regexpMatches) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	match := f.re.FindSubmatchIndex(input)
+	if match == nil {
+		return constant.Null, nil
+	}
+	if len(match) > 2 {
+		match = match[2:]
+	}
+	result := make([]constant.Value, 0, len(match)/2)
+	for i := 0; i < len(match); i += 2 {
+		if match[i] < 0 {
+			result = append(result, constant.Null)
+		} else {
+			result = append(result, constant.MakeBytes(input[match[i]:match[i+1]]))
+		}
+	}
+	return constant.MakeArray(result), nil
+}
+
+// optionalArg returns the i-th argument, or nil if it is absent.
+func optionalArg(args Arguments, i int) constant.Value {
+	if i < len(args) {
+		return args[i]
+	}
+	return nil
+}
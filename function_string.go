@@ -0,0 +1,713 @@
+package dbgen
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gozssky/dbgen/constant"
+	"github.com/gozssky/dbgen/template"
+)
+
+// unitFunction is a function whose behavior depends on the string unit. The
+// unit is chosen with a trailing `USING CHARACTERS` or `USING OCTETS` clause
+// in the function call and defaults to characters.
+type unitFunction interface {
+	Function
+	// WithUnit returns a copy of the function using the given unit.
+	WithUnit(unit template.StringUnit) Function
+}
+
+// unitLen returns the length of b measured in the given unit.
+func unitLen(b []byte, unit template.StringUnit) int {
+	if unit == template.StringUnitOctets {
+		return len(b)
+	}
+	return utf8.RuneCount(b)
+}
+
+// unitOffset returns the byte offset of the n-th (0-based) unit of b.
+// The result is clamped to [0, len(b)].
+func unitOffset(b []byte, unit template.StringUnit, n int64) int {
+	if n <= 0 {
+		return 0
+	}
+	if unit == template.StringUnitOctets {
+		if n > int64(len(b)) {
+			return len(b)
+		}
+		return int(n)
+	}
+	offset := 0
+	for ; n > 0 && offset < len(b); n-- {
+		_, size := utf8.DecodeRune(b[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// unitSlice returns the units of b in the 0-based, half-open range [start, end).
+// The range is clamped to the bounds of b.
+func unitSlice(b []byte, unit template.StringUnit, start, end int64) []byte {
+	if end <= start {
+		return []byte{}
+	}
+	from := unitOffset(b, unit, start)
+	to := unitOffset(b, unit, end)
+	return b[from:to:to]
+}
+
+// unitSplit splits b into its individual units.
+func unitSplit(b []byte, unit template.StringUnit) [][]byte {
+	parts := make([][]byte, 0, len(b))
+	for len(b) > 0 {
+		size := 1
+		if unit != template.StringUnitOctets {
+			_, size = utf8.DecodeRune(b)
+		}
+		parts = append(parts, b[:size:size])
+		b = b[size:]
+	}
+	return parts
+}
+
+// stringify returns the textual representation of a value. Byte strings are
+// returned as is.
+func stringify(v constant.Value) []byte {
+	if b, err := constant.AsBytes(v); err == nil {
+		return b
+	}
+	return []byte(v.String())
+}
+
+// hasNull reports whether any of the arguments is NULL.
+func hasNull(args Arguments) bool {
+	for _, arg := range args {
+		if arg == constant.Null {
+			return true
+		}
+	}
+	return false
+}
+
+// asBytesArgs converts all arguments to byte strings.
+func asBytesArgs(args Arguments) ([][]byte, error) {
+	result := make([][]byte, 0, len(args))
+	for _, arg := range args {
+		b, err := constant.AsBytes(arg)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, b)
+	}
+	return result, nil
+}
+
+// LowerFunc implements the 'lower' SQL function.
+type LowerFunc struct {
+	oneArg
+}
+
+func (LowerFunc) Signature() Signature {
+	return signature(bytesKinds, bytesKinds)
+}
+
+func (LowerFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeBytes(bytes.ToLower(input)), nil
+}
+
+// UpperFunc implements the 'upper' SQL function.
+type UpperFunc struct {
+	oneArg
+}
+
+func (UpperFunc) Signature() Signature {
+	return signature(bytesKinds, bytesKinds)
+}
+
+func (UpperFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeBytes(bytes.ToUpper(input)), nil
+}
+
+// InitcapFunc implements the 'initcap' SQL function. The first letter of
+// each word is converted to upper case and the rest to lower case, where
+// words are sequences of alphanumeric characters.
+type InitcapFunc struct {
+	oneArg
+}
+
+func (InitcapFunc) Signature() Signature {
+	return signature(bytesKinds, bytesKinds)
+}
+
+func (InitcapFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	result := make([]byte, 0, len(input))
+	inWord := false
+	for len(input) > 0 {
+		r, size := utf8.DecodeRune(input)
+		if r == utf8.RuneError && size == 1 {
+			result = append(result, input[0])
+			inWord = false
+		} else {
+			isAlnum := unicode.IsLetter(r) || unicode.IsDigit(r)
+			if inWord {
+				r = unicode.ToLower(r)
+			} else {
+				r = unicode.ToUpper(r)
+			}
+			result = utf8.AppendRune(result, r)
+			inWord = isAlnum
+		}
+		input = input[size:]
+	}
+	return constant.MakeBytes(result), nil
+}
+
+// TrimFunc implements the 'trim', 'ltrim' and 'rtrim' SQL functions.
+// The optional second argument is the set of units to remove,
+// which defaults to a single space.
+type TrimFunc struct {
+	varArgs
+	Left  bool
+	Right bool
+	Unit  template.StringUnit
+}
+
+func (f TrimFunc) WithUnit(unit template.StringUnit) Function {
+	f.Unit = unit
+	return f
+}
+
+func (f TrimFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args) < 1 || len(args) > 2 {
+		return nil, fmt.Errorf("trim functions require 1 or 2 arguments, got %d", len(args))
+	}
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	strs, err := asBytesArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	input, set := strs[0], []byte{' '}
+	if len(strs) == 2 {
+		set = strs[1]
+	}
+	units := unitSplit(input, f.Unit)
+	trimSet := unitSplit(set, f.Unit)
+	inSet := func(u []byte) bool {
+		for _, t := range trimSet {
+			if bytes.Equal(u, t) {
+				return true
+			}
+		}
+		return false
+	}
+	start, end := 0, len(units)
+	if f.Left {
+		for start < end && inSet(units[start]) {
+			start++
+		}
+	}
+	if f.Right {
+		for end > start && inSet(units[end-1]) {
+			end--
+		}
+	}
+	return constant.MakeBytes(bytes.Join(units[start:end], nil)), nil
+}
+
+// PadFunc implements the 'lpad' and 'rpad' SQL functions. The string is
+// padded to the given length with the fill string, which defaults to a
+// single space. A string longer than the length is truncated on the right.
+type PadFunc struct {
+	varArgs
+	Left bool
+	Unit template.StringUnit
+}
+
+func (f PadFunc) WithUnit(unit template.StringUnit) Function {
+	f.Unit = unit
+	return f
+}
+
+// maxStringLength bounds the length of strings built by the string functions.
+const maxStringLength = 1 << 30
+
+func (f PadFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, fmt.Errorf("pad functions require 2 or 3 arguments, got %d", len(args))
+	}
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	length, err := constant.AsInt64(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if length > maxStringLength {
+		return nil, fmt.Errorf("requested length too large: %d", length)
+	}
+	fill := []byte{' '}
+	if len(args) == 3 {
+		if fill, err = constant.AsBytes(args[2]); err != nil {
+			return nil, err
+		}
+	}
+
+	inputLen := int64(unitLen(input, f.Unit))
+	if length <= inputLen {
+		return constant.MakeBytes(unitSlice(input, f.Unit, 0, length)), nil
+	}
+	fillUnits := unitSplit(fill, f.Unit)
+	if len(fillUnits) == 0 {
+		return constant.MakeBytes(input), nil
+	}
+	padding := make([]byte, 0, int(length-inputLen)*len(fill)/len(fillUnits))
+	for i := int64(0); i < length-inputLen; i++ {
+		padding = append(padding, fillUnits[i%int64(len(fillUnits))]...)
+	}
+	var result []byte
+	if f.Left {
+		result = append(padding, input...)
+	} else {
+		result = append(append(make([]byte, 0, len(input)+len(padding)), input...), padding...)
+	}
+	return constant.MakeBytes(result), nil
+}
+
+// ReplaceFunc implements the 'replace' SQL function.
+type ReplaceFunc struct {
+	threeArgs
+}
+
+func (ReplaceFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	strs, err := asBytesArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(strs[1]) == 0 {
+		return constant.MakeBytes(strs[0]), nil
+	}
+	return constant.MakeBytes(bytes.ReplaceAll(strs[0], strs[1], strs[2])), nil
+}
+
+// RepeatFunc implements the 'repeat' SQL function.
+type RepeatFunc struct {
+	twoArgs
+}
+
+func (RepeatFunc) Signature() Signature {
+	return signature(bytesKinds, bytesKinds, intKinds)
+}
+
+func (RepeatFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	count, err := constant.AsInt64(args[1])
+	if err != nil {
+		return nil, err
+	}
+	if count <= 0 {
+		return constant.MakeBytes([]byte{}), nil
+	}
+	if len(input) > 0 && count > maxStringLength/int64(len(input)) {
+		return nil, fmt.Errorf("requested length too large: %d", count*int64(len(input)))
+	}
+	return constant.MakeBytes(bytes.Repeat(input, int(count))), nil
+}
+
+// ReverseFunc implements the 'reverse' SQL function.
+type ReverseFunc struct {
+	oneArg
+	Unit template.StringUnit
+}
+
+func (ReverseFunc) Signature() Signature {
+	return signature(bytesKinds, bytesKinds)
+}
+
+func (f ReverseFunc) WithUnit(unit template.StringUnit) Function {
+	f.Unit = unit
+	return f
+}
+
+func (f ReverseFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	units := unitSplit(input, f.Unit)
+	result := make([]byte, 0, len(input))
+	for i := len(units) - 1; i >= 0; i-- {
+		result = append(result, units[i]...)
+	}
+	return constant.MakeBytes(result), nil
+}
+
+// PositionFunc implements the 'position' SQL function. It returns the
+// 1-based position of the first argument in the second one, or 0 if absent.
+type PositionFunc struct {
+	twoArgs
+	Unit template.StringUnit
+}
+
+func (f PositionFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	strs, err := asBytesArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	needle, haystack := strs[0], strs[1]
+	index := bytes.Index(haystack, needle)
+	if index == -1 {
+		return constant.MakeInt64(0), nil
+	}
+	return constant.MakeInt64(int64(unitLen(haystack[:index], f.Unit)) + 1), nil
+}
+
+// SplitPartFunc implements the 'split_part' SQL function. A negative field
+// number counts from the end of the string.
+type SplitPartFunc struct {
+	threeArgs
+}
+
+func (SplitPartFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	strs, err := asBytesArgs(args[:2])
+	if err != nil {
+		return nil, err
+	}
+	n, err := constant.AsInt64(args[2])
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("field position must not be zero")
+	}
+	var parts [][]byte
+	if len(strs[1]) == 0 {
+		parts = [][]byte{strs[0]}
+	} else {
+		parts = bytes.Split(strs[0], strs[1])
+	}
+	if n < 0 {
+		n += int64(len(parts)) + 1
+	}
+	if n <= 0 || n > int64(len(parts)) {
+		return constant.MakeBytes([]byte{}), nil
+	}
+	return constant.MakeBytes(parts[n-1]), nil
+}
+
+// StringToArrayFunc implements the 'string_to_array' SQL function. A NULL
+// delimiter splits the string into individual units. Elements equal to the
+// optional third argument are replaced by NULL.
+type StringToArrayFunc struct {
+	varArgs
+	Unit template.StringUnit
+}
+
+func (f StringToArrayFunc) WithUnit(unit template.StringUnit) Function {
+	f.Unit = unit
+	return f
+}
+
+func (f StringToArrayFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, fmt.Errorf("string_to_array requires 2 or 3 arguments, got %d", len(args))
+	}
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	input, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(input) == 0 {
+		return constant.MakeArray(nil), nil
+	}
+
+	var parts [][]byte
+	if args[1] == constant.Null {
+		parts = unitSplit(input, f.Unit)
+	} else {
+		delim, err := constant.AsBytes(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if len(delim) == 0 {
+			parts = [][]byte{input}
+		} else {
+			parts = bytes.Split(input, delim)
+		}
+	}
+
+	var nullStr []byte
+	if len(args) == 3 && args[2] != constant.Null {
+		if nullStr, err = constant.AsBytes(args[2]); err != nil {
+			return nil, err
+		}
+	}
+	result := make([]constant.Value, 0, len(parts))
+	for _, part := range parts {
+		if nullStr != nil && bytes.Equal(part, nullStr) {
+			result = append(result, constant.Null)
+		} else {
+			result = append(result, constant.MakeBytes(part))
+		}
+	}
+	return constant.MakeArray(result), nil
+}
+
+// ArrayToStringFunc implements the 'array_to_string' SQL function. NULL
+// elements are skipped unless the optional third argument gives their text.
+type ArrayToStringFunc struct {
+	varArgs
+}
+
+func (ArrayToStringFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, fmt.Errorf("array_to_string requires 2 or 3 arguments, got %d", len(args))
+	}
+	if args[0] == constant.Null || args[1] == constant.Null {
+		return constant.Null, nil
+	}
+	array, err := constant.AsArray(args[0])
+	if err != nil {
+		return nil, err
+	}
+	delim, err := constant.AsBytes(args[1])
+	if err != nil {
+		return nil, err
+	}
+	var nullStr []byte
+	if len(args) == 3 && args[2] != constant.Null {
+		if nullStr, err = constant.AsBytes(args[2]); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []byte
+	first := true
+	var appendElems func(elems []constant.Value)
+	appendElems = func(elems []constant.Value) {
+		for _, elem := range elems {
+			if nested, err := constant.AsArray(elem); err == nil {
+				appendElems(nested)
+				continue
+			}
+			if elem == constant.Null && nullStr == nil {
+				continue
+			}
+			if !first {
+				result = append(result, delim...)
+			}
+			first = false
+			if elem == constant.Null {
+				result = append(result, nullStr...)
+			} else {
+				result = append(result, stringify(elem)...)
+			}
+		}
+	}
+	appendElems(array)
+	if result == nil {
+		result = []byte{}
+	}
+	return constant.MakeBytes(result), nil
+}
+
+// FormatFunc implements the 'format' SQL function.
+//
+// The format string follows the printf conventions: a conversion
+// specification is `%[n$][flags][width][.precision]verb`, where the verb is
+// one of `s`, `d`, `i`, `u`, `x`, `X`, `o`, `c`, `e`, `E`, `f`, `F`, `g`
+// or `G`, and `%%` produces a literal percent sign. NULL arguments are
+// rendered as empty strings.
+type FormatFunc struct {
+	varArgs
+}
+
+func (FormatFunc) Call(_ *State, args Arguments) (constant.Value, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("format requires at least 1 argument, got 0")
+	}
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	format, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	result, err := formatValues(format, args[1:])
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeBytes(result), nil
+}
+
+func formatValues(format []byte, args []constant.Value) ([]byte, error) {
+	var result []byte
+	nextArg := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			result = append(result, format[i])
+			continue
+		}
+		start := i
+		i++
+		if i < len(format) && format[i] == '%' {
+			result = append(result, '%')
+			continue
+		}
+
+		// Positional argument, e.g. `%2$s`.
+		argIndex := -1
+		if j := i + bytesSpan(format[i:], isASCIIDigit); j > i && j < len(format) && format[j] == '$' {
+			n, _ := strconv.Atoi(string(format[i:j]))
+			if n == 0 {
+				return nil, fmt.Errorf("format specifies argument 0, but arguments are numbered from 1")
+			}
+			argIndex = n - 1
+			i = j + 1
+		}
+		specStart := i
+		i += bytesSpan(format[i:], func(c byte) bool { return bytes.IndexByte([]byte("-+ #0"), c) >= 0 })
+		i += bytesSpan(format[i:], isASCIIDigit)
+		if i < len(format) && format[i] == '.' {
+			i++
+			i += bytesSpan(format[i:], isASCIIDigit)
+		}
+		if i >= len(format) {
+			return nil, fmt.Errorf("unterminated format specifier: %s", format[start:])
+		}
+		spec, verb := string(format[specStart:i]), format[i]
+
+		if argIndex == -1 {
+			argIndex = nextArg
+		}
+		if argIndex >= len(args) {
+			return nil, fmt.Errorf("too few arguments for format: %s", format)
+		}
+		nextArg = argIndex + 1
+
+		formatted, err := formatValue(spec, verb, args[argIndex])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, formatted...)
+	}
+	if result == nil {
+		result = []byte{}
+	}
+	return result, nil
+}
+
+func formatValue(spec string, verb byte, arg constant.Value) (string, error) {
+	if arg == constant.Null {
+		return fmt.Sprintf("%"+spec+"s", ""), nil
+	}
+	switch verb {
+	case 's':
+		return fmt.Sprintf("%"+spec+"s", stringify(arg)), nil
+	case 'd', 'i', 'u', 'o', 'x', 'X', 'c':
+		if verb == 'x' || verb == 'X' {
+			if b, err := constant.AsBytes(arg); err == nil {
+				return fmt.Sprintf("%"+spec+string(verb), b), nil
+			}
+		}
+		i, err := asFormatInt(arg)
+		if err != nil {
+			return "", err
+		}
+		switch verb {
+		case 'i', 'u':
+			verb = 'd'
+		case 'c':
+			if !i.IsInt64() || i.Int64() < 0 || i.Int64() > unicode.MaxRune {
+				return "", fmt.Errorf("character code out of range: %s", i)
+			}
+			return fmt.Sprintf("%"+spec+"c", rune(i.Int64())), nil
+		}
+		return fmt.Sprintf("%"+spec+string(verb), i), nil
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		f, err := constant.AsFloat(arg)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%"+spec+string(verb), f), nil
+	default:
+		return "", fmt.Errorf("unrecognized format specifier: %q", verb)
+	}
+}
+
+// asFormatInt converts an integer argument of 'format', truncating floats.
+func asFormatInt(v constant.Value) (*big.Int, error) {
+	if v.Kind() == constant.KindFloat {
+		f, _ := constant.AsFloat(v)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, &constant.ConvertError{From: v, To: "*big.Int"}
+		}
+		i, _ := big.NewFloat(math.Trunc(f)).Int(nil)
+		return i, nil
+	}
+	return constant.AsInt(v)
+}
+
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// bytesSpan returns the length of the longest prefix of b whose bytes satisfy pred.
+func bytesSpan(b []byte, pred func(c byte) bool) int {
+	n := 0
+	for n < len(b) && pred(b[n]) {
+		n++
+	}
+	return n
+}
@@ -1,10 +1,14 @@
 package dbgen_test
 
 import (
+	"math/rand"
+	"regexp"
 	"testing"
+	"time"
 
 	"github.com/gozssky/dbgen"
 	"github.com/gozssky/dbgen/constant"
+	"github.com/gozssky/dbgen/template"
 	"github.com/stretchr/testify/require"
 )
 
@@ -24,28 +28,26 @@ func TestArrayFunc(t *testing.T) {
 	testCases := []struct {
 		name   string
 		args   dbgen.Arguments
-		result dbgen.Compiled
+		result constant.Value
 	}{
 		{
 			"nil",
 			dbgen.Arguments{},
-			&dbgen.Constant{Value: constant.MakeArray(nil)},
+			constant.MakeArray(nil),
 		},
 		{
 			"empty",
 			dbgen.Arguments{},
-			&dbgen.Constant{Value: constant.MakeArray(nil)},
+			constant.MakeArray(nil),
 		},
 		{
 			"single",
 			dbgen.Arguments{
 				constant.MakeInt64(1),
 			},
-			&dbgen.Constant{
-				Value: constant.MakeArray([]constant.Value{
-					constant.MakeInt64(1),
-				}),
-			},
+			constant.MakeArray([]constant.Value{
+				constant.MakeInt64(1),
+			}),
 		},
 		{
 			"multiple",
@@ -54,21 +56,19 @@ func TestArrayFunc(t *testing.T) {
 				constant.MakeInt64(1),
 				constant.MakeFloat(2.0),
 			},
-			&dbgen.Constant{
-				Value: constant.MakeArray([]constant.Value{
-					constant.Null,
-					constant.MakeInt64(1),
-					constant.MakeFloat(2.0),
-				}),
-			},
+			constant.MakeArray([]constant.Value{
+				constant.Null,
+				constant.MakeInt64(1),
+				constant.MakeFloat(2.0),
+			}),
 		},
 	}
 
-	ctx := dbgen.NewCompileContext()
+	state := newTestState(1)
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			fn := &dbgen.ArrayFunc{}
-			result, err := fn.Compile(ctx, tc.args)
+			result, err := fn.Call(state, tc.args)
 			require.NoError(t, err)
 			require.Equal(t, tc.result, result)
 		})
@@ -84,11 +84,34 @@ func TestGenerateSeriesFunc(t *testing.T) {
 }
 
 func TestEncodeFunc(t *testing.T) {
-
+	testExprResults(t, []exprResult{
+		{"encode.hex('abc')", "616263"},
+		{"encode.base32('hello?')", "NBSWY3DPH4======"},
+		{"encode.base64('abc')", "YWJj"},
+		{"encode.base64url(decode.hex('fbff3f'))", "-_8_"},
+		{"encode.percent('a b/ü~')", "a%20b%2F%C3%BC~"},
+	})
 }
 
 func TestDecodeFunc(t *testing.T) {
-
+	testExprResults(t, []exprResult{
+		{"decode.hex('616263')", "abc"},
+		{"decode.base32('NBSWY3DPH4======')", "hello?"},
+		{"decode.base64('YWJj')", "abc"},
+		{"encode.hex(decode.base64url('-_8_'))", "fbff3f"},
+		{"decode.percent('a%20b%2F%C3%BC~')", "a b/ü~"},
+	})
+
+	for _, input := range []string{
+		"decode.hex('6')",
+		"decode.percent('%2')",
+		"decode.percent('%zz')",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		require.Error(t, err, input)
+	}
 }
 
 func TestPanicFunc(t *testing.T) {
@@ -144,11 +167,39 @@ func TestBitXorFunc(t *testing.T) {
 }
 
 func TestLogicalAndFunc(t *testing.T) {
-
+	testExprResults(t, []exprResult{
+		{"true AND true", "TRUE"},
+		{"true AND false", "FALSE"},
+		{"true AND NULL", "NULL"},
+		{"NULL AND false", "FALSE"},
+		{"NULL AND NULL", "NULL"},
+		{"false AND debug.panic(rownum)", "FALSE"},
+		{"rownum = 0 AND true", "TRUE"},
+		{"rownum = 1 AND debug.panic(rownum)", "FALSE"},
+		{"rownum = 0 AND NULL", "NULL"},
+	})
 }
 
 func TestLogicalOrFunc(t *testing.T) {
-
+	testExprResults(t, []exprResult{
+		{"false OR false", "FALSE"},
+		{"false OR true", "TRUE"},
+		{"false OR NULL", "NULL"},
+		{"NULL OR true", "TRUE"},
+		{"NULL OR NULL", "NULL"},
+		{"true OR debug.panic(rownum)", "TRUE"},
+		{"rownum = 0 OR debug.panic(rownum)", "TRUE"},
+		{"rownum = 1 OR NULL", "NULL"},
+	})
+	for _, input := range []string{
+		"rownum = 1 OR debug.panic(rownum)",
+		"rownum = 1 OR 1",
+	} {
+		state := newTestState(1)
+		compiled := compileTestExpr(t, state.CompileCtx, input)
+		_, err := compiled.Eval(state)
+		require.Error(t, err, input)
+	}
 }
 
 func TestAddFunc(t *testing.T) {
@@ -176,19 +227,178 @@ func TestModFunc(t *testing.T) {
 }
 
 func TestGreatestFunc(t *testing.T) {
-
+	testExprResults(t, []exprResult{
+		{"greatest(1, 3, 2)", "3"},
+		{"greatest(1, 2.5)", "2.5"},
+		{"greatest('a', NULL, 'b')", "b"},
+		{"greatest(NULL, NULL)", "NULL"},
+	})
 }
 
 func TestLeastFunc(t *testing.T) {
-
+	testExprResults(t, []exprResult{
+		{"least(3, 1, 2)", "1"},
+		{"least(NULL, 2, 1.5)", "1.5"},
+	})
 }
 
 func TestRoundFunc(t *testing.T) {
+	testExprResults(t, []exprResult{
+		{"round(2.5)", "3"},
+		{"round(-2.5)", "-3"},
+		{"round(3.14159, 2)", "3.14"},
+		{"round(1234.5, -2)", "1200"},
+		{"round(1250, -2)", "1300"},
+		{"round(-1250, -2)", "-1300"},
+		{"round(1249, -2)", "1200"},
+		{"round(12, 3)", "12"},
+		{"round(12, -5)", "0"},
+		{"round(NULL, 2)", "NULL"},
+		{"floor(-1.5)", "-2"},
+		{"floor(-1234, -2)", "-1300"},
+		{"ceil(1.2)", "2"},
+		{"ceil(1201, -2)", "1300"},
+		{"trunc(-1.7)", "-1"},
+		{"trunc(1.789, 2)", "1.78"},
+		{"trunc(-1299, -2)", "-1200"},
+	})
+}
 
+func TestDecimalFuncs(t *testing.T) {
+	for _, tc := range []exprResult{
+		{"0.1 + 0.2", "0.3"},
+		{"19.99 * 3", "59.97"},
+		{"round(2.345, 2)", "2.35"},
+		{"round(-2.345, 2)", "-2.35"},
+		{"round(2.5, 2)", "2.50"},
+		{"round(1234.5, -2)", "1200"},
+		{"floor(-2.345, 1)", "-2.4"},
+		{"trunc(2.349, 2)", "2.34"},
+		{"abs(-1.50)", "1.50"},
+		{"sign(-1.50)", "-1"},
+		{"greatest(1.5, 2, 1.75)", "2"},
+		{"format('%s', 1.10)", "1.10"},
+	} {
+		p := template.Parser{DecimalLiterals: true}
+		expr, err := p.ParseExpr(tc.input)
+		require.NoError(t, err)
+		state := newTestState(1)
+		compiled, err := state.CompileCtx.CompileExpr(expr)
+		require.NoError(t, err, tc.input)
+		result, err := compiled.Eval(state)
+		require.NoError(t, err, tc.input)
+		require.Equal(t, tc.expected, result.String(), tc.input)
+	}
+
+	state := newTestState(1)
+	for input, pattern := range map[string]string{
+		"rand.decimal(0, 99.99, 2)":     `^\d{1,2}\.\d{2}$`,
+		"rand.decimal(-1, 1, 0)":        `^(-1|0|1)$`,
+		"rand.decimal(0.999, 1.009, 2)": `^1\.00$`,
+		"rand.decimal(0, 1e30, 5)":      `^\d{1,31}\.\d{5}$`,
+		"rand.decimal(-0.5, -0.25, 3)":  `^-0\.(2[5-9]\d|[34]\d\d|500)$`,
+		"rand.decimal(NULL, 1, 2)":      `^NULL$`,
+	} {
+		compiled := compileTestExpr(t, state.CompileCtx, input)
+		for i := 0; i < 20; i++ {
+			result, err := compiled.Eval(state)
+			require.NoError(t, err)
+			require.Regexp(t, regexp.MustCompile(pattern), result.String(), input)
+		}
+	}
+
+	for _, input := range []string{
+		"rand.decimal(1, 0, 2)",
+		"rand.decimal(1.001, 1.009, 2)",
+		"rand.decimal(0, 1, -1)",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		require.Error(t, err, input)
+	}
+}
+
+func TestRandNumberFuncs(t *testing.T) {
+	state := newTestState(1)
+	for input, pattern := range map[string]string{
+		"rand.range(0, 100)":         `^\d{1,2}$`,
+		"rand.range(-3, -2)":         `^-3$`,
+		"rand.range_inclusive(5, 5)": `^5$`,
+		"rand.range_inclusive(-9223372036854775808, 9223372036854775807)":              `^-?\d+$`,
+		"rand.range(9223372036854775808, 18446744073709551615) >= 9223372036854775808": `^TRUE$`,
+		"rand.range(NULL, 1)":          `^NULL$`,
+		"rand.uniform(0, 1) < 1":       `^TRUE$`,
+		"rand.uniform(-2.5, -2)":       `^-2(\.([0-4]\d*|5))?$`,
+		"rand.uniform_inclusive(1, 1)": `^1$`,
+	} {
+		compiled := compileTestExpr(t, state.CompileCtx, input)
+		for i := 0; i < 20; i++ {
+			result, err := compiled.Eval(state)
+			require.NoError(t, err)
+			require.Regexp(t, regexp.MustCompile(pattern), result.String(), input)
+		}
+	}
+
+	for _, input := range []string{
+		"rand.range(1, 1)",
+		"rand.range_inclusive(2, 1)",
+		"rand.range(-1, 18446744073709551615)",
+		"rand.uniform(1, 1)",
+		"rand.uniform_inclusive(2, 1)",
+		"rand.uniform(0, 'Infinity'::double)",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		require.Error(t, err, input)
+	}
 }
 
 func TestCoalesceFunc(t *testing.T) {
+	testExprResults(t, []exprResult{
+		{"coalesce(NULL, 1, 2)", "1"},
+		{"coalesce(NULL, NULL)", "NULL"},
+		{"coalesce(NULL, rownum, debug.panic(rownum))", "0"},
+		{"coalesce(@x, 'a', debug.panic(rownum))", "a"},
+		{"coalesce(rownum, NULL)", "0"},
+	})
+}
+
+func TestShortCircuit(t *testing.T) {
+	for _, tc := range []struct {
+		input    string
+		expected string
+		x        string
+	}{
+		{"rownum = 1 AND (@x := true)", "FALSE", "NULL"},
+		{"rownum = 0 AND (@x := true)", "TRUE", "TRUE"},
+		{"rownum = 0 OR (@x := true)", "TRUE", "NULL"},
+		{"coalesce(rownum, @x := 1)", "0", "NULL"},
+		{"CASE WHEN rownum = 0 THEN 'a' ELSE @x := 'b' END", "a", "NULL"},
+		{"CASE WHEN rownum = 1 THEN @x := 'a' ELSE 'b' END", "b", "NULL"},
+		{"CASE rownum WHEN 1 THEN @x := 'a' WHEN 0 THEN 'b' END", "b", "NULL"},
+		{"CASE rownum WHEN @x := 0 THEN 'a' ELSE 'b' END", "a", "0"},
+	} {
+		state := newTestState(1)
+		compiled := compileTestExpr(t, state.CompileCtx, tc.input)
+		result, err := compiled.Eval(state)
+		require.NoError(t, err, tc.input)
+		require.Equal(t, tc.expected, result.String(), tc.input)
+		require.Equal(t, tc.x, state.CompileCtx.Variables[0].B.String(), tc.input)
+	}
+}
 
+func TestCaseValueWhen(t *testing.T) {
+	testExprResults(t, []exprResult{
+		{"CASE 2 WHEN 1 THEN 'a' WHEN 2 THEN 'b' ELSE 'c' END", "b"},
+		{"CASE 3 WHEN 1 THEN 'a' WHEN 2 THEN 'b' ELSE 'c' END", "c"},
+		{"CASE 3 WHEN 1 THEN 'a' END", "NULL"},
+		{"CASE NULL WHEN NULL THEN 'a' ELSE 'b' END", "b"},
+		{"CASE WHEN NULL THEN 'a' WHEN 1 < 2 THEN 'b' END", "b"},
+		{"CASE WHEN false THEN 'a' ELSE 'b' END", "b"},
+		{"CASE rownum WHEN 0 THEN 'zero' ELSE debug.panic(rownum) END", "zero"},
+	})
 }
 
 func TestLastFunc(t *testing.T) {
@@ -248,23 +458,46 @@ func TestRandShuffleFunc(t *testing.T) {
 }
 
 func TestSubstringFunc(t *testing.T) {
-
+	testExprResults(t, []exprResult{
+		{"substring('ⓘⓝⓟⓤⓣ' FROM 2 FOR 3)", "ⓝⓟⓤ"},
+		{"substring('ⓘⓝⓟⓤⓣ' FROM 2 FOR 3 USING OCTETS)", "\x93\x98\xe2"},
+		{"substring('input' FROM 3)", "put"},
+		{"substring('input' FOR 2)", "in"},
+		{"substring('input' FROM -1 FOR 3)", "i"},
+		{"substring(NULL FROM 1)", "NULL"},
+	})
 }
 
 func TestCharLengthFunc(t *testing.T) {
-
+	testExprResults(t, []exprResult{
+		{"char_length('ⓘⓝⓟⓤⓣ')", "5"},
+		{"char_length('ⓘⓝⓟⓤⓣ' USING OCTETS)", "15"},
+		{"char_length(NULL)", "NULL"},
+	})
 }
 
 func TestOctetLengthFunc(t *testing.T) {
-
+	testExprResults(t, []exprResult{
+		{"octet_length('ⓘⓝⓟⓤⓣ')", "15"},
+		{"octet_length('')", "0"},
+	})
 }
 
 func TestOverlayFunc(t *testing.T) {
-
+	testExprResults(t, []exprResult{
+		{"overlay('input' PLACING 'XY' FROM 2)", "iXYut"},
+		{"overlay('input' PLACING 'XY' FROM 2 FOR 0)", "iXYnput"},
+		{"overlay('ⓘⓝⓟⓤⓣ' PLACING '-' FROM 2 FOR 3 USING CHARACTERS)", "ⓘ-ⓣ"},
+	})
 }
 
 func TestConcatFunc(t *testing.T) {
-
+	testExprResults(t, []exprResult{
+		{"'a' || 'b'", "ab"},
+		{"'a' || 1", "a1"},
+		{"array[1] || array[2, 3]", "[1, 2, 3]"},
+		{"'a' || NULL", "NULL"},
+	})
 }
 
 func TestTimestampFunc(t *testing.T) {
@@ -274,3 +507,780 @@ func TestTimestampFunc(t *testing.T) {
 func TestTimestampWithTimeZoneFunc(t *testing.T) {
 
 }
+
+func TestFakeFunc(t *testing.T) {
+	testCases := []struct {
+		input   string
+		pattern string
+	}{
+		{"fake.first_name()", `^[A-Z][a-z]+$`},
+		{"fake.last_name('de_DE')", `^\pL+$`},
+		{"fake.email()", `^[a-z0-9.]+@[a-z]+\.(com|net|org)$`},
+		{"fake.email('zh_CN')", `^[a-z0-9.]+@[a-z.]+$`},
+		{"fake.phone('en_US')", `^[-+() .0-9]+$`},
+		{"fake.street_address('zh_CN')", `^\p{Han}+[0-9]+号([0-9]+室)?$`},
+		{"fake.city('zh_CN')", `^\p{Han}+$`},
+		{"fake.company('de_DE')", `\S`},
+		{"fake.country_code()", `^[A-Z]{2}$`},
+		{"fake.lorem_words(3)", `^[a-z]+ [a-z]+ [a-z]+$`},
+		{"fake.lorem_sentence()", `^[A-Z][a-z]*( [a-z]+){3,11}\.$`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			results := make([][]constant.Value, 2)
+			for i := range results {
+				state := newTestState(1)
+				compiled := compileTestExpr(t, state.CompileCtx, tc.input)
+				for j := 0; j < 20; j++ {
+					result, err := compiled.Eval(state)
+					require.NoError(t, err)
+					require.Regexp(t, regexp.MustCompile(tc.pattern), result.String())
+					results[i] = append(results[i], result)
+				}
+			}
+			require.Equal(t, results[0], results[1], "results must be reproducible")
+		})
+	}
+
+	ctx := dbgen.NewCompileContext()
+	expr, err := template.ParseExpr("fake.city('xx_YY')")
+	require.NoError(t, err)
+	_, err = ctx.CompileExpr(expr)
+	require.EqualError(t, err, "unknown locale: xx_YY")
+}
+
+func TestRandNetFuncs(t *testing.T) {
+	testCases := []struct {
+		input   string
+		pattern string
+	}{
+		{"rand.ipv4()", `^\d{1,3}(\.\d{1,3}){3}$`},
+		{"rand.ipv4_in('10.0.0.0/8')", `^10(\.\d{1,3}){3}$`},
+		{"rand.ipv4_in('192.168.1.77/30')", `^192\.168\.1\.7[6-9]$`},
+		{"rand.ipv4_in('172.16.0.0/12')", `^172\.(1[6-9]|2\d|3[01])(\.\d{1,3}){2}$`},
+		{"rand.ipv6()", `^[0-9a-f:]+$`},
+		{"rand.mac()", `^[0-9a-f]([02468ace])(:[0-9a-f]{2}){5}$`},
+		{"rand.hostname()", `^[a-z]+-\d{2}\.[a-z0-9-]+\.[a-z.]+$`},
+		{"rand.user_agent()", `\S`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			state := newTestState(1)
+			compiled := compileTestExpr(t, state.CompileCtx, tc.input)
+			for i := 0; i < 20; i++ {
+				result, err := compiled.Eval(state)
+				require.NoError(t, err)
+				require.Regexp(t, regexp.MustCompile(tc.pattern), result.String())
+			}
+		})
+	}
+
+	state := newTestState(1)
+	for input, size := range map[string]int{
+		"rand.ipv4('binary')":                  4,
+		"rand.ipv4_in('10.0.0.0/8', 'binary')": 4,
+		"rand.ipv6('binary')":                  16,
+		"rand.mac('binary')":                   6,
+	} {
+		compiled := compileTestExpr(t, state.CompileCtx, input)
+		result, err := compiled.Eval(state)
+		require.NoError(t, err)
+		b, err := constant.AsBytes(result)
+		require.NoError(t, err)
+		require.Len(t, b, size, input)
+	}
+
+	expr, err := template.ParseExpr("rand.mac('nothing')")
+	require.NoError(t, err)
+	_, err = state.CompileCtx.CompileExpr(expr)
+	require.EqualError(t, err, "unknown network identifier format: nothing")
+
+	_, err = state.CompileCtx.CompileExpr(&template.FuncExpr{
+		Name: template.NewQName("rand", "ipv4_in"),
+		Args: []template.Expr{&template.Constant{Value: constant.MakeBytes([]byte("::1/64"))}},
+	})
+	require.Error(t, err)
+}
+
+func TestIDFuncs(t *testing.T) {
+	const ts = "TIMESTAMP '2016-07-30 22:36:16.385'"
+	testCases := []struct {
+		input   string
+		pattern string
+	}{
+		{"rand.uuid()", `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{"uuid.v7(" + ts + ")", `^01563df3-6481-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{"ulid(" + ts + ")", `^01ARYZ6S41[0-9A-HJKMNP-TV-Z]{16}$`},
+		{"ksuid(" + ts + ")", `^[0-9A-Za-z]{27}$`},
+		{"snowflake(" + ts + ", 5, 7)", `^759517997340577799$`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			state := newTestState(1)
+			compiled := compileTestExpr(t, state.CompileCtx, tc.input)
+			for i := 0; i < 20; i++ {
+				result, err := compiled.Eval(state)
+				require.NoError(t, err)
+				require.Regexp(t, regexp.MustCompile(tc.pattern), result.String())
+			}
+		})
+	}
+
+	// Time-ordered IDs must sort by their timestamp component.
+	for _, fn := range []string{"uuid.v7", "ulid", "ksuid"} {
+		state := newTestState(1)
+		earlier := compileTestExpr(t, state.CompileCtx, fn+"(TIMESTAMP '2020-01-01 00:00:00')")
+		later := compileTestExpr(t, state.CompileCtx, fn+"(TIMESTAMP '2020-01-01 00:00:01')")
+		for i := 0; i < 20; i++ {
+			a, err := earlier.Eval(state)
+			require.NoError(t, err)
+			b, err := later.Eval(state)
+			require.NoError(t, err)
+			require.Less(t, a.String(), b.String(), fn)
+		}
+	}
+
+	for _, input := range []string{
+		"snowflake(" + ts + ", 1024, 0)",
+		"snowflake(" + ts + ", 0, 4096)",
+		"snowflake(TIMESTAMP '2000-01-01 00:00:00', 0, 0)",
+		"ksuid(TIMESTAMP '2000-01-01 00:00:00')",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		require.Error(t, err, input)
+	}
+}
+
+func TestStringFuncs(t *testing.T) {
+	testExprResults(t, []exprResult{
+		{"lower('ÀbC')", "àbc"},
+		{"upper('àbc')", "ÀBC"},
+		{"initcap('hello wORLD-foo 2nd')", "Hello World-Foo 2nd"},
+		{"trim('  abc  ')", "abc"},
+		{"ltrim('  abc  ')", "abc  "},
+		{"rtrim('  abc  ')", "  abc"},
+		{"trim('xyabcyx', 'xy')", "abc"},
+		{"trim('ⓘaⓘ', 'ⓘ')", "a"},
+		{"lpad('abc', 6, 'xy')", "xyxabc"},
+		{"rpad('abc', 5)", "abc  "},
+		{"lpad('abcdef', 3)", "abc"},
+		{"lpad('ⓘ', 3, '-')", "--ⓘ"},
+		{"lpad('ⓘ', 5, '-' USING OCTETS)", "--ⓘ"},
+		{"replace('abcabc', 'b', 'XY')", "aXYcaXYc"},
+		{"repeat('ab', 3)", "ababab"},
+		{"repeat('ab', -1)", ""},
+		{"reverse('ⓘⓝⓟ')", "ⓟⓝⓘ"},
+		{"reverse('ab' USING OCTETS)", "ba"},
+		{"position('ⓟ' IN 'ⓘⓝⓟ')", "3"},
+		{"position('ⓟ' IN 'ⓘⓝⓟ' USING OCTETS)", "7"},
+		{"position('x', 'abc')", "0"},
+		{"split_part('a,b,c', ',', 2)", "b"},
+		{"split_part('a,b,c', ',', -1)", "c"},
+		{"split_part('a,b,c', ',', 4)", ""},
+		{"string_to_array('a,b,,c', ',')", "[a, b, , c]"},
+		{"string_to_array('a,b,,c', ',', '')", "[a, b, NULL, c]"},
+		{"string_to_array('ⓘⓝ', NULL)", "[ⓘ, ⓝ]"},
+		{"array_to_string(array['a', NULL, 'c'], '-')", "a-c"},
+		{"array_to_string(array['a', NULL, 'c'], '-', '*')", "a-*-c"},
+		{"array_to_string(array[1, 2], ', ')", "1, 2"},
+		{"format('%s-%05d-%.2f-%x%%', 'a', 42, 3.14159, 255)", "a-00042-3.14-ff%"},
+		{"format('%2$s %1$s', 'world', 'hello')", "hello world"},
+		{"format('[%-4s|%4s]', 'ab', NULL)", "[ab  |    ]"},
+		{"upper(NULL)", "NULL"},
+	})
+
+	for _, input := range []string{
+		"split_part('a,b', ',', 0)",
+		"format('%s')",
+		"format('%q', 1)",
+		"repeat('ab', 1000000000000)",
+		"upper('a' USING OCTETS)",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		require.Error(t, err, input)
+	}
+}
+
+func TestMathFuncs(t *testing.T) {
+	testExprResults(t, []exprResult{
+		{"abs(-3)", "3"},
+		{"abs(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"abs(-1.5)", "1.5"},
+		{"sign(-7)", "-1"},
+		{"sign(0)", "0"},
+		{"sign(2.5)", "1"},
+		{"sqrt(16)", "4"},
+		{"cbrt(27)", "3"},
+		{"exp(0)", "1"},
+		{"ln(1)", "0"},
+		{"log10(1000)", "3"},
+		{"log(100)", "2"},
+		{"log(2, 1024)", "10"},
+		{"power(2, 10)", "1024"},
+		{"power(2, 64)", "18446744073709551616"},
+		{"power(-1, 99999999999999999999)", "-1"},
+		{"power(0, 18446744073709551616)", "0"},
+		{"power(1, 18446744073709551616)", "1"},
+		{"power(0, 0)", "1"},
+		{"power(2, -1)", "0.5"},
+		{"power(2.25, 0.5)", "1.5"},
+		{"sin(0)", "0"},
+		{"cos(0)", "1"},
+		{"atan2(1, 1) * 4 = pi()", "TRUE"},
+		{"degrees(pi())", "180"},
+		{"radians(180) = pi()", "TRUE"},
+		{"pi()", "3.141592653589793"},
+		{"width_bucket(5.35, 0.024, 10.06, 5)", "3"},
+		{"width_bucket(-1, 0, 10, 5)", "0"},
+		{"width_bucket(10, 0, 10, 5)", "6"},
+		{"width_bucket(7, 10, 0, 5)", "2"},
+		{"gcd(12, -18)", "6"},
+		{"gcd(0, 0)", "0"},
+		{"lcm(4, 6)", "12"},
+		{"lcm(9223372036854775807, 2)", "18446744073709551614"},
+		{"sqrt(NULL)", "NULL"},
+	})
+
+	for _, input := range []string{
+		"sqrt(-1)",
+		"ln(0)",
+		"log(-1)",
+		"log(1, 10)",
+		"asin(2)",
+		"power(-8, 0.5)",
+		"power(0, -1)",
+		"power(10, 1000000)",
+		"power(4, 9223372036854775807)",
+		"width_bucket(1, 0, 0, 5)",
+		"width_bucket(1, 0, 10, 0)",
+		"gcd(1.5, 3)",
+		"abs('a')",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		require.Error(t, err, input)
+	}
+}
+
+func TestHashFuncs(t *testing.T) {
+	testExprResults(t, []exprResult{
+		{"encode.hex(hash.md5('abc'))", "900150983cd24fb0d6963f7d28e17f72"},
+		{"encode.hex(hash.md5(123))", "202cb962ac59075b964b07152d234b70"},
+		{"encode.hex(hash.sha1('abc'))", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"encode.hex(hash.sha256('abc'))", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"encode.hex(hash.sha512('abc'))", "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f"},
+		{"hash.crc32('abc')", "891568578"},
+		{"hash.crc64('abc')", "3231342946509354535"},
+		{"hash.fnv64('abc')", "16654208175385433931"},
+		{"hash.fnv64(123)", "5003431119771845851"},
+		{"hash.murmur3('')", "0"},
+		{"hash.murmur3('', 1)", "1364076727"},
+		{"hash.murmur3('hello')", "613153351"},
+		{"hash.murmur3('The quick brown fox jumps over the lazy dog')", "776992547"},
+		{"hash.crc32(NULL)", "NULL"},
+	})
+}
+
+func TestTimeFuncs(t *testing.T) {
+	const ts = "TIMESTAMP '2016-07-30 22:36:16.385'"
+	testExprResults(t, []exprResult{
+		{"extract(year FROM " + ts + ")", "2016"},
+		{"extract(QUARTER FROM " + ts + ")", "3"},
+		{"extract(month FROM " + ts + ")", "7"},
+		{"extract(day FROM " + ts + ")", "30"},
+		{"extract(hour FROM " + ts + ")", "22"},
+		{"extract(minute FROM " + ts + ")", "36"},
+		{"extract(second FROM " + ts + ")", "16.385"},
+		{"extract(microsecond FROM " + ts + ")", "16385000"},
+		{"extract(week FROM " + ts + ")", "30"},
+		{"extract(dow FROM " + ts + ")", "6"},
+		{"extract(isodow FROM TIMESTAMP '2016-07-31 00:00:00')", "7"},
+		{"extract(doy FROM " + ts + ")", "212"},
+		{"extract(isoyear FROM TIMESTAMP '2016-01-01 00:00:00')", "2015"},
+		{"extract(decade FROM " + ts + ")", "201"},
+		{"extract(century FROM " + ts + ")", "21"},
+		{"extract(century FROM TIMESTAMP '2000-12-31 00:00:00')", "20"},
+		{"extract(millennium FROM " + ts + ")", "3"},
+		{"extract(epoch FROM " + ts + ") = 1469918176.385", "TRUE"},
+		{"epoch(" + ts + ") = 1469918176.385", "TRUE"},
+		{"day_of_week(" + ts + ")", "6"},
+		{"date_trunc('second', " + ts + ")", "2016-07-30 22:36:16"},
+		{"date_trunc('hour', " + ts + ")", "2016-07-30 22:00:00"},
+		{"date_trunc('day', " + ts + ")", "2016-07-30 00:00:00"},
+		{"date_trunc('week', " + ts + ")", "2016-07-25 00:00:00"},
+		{"date_trunc('month', " + ts + ")", "2016-07-01 00:00:00"},
+		{"date_trunc('quarter', " + ts + ")", "2016-07-01 00:00:00"},
+		{"date_trunc('year', " + ts + ")", "2016-01-01 00:00:00"},
+		{"date_trunc('century', " + ts + ")", "2001-01-01 00:00:00"},
+		{"to_char(" + ts + ", 'YYYY-MM-DD HH24:MI:SS.MS')", "2016-07-30 22:36:16.385"},
+		{"to_char(" + ts + ", 'HH12:MI AM, Dy Mon DDD \"Q\"Q')", "10:36 PM, Sat Jul 212 Q3"},
+		{"to_char(" + ts + ", 'Day, DD Month YYYY')", "Saturday , 30 July      2016"},
+		{"to_char(" + ts + ", 'FMDay, FMDD FMMonth IYYY-IW-ID')", "Saturday, 30 July 2016-30-6"},
+		{"to_char(" + ts + ", '%Y-%m-%d %H:%M:%S.%f %a %b %j %%')", "2016-07-30 22:36:16.385000 Sat Jul 212 %"},
+		{"to_char(" + ts + ", '%F %T %I%p %s')", "2016-07-30 22:36:16 10PM 1469918176"},
+		{"to_timestamp(1469918176)", "2016-07-30 22:36:16"},
+		{"to_timestamp(1469918176.385)", "2016-07-30 22:36:16.385"},
+		{"make_timestamp(2016, 7, 30, 22, 36, 16.385)", "2016-07-30 22:36:16.385"},
+		{"make_timestamp(2016, 2, 29, 0, 0, 0)", "2016-02-29 00:00:00"},
+		{ts + " AT TIME ZONE 'Asia/Hong_Kong'", "2016-07-31 06:36:16.385"},
+		{"extract(hour FROM " + ts + " AT TIME ZONE 'America/New_York')", "18"},
+		{"extract(year FROM NULL)", "NULL"},
+		{"DATE '2016-02-28' + 1", "2016-02-29"},
+		{"DATE '2016-03-01' - DATE '2016-02-01'", "29"},
+		{"DATE '2016-01-31' + INTERVAL 1 MONTH", "2016-02-29 00:00:00"},
+		{"DATE '2016-01-31' + TIME '12:30'", "2016-01-31 12:30:00"},
+		{"TIME '23:30:00' + INTERVAL 1 HOUR", "00:30:00"},
+		{"TIME '12:00:00.25' - TIME '11:00:00'", "01:00:00.25"},
+		{"DATE '2016-01-31' < DATE '2016-02-01'", "TRUE"},
+		{"DATE '2016-01-31' = TIMESTAMP '2016-01-31 00:00:00'", "TRUE"},
+		{"DATE '2016-01-31' < TIMESTAMP '2016-01-31 00:00:01'", "TRUE"},
+		{"DATE " + ts, "2016-07-30"},
+		{"TIME " + ts, "22:36:16.385"},
+		{"TIMESTAMP DATE '2016-07-30'", "2016-07-30 00:00:00"},
+		{"TIMESTAMP " + ts, "2016-07-30 22:36:16.385"},
+		{"DATE NULL", "NULL"},
+		{"extract(dow FROM DATE '2016-07-30')", "6"},
+		{"date_trunc('month', DATE '2016-07-30')", "2016-07-01 00:00:00"},
+		{"to_char(DATE '2016-07-30', 'DD/MM/YYYY')", "30/07/2016"},
+		{"TIMESTAMP '2016-01-31 12:00:00' + INTERVAL 1 MONTH", "2016-02-29 12:00:00"},
+		{"TIMESTAMP '2016-02-29 12:00:00' + INTERVAL 1 YEAR", "2017-02-28 12:00:00"},
+		{"TIMESTAMP '2016-05-31 12:00:00' - INTERVAL 1 QUARTER", "2016-02-29 12:00:00"},
+		{"TIMESTAMP '2016-01-31 12:00:00' + INTERVAL 1 MONTH + INTERVAL 1 DAY", "2016-03-01 12:00:00"},
+		{"INTERVAL 1 YEAR + INTERVAL 2 MONTH + INTERVAL 3 DAY + INTERVAL 4 HOUR", "1 year 2 mons 3 days 04:00:00"},
+		{"INTERVAL 1.5 MONTH", "1 mon 15 days"},
+		{"INTERVAL 1 MONTH > INTERVAL 29 DAY", "TRUE"},
+		{"TIMESTAMP '2020-01-01 00:00:00' + INTERVAL 3000000 HOUR", "2362-03-29 00:00:00"},
+	})
+
+	for _, input := range []string{
+		"extract(fortnight FROM " + ts + ")",
+		"date_trunc('dow', " + ts + ")",
+		"to_char(" + ts + ", '%Q')",
+		"make_timestamp(2015, 2, 29, 0, 0, 0)",
+		"make_timestamp(2015, 1, 1, 24, 0, 0)",
+		ts + " AT TIME ZONE 'Mars/Olympus_Mons'",
+		"extract(year FROM 1)",
+		"DATE '2016-02-30'",
+		"TIME '24:00:00'",
+		"DATE 1",
+		"INTERVAL 9223372036854775807 MICROSECOND + INTERVAL 1 MICROSECOND",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		require.Error(t, err, input)
+	}
+}
+
+func TestDateInTimeZone(t *testing.T) {
+	// A date is midnight in the session time zone, both when cast to a
+	// timestamp and when used directly as one.
+	for _, tc := range []exprResult{
+		{"extract(epoch FROM DATE '2024-01-01') = 1704038400", "TRUE"},
+		{"DATE '2024-01-01' + INTERVAL 1 HOUR = CAST(DATE '2024-01-01' AS TIMESTAMP) + INTERVAL 1 HOUR", "TRUE"},
+		{"DATE '2024-01-01' + TIME '12:30' = TIMESTAMP '2024-01-01 12:30:00'", "TRUE"},
+		{"INTERVAL 1 DAY + DATE '2024-01-01' = TIMESTAMP '2024-01-02 00:00:00'", "TRUE"},
+		{"DATE '2024-01-01' - INTERVAL 1 DAY = TIMESTAMP '2023-12-31 00:00:00'", "TRUE"},
+		{"date_trunc('day', DATE '2024-01-01') = CAST(DATE '2024-01-01' AS TIMESTAMP)", "TRUE"},
+		{"to_char(DATE '2024-01-01', '%s')", "1704038400"},
+		{"DATE '2024-01-01' = TIMESTAMP '2024-01-01 00:00:00'", "TRUE"},
+		{"TIMESTAMP '2024-01-01 00:00:01' > DATE '2024-01-01'", "TRUE"},
+		{"greatest(DATE '2024-01-02', TIMESTAMP '2024-01-01 12:00:00')", "2024-01-02"},
+		{"CASE DATE '2024-01-01' WHEN TIMESTAMP '2024-01-01 00:00:00' THEN 'same' END", "same"},
+	} {
+		state := newTestState(1)
+		state.CompileCtx.TimeZone = time.FixedZone("UTC+8", 8*60*60)
+		compiled := compileTestExpr(t, state.CompileCtx, tc.input)
+		result, err := compiled.Eval(state)
+		require.NoError(t, err, tc.input)
+		require.Equal(t, tc.expected, result.String(), tc.input)
+	}
+}
+
+func TestRandTimeFuncs(t *testing.T) {
+	start, err := time.Parse("2006-01-02 15:04:05", "2020-01-01 00:00:00")
+	require.NoError(t, err)
+	end := start.AddDate(0, 3, 0)
+	const (
+		rangeArgs    = "TIMESTAMP '2020-01-01 00:00:00', TIMESTAMP '2020-04-01 00:00:00'"
+		dateArgs     = "TIMESTAMP '2020-01-01 00:00:00', TIMESTAMP '2020-03-31 00:00:00'"
+		nightWeights = "array[1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0]"
+	)
+	testCases := []struct {
+		input string
+		check func(t time.Time) bool
+	}{
+		{"rand.timestamp(" + rangeArgs + ")", func(t time.Time) bool { return true }},
+		{"rand.timestamp(" + rangeArgs + ", 'weekday')", func(t time.Time) bool {
+			return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+		}},
+		{"rand.timestamp(" + rangeArgs + ", 'business_hours')", func(t time.Time) bool {
+			return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday && t.Hour() >= 9 && t.Hour() < 17
+		}},
+		{"rand.timestamp(" + rangeArgs + ", " + nightWeights + ")", func(t time.Time) bool { return t.Hour() < 6 }},
+		// Short ranges are sampled exactly.
+		{"rand.timestamp(TIMESTAMP '2020-01-03 16:30:00', TIMESTAMP '2020-01-06 09:30:00', 'business_hours')", func(t time.Time) bool {
+			return t.Day() == 3 && t.Hour() == 16 && t.Minute() >= 30 || t.Day() == 6 && t.Hour() == 9 && t.Minute() < 30
+		}},
+		{"rand.date(" + dateArgs + ")", func(t time.Time) bool {
+			return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+		}},
+		{"rand.date(" + dateArgs + ", 'weekday')", func(t time.Time) bool {
+			return t.Hour() == 0 && t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+		}},
+		{"rand.date(DATE '2020-01-01', DATE '2020-01-31')", func(t time.Time) bool { return t.Month() == time.January }},
+		{"rand.timestamp(DATE '2020-01-01', DATE '2020-01-02')", func(t time.Time) bool { return t.Day() == 1 }},
+		{"rand.date(TIMESTAMP '2020-01-03 12:00:00', TIMESTAMP '2020-01-06 12:00:00', 'weekday')", func(t time.Time) bool {
+			return t.Day() == 3 || t.Day() == 6
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			state := newTestState(1)
+			compiled := compileTestExpr(t, state.CompileCtx, tc.input)
+			for i := 0; i < 200; i++ {
+				result, err := compiled.Eval(state)
+				require.NoError(t, err)
+				var ts time.Time
+				if result.Kind() == constant.KindDate {
+					ts, err = constant.AsDate(result)
+				} else {
+					ts, err = constant.AsTimestamp(result)
+				}
+				require.NoError(t, err)
+				require.False(t, ts.Before(start), ts)
+				require.True(t, ts.Before(end), ts)
+				require.True(t, tc.check(ts), ts)
+			}
+		})
+	}
+
+	// The last day of a date range is included.
+	state := newTestState(1)
+	compiled := compileTestExpr(t, state.CompileCtx, "rand.date(TIMESTAMP '2020-01-01 00:00:00', TIMESTAMP '2020-01-02 00:00:00')")
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		result, err := compiled.Eval(state)
+		require.NoError(t, err)
+		seen[result.String()] = true
+	}
+	require.Equal(t, map[string]bool{"2020-01-01": true, "2020-01-02": true}, seen)
+
+	for _, input := range []string{
+		"rand.timestamp(TIMESTAMP '2020-01-01 00:00:00', TIMESTAMP '2020-01-01 00:00:00')",
+		"rand.timestamp(" + rangeArgs + ", 'lunch_break')",
+		"rand.timestamp(" + rangeArgs + ", array[1, 2, 3])",
+		"rand.timestamp(" + rangeArgs + ", array[0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0])",
+		"rand.timestamp(TIMESTAMP '2020-01-04 00:00:00', TIMESTAMP '2020-01-06 00:00:00', 'weekday')",
+		"rand.date(TIMESTAMP '2020-01-02 00:00:00', TIMESTAMP '2020-01-01 00:00:00')",
+		"rand.date(TIMESTAMP '2020-01-04 00:00:00', TIMESTAMP '2020-01-05 00:00:00', 'business_hours')",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		require.Error(t, err, input)
+	}
+}
+
+func TestRegexpFuncs(t *testing.T) {
+	testExprResults(t, []exprResult{
+		{"regexp_like('abc123', '^[a-z]+[0-9]+$')", "TRUE"},
+		{"regexp_like('ABC', '^abc$')", "FALSE"},
+		{"regexp_like('ABC', '^abc$', 'i')", "TRUE"},
+		{"regexp_like('ABC', '^abc$', 'ic')", "FALSE"},
+		{"regexp_like(NULL, 'a')", "NULL"},
+		{"regexp_replace('john.doe@example.com', '@.*$', '')", "john.doe"},
+		{"regexp_replace('a-b-c', '-', '+')", "a+b-c"},
+		{"regexp_replace('a-b-c', '-', '+', 'g')", "a+b+c"},
+		{"regexp_replace('John Smith', '(\\w+) (\\w+)', '\\2, \\1')", "Smith, John"},
+		{"regexp_replace('abc', 'b', '[\\&]')", "a[b]c"},
+		{"regexp_substr('a1b22c333', '[0-9]+')", "1"},
+		{"regexp_substr('a1b22c333', '[0-9]+', 3)", "333"},
+		{"regexp_substr('a1b22c333', '[0-9]+', 4)", "NULL"},
+		{"regexp_matches('john.doe@example.com', '^([^@]+)@(.+)$')", "[john.doe, example.com]"},
+		{"regexp_matches('abc', 'b')", "[b]"},
+		{"regexp_matches('abc', 'x')", "NULL"},
+	})
+
+	for _, input := range []string{
+		"regexp_like('a', '(')",
+		"regexp_like('a', 'a', 'x')",
+		"regexp_matches('a', 'a', 'g')",
+		"regexp_substr('a', 'a', 0)",
+		// Constant patterns and flags are checked even if the input is not.
+		"regexp_like(rownum::text, '(')",
+		"regexp_like(rownum::text, 'a', 'x')",
+		"regexp_replace(rownum::text, 'a', 'b', 'q')",
+		"regexp_matches(rownum::text, '[')",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		require.Error(t, err, input)
+	}
+
+	// Patterns applied to generated input are compiled only once.
+	state := newTestState(1)
+	compiled := compileTestExpr(t, state.CompileCtx, "regexp_replace(fake.email(), '@.*$', '')")
+	_, isUnprepared := compiled.(*dbgen.FunctionCall).Fn.(dbgen.RegexpReplaceFunc)
+	require.False(t, isUnprepared)
+	for i := 0; i < 20; i++ {
+		result, err := compiled.Eval(state)
+		require.NoError(t, err)
+		require.Regexp(t, regexp.MustCompile(`^[a-z0-9.]+$`), result.String())
+	}
+	// Patterns which are not constant are compiled on every row.
+	compiled = compileTestExpr(t, state.CompileCtx, "regexp_like('a1', 'a' || rownum)")
+	state.RowNum = 1
+	result, err := compiled.Eval(state)
+	require.NoError(t, err)
+	require.Equal(t, "TRUE", result.String())
+
+	re1, err := state.CompileCtx.CompileRegexp("@.*$")
+	require.NoError(t, err)
+	re2, err := state.CompileCtx.CompileRegexp("@.*$")
+	require.NoError(t, err)
+	require.Same(t, re1, re2)
+}
+
+func TestJSONFuncs(t *testing.T) {
+	testExprResults(t, []exprResult{
+		{`json(' {"a" : [1, 2.50]} ')`, `{"a":[1,2.50]}`},
+		{`json(NULL)`, `NULL`},
+		{`to_json('a"b')`, `"a\"b"`},
+		{`to_json(array[1, NULL])`, `[1,null]`},
+		{`to_json(NULL)`, `null`},
+		{`json_object('id', 1, 'name', 'x', 'tags', array['a'], 'extra', json('{"k":true}'))`, `{"id":1,"name":"x","tags":["a"],"extra":{"k":true}}`},
+		{`json_object()`, `{}`},
+		{`json_array(1, 'a', NULL, TRUE)`, `[1,"a",null,true]`},
+		{`json_build_from_array(array[1, 2])`, `[1,2]`},
+		{`json_build_from_array(array['a', 'b'], array[1, NULL])`, `{"a":1,"b":null}`},
+		{`json_extract('{"a":{"b c":[10,{"d":"x"}]}}', '$.a."b c"[1].d')`, `"x"`},
+		{`json_extract(json_object('a', array[1, 2]), '$.a')`, `[1,2]`},
+		{`json_extract('{"a":1}', '$')`, `{"a":1}`},
+		{`json_extract('{"a":1}', '$.b')`, `NULL`},
+		{`json_extract('[1]', '$[3]')`, `NULL`},
+	})
+
+	for _, input := range []string{
+		"json('{')",
+		"json_object('a')",
+		"json_object(NULL, 1)",
+		"json_build_from_array(array['a'], array[1, 2])",
+		"json_build_from_array(1)",
+		"json_extract('{}', 'a')",
+		"json_extract('{}', '$.')",
+		"json_extract('{}', '$[x]')",
+		"json_extract(json_object('a', rownum), '$.')",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		require.Error(t, err, input)
+	}
+
+	// Paths applied to generated documents are parsed only once.
+	state := newTestState(1)
+	state.RowNum = 7
+	compiled := compileTestExpr(t, state.CompileCtx, "json_extract(json_object('a', rownum), '$.a')")
+	_, isUnprepared := compiled.(*dbgen.FunctionCall).Fn.(dbgen.JSONExtractFunc)
+	require.False(t, isUnprepared)
+	result, err := compiled.Eval(state)
+	require.NoError(t, err)
+	require.Equal(t, "7", result.String())
+	// Paths which are not constant are parsed on every row.
+	compiled = compileTestExpr(t, state.CompileCtx, `json_extract('{"a7":1}', '$.a' || rownum)`)
+	result, err = compiled.Eval(state)
+	require.NoError(t, err)
+	require.Equal(t, "1", result.String())
+}
+
+func TestCast(t *testing.T) {
+	testExprResults(t, []exprResult{
+		{"cast('42' AS integer) + 1", "43"},
+		{"'3.7'::double * 2", "7.4"},
+		{"cast(1.005 AS decimal(4, 2))", "1.01"},
+		{"12::text || 'px'", "12px"},
+		{"'t'::boolean", "TRUE"},
+		{"'2020-01-31'::date + 1", "2020-02-01"},
+		{"'2020-01-31 10:00'::timestamp::date", "2020-01-31"},
+		{"'1 day 2 hours'::interval", "1 day 02:00:00"},
+		{"cast(NULL AS bigint)", "NULL"},
+		{"-'5'::int", "-5"},
+		{"extract(year FROM '2021-06-01'::date)", "2021"},
+	})
+
+	for _, input := range []string{
+		"cast('abc' AS integer)",
+		"cast(3000000000 AS integer)",
+		"1::bytea",
+		"cast(1.5 AS boolean)",
+		"'2020-02-30'::date",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		var convertErr *constant.ConvertError
+		require.ErrorAs(t, err, &convertErr, input)
+	}
+}
+
+func TestArrayFuncs(t *testing.T) {
+	testExprResults(t, []exprResult{
+		{"cardinality(array[1, 2, 3])", "3"},
+		{"cardinality(array[])", "0"},
+		{"cardinality(NULL)", "NULL"},
+		{"array_length(array['a', 'b'])", "2"},
+		{"array_length(array['a', 'b'], 1)", "2"},
+		{"array_length(array[])", "NULL"},
+		{"array_append(array[1, 2], 3)", "[1, 2, 3]"},
+		{"array_append(NULL, 3)", "[3]"},
+		{"array_prepend(0, array[1, 2])", "[0, 1, 2]"},
+		{"array_cat(array[1], array[2, 3])", "[1, 2, 3]"},
+		{"array_cat(NULL, array[2])", "[2]"},
+		{"array_slice(array[1, 2, 3, 4], 2, 3)", "[2, 3]"},
+		{"array_slice(array[1, 2, 3, 4], 0, 10)", "[1, 2, 3, 4]"},
+		{"array_slice(array[1, 2, 3, 4], 3, 2)", "[]"},
+		{"(array[1, 2, 3, 4])[2:3]", "[2, 3]"},
+		{"generate_series(1, 10)[:2]", "[1, 2]"},
+		{"generate_series(1, 10)[9:]", "[9, 10]"},
+		{"array_position(array['a', 'b', 'b'], 'b')", "2"},
+		{"array_position(array['a', NULL], NULL)", "2"},
+		{"array_position(array['a'], 'z')", "NULL"},
+		{"array_position(array[1, 'a'], 'a')", "2"},
+		{"array_contains(array[1, 2], 2)", "TRUE"},
+		{"array_contains(array[1, 2], 5)", "FALSE"},
+		{"array_distinct(array[3, 1, 3, NULL, 1, NULL])", "[3, 1, NULL]"},
+		{"array_sort(array[3, NULL, 1, 2])", "[1, 2, 3, NULL]"},
+		{"array_sort(array['b', 'a'])", "[a, b]"},
+		{"array_sum(array[1, NULL, 2.5])", "3.5"},
+		{"array_sum(array[])", "NULL"},
+		{"array_min(array[3, NULL, 1])", "1"},
+		{"array_max(array['a', 'c', 'b'])", "c"},
+		{"array_max(array[NULL])", "NULL"},
+		{"rand.subset(array[1, 2, 3], 1)", "[1, 2, 3]"},
+		{"rand.subset(array[1, 2, 3], 0)", "[]"},
+	})
+
+	for _, input := range []string{
+		"cardinality(1)",
+		"array_length(array[1], 1, 2)",
+		"array_sort(array[1, 'a'])",
+		"array_sum(array['a', 'b'])",
+		"rand.subset(array[1], 1.5)",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		require.Error(t, err, input)
+	}
+
+	// Each element is kept independently, so the subset changes per row.
+	state := newTestState(1)
+	compiled := compileTestExpr(t, state.CompileCtx, "rand.subset(generate_series(1, 100), 0.3)")
+	sizes := make(map[int]bool)
+	for i := 0; i < 20; i++ {
+		result, err := compiled.Eval(state)
+		require.NoError(t, err)
+		elems, err := constant.AsArray(result)
+		require.NoError(t, err)
+		require.InDelta(t, 30, len(elems), 20)
+		sizes[len(elems)] = true
+	}
+	require.Greater(t, len(sizes), 1)
+}
+
+func TestLambdaFuncs(t *testing.T) {
+	testExprResults(t, []exprResult{
+		{"array_map(array[1, 2, 3], x -> x * 10)", "[10, 20, 30]"},
+		{"array_map(array['a', 'b'], date -> date || '!')", "[a!, b!]"},
+		{"array_map(NULL, x -> x)", "NULL"},
+		{"array_filter(array[1, 2, 3, 4], x -> mod(x, 2) = 0)", "[2, 4]"},
+		{"array_filter(array[1, NULL, 3], x -> x > 1)", "[3]"},
+		{"array_reduce(array[1, 2, 3], 0, (acc, x) -> acc + x)", "6"},
+		{"array_reduce(array[], 'z', (acc, x) -> acc || x)", "z"},
+		{"array_generate(3, i -> i * i)", "[1, 4, 9]"},
+		{"array_generate(0, i -> i)", "[]"},
+		{"array_map(array[1, 2], x -> array_map(array[10, 20], y -> x + y))", "[[11, 21], [12, 22]]"},
+		{"array_map(generate_series(1, 3), x -> (x))", "[1, 2, 3]"},
+	})
+
+	for _, input := range []string{
+		"array_map(array[1], 1)",
+		"array_map(array[1], (x, y) -> x)",
+		"array_reduce(array[1], 0, x -> x)",
+		"array_generate(-1, i -> i)",
+		"abs(x -> x)",
+	} {
+		expr, err := template.ParseExpr(input)
+		require.NoError(t, err, input)
+		_, err = dbgen.NewCompileContext().CompileExpr(expr)
+		require.Error(t, err, input)
+	}
+
+	// The lambda is evaluated for each element and each row.
+	state := newTestState(1)
+	_, err := compileTestExpr(t, state.CompileCtx, "array_filter(array[1], x -> x + 1)").Eval(state)
+	require.Error(t, err)
+	compiled := compileTestExpr(t, state.CompileCtx, "array_generate(rownum, i -> rand.range(0, 100))")
+	state.RowNum = 5
+	tags, err := compiled.Eval(state)
+	require.NoError(t, err)
+	require.Regexp(t, regexp.MustCompile(`^\[\d{1,2}(, \d{1,2}){4}\]$`), tags.String())
+	compiled = compileTestExpr(t, state.CompileCtx, "array_generate(5, i -> rand.uuid())")
+	seen := make(map[string]bool)
+	for i := 0; i < 10; i++ {
+		result, err := compiled.Eval(state)
+		require.NoError(t, err)
+		elems, err := constant.AsArray(result)
+		require.NoError(t, err)
+		require.Len(t, elems, 5)
+		for _, elem := range elems {
+			seen[elem.String()] = true
+		}
+	}
+	require.Greater(t, len(seen), 40)
+
+	// The array may also depend on the row.
+	compiled = compileTestExpr(t, state.CompileCtx, "array_map(generate_series(1, rownum), x -> x + rownum)")
+	state.RowNum = 2
+	result, err := compiled.Eval(state)
+	require.NoError(t, err)
+	require.Equal(t, "[3, 4]", result.String())
+}
+
+func newTestState(seed int64) *dbgen.State {
+	return &dbgen.State{
+		Rng:        rand.NewSource(seed).(rand.Source64),
+		CompileCtx: dbgen.NewCompileContext(),
+	}
+}
+
+type exprResult struct {
+	input    string
+	expected string
+}
+
+// testExprResults checks the string representation of constant expressions.
+func testExprResults(t *testing.T, testCases []exprResult) {
+	for _, tc := range testCases {
+		state := newTestState(1)
+		compiled := compileTestExpr(t, state.CompileCtx, tc.input)
+		result, err := compiled.Eval(state)
+		require.NoError(t, err, tc.input)
+		require.Equal(t, tc.expected, result.String(), tc.input)
+	}
+}
+
+func compileTestExpr(t *testing.T, ctx *dbgen.CompileContext, input string) dbgen.Compiled {
+	expr, err := template.ParseExpr(input)
+	require.NoError(t, err)
+	compiled, err := ctx.CompileExpr(expr)
+	require.NoError(t, err)
+	return compiled
+}
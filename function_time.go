@@ -0,0 +1,912 @@
+package dbgen
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gozssky/dbgen/constant"
+)
+
+// DateField is a field of a timestamp, used by 'extract' and 'date_trunc'.
+type DateField int
+
+const (
+	DateFieldMicrosecond DateField = iota
+	DateFieldMillisecond
+	DateFieldSecond
+	DateFieldMinute
+	DateFieldHour
+	DateFieldDay
+	DateFieldWeek
+	DateFieldMonth
+	DateFieldQuarter
+	DateFieldYear
+	DateFieldDecade
+	DateFieldCentury
+	DateFieldMillennium
+	// The fields below can be extracted but not truncated to.
+	DateFieldDayOfWeek
+	DateFieldIsoDayOfWeek
+	DateFieldDayOfYear
+	DateFieldIsoYear
+	DateFieldEpoch
+)
+
+var dateFields = map[string]DateField{
+	"microsecond":  DateFieldMicrosecond,
+	"microseconds": DateFieldMicrosecond,
+	"millisecond":  DateFieldMillisecond,
+	"milliseconds": DateFieldMillisecond,
+	"second":       DateFieldSecond,
+	"seconds":      DateFieldSecond,
+	"minute":       DateFieldMinute,
+	"minutes":      DateFieldMinute,
+	"hour":         DateFieldHour,
+	"hours":        DateFieldHour,
+	"day":          DateFieldDay,
+	"days":         DateFieldDay,
+	"week":         DateFieldWeek,
+	"weeks":        DateFieldWeek,
+	"month":        DateFieldMonth,
+	"months":       DateFieldMonth,
+	"quarter":      DateFieldQuarter,
+	"year":         DateFieldYear,
+	"years":        DateFieldYear,
+	"decade":       DateFieldDecade,
+	"century":      DateFieldCentury,
+	"millennium":   DateFieldMillennium,
+	"dow":          DateFieldDayOfWeek,
+	"isodow":       DateFieldIsoDayOfWeek,
+	"doy":          DateFieldDayOfYear,
+	"isoyear":      DateFieldIsoYear,
+	"epoch":        DateFieldEpoch,
+}
+
+func parseDateField(name string) (DateField, error) {
+	field, ok := dateFields[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown date field: %s", name)
+	}
+	return field, nil
+}
+
+// ExtractFunc implements the 'extract', 'epoch' and 'day_of_week' SQL
+// functions.
+type ExtractFunc struct {
+	oneArg
+	Field DateField
+}
+
+func (f ExtractFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	t, err := asDateTime(args[0], state.CompileCtx.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	return extractField(t, f.Field), nil
+}
+
+// asDateTime converts a timestamp or a date to a time. A date is midnight
+// of that day in loc, the session time zone, as when it is cast to a
+// timestamp.
+func asDateTime(v constant.Value, loc *time.Location) (time.Time, error) {
+	if v.Kind() == constant.KindDate {
+		d, err := constant.AsDate(v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		year, month, day := d.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, loc), nil
+	}
+	return constant.AsTimestamp(v)
+}
+
+// extractField returns a field of a timestamp. The second, millisecond and
+// epoch fields include the fractional seconds and are floats, all other
+// fields are integers.
+func extractField(t time.Time, field DateField) constant.Value {
+	year := t.Year()
+	switch field {
+	case DateFieldMicrosecond:
+		return constant.MakeInt64(int64(t.Second())*1e6 + int64(t.Nanosecond()/1e3))
+	case DateFieldMillisecond:
+		return constant.MakeFloat(float64(t.Second())*1e3 + float64(t.Nanosecond())/1e6)
+	case DateFieldSecond:
+		return constant.MakeFloat(float64(t.Second()) + float64(t.Nanosecond())/1e9)
+	case DateFieldMinute:
+		return constant.MakeInt64(int64(t.Minute()))
+	case DateFieldHour:
+		return constant.MakeInt64(int64(t.Hour()))
+	case DateFieldDay:
+		return constant.MakeInt64(int64(t.Day()))
+	case DateFieldWeek:
+		_, week := t.ISOWeek()
+		return constant.MakeInt64(int64(week))
+	case DateFieldMonth:
+		return constant.MakeInt64(int64(t.Month()))
+	case DateFieldQuarter:
+		return constant.MakeInt64(int64(t.Month()-1)/3 + 1)
+	case DateFieldYear:
+		return constant.MakeInt64(int64(year))
+	case DateFieldDecade:
+		return constant.MakeInt64(floorDiv(int64(year), 10))
+	case DateFieldCentury:
+		return constant.MakeInt64(ordinalPeriod(year, 100))
+	case DateFieldMillennium:
+		return constant.MakeInt64(ordinalPeriod(year, 1000))
+	case DateFieldDayOfWeek:
+		return constant.MakeInt64(int64(t.Weekday()))
+	case DateFieldIsoDayOfWeek:
+		return constant.MakeInt64(int64(isoWeekday(t)))
+	case DateFieldDayOfYear:
+		return constant.MakeInt64(int64(t.YearDay()))
+	case DateFieldIsoYear:
+		isoYear, _ := t.ISOWeek()
+		return constant.MakeInt64(int64(isoYear))
+	default:
+		return constant.MakeFloat(epochSeconds(t))
+	}
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+// ordinalPeriod returns the century or millennium of a year, counting from
+// year 1 as in `the 21st century started in 2001`. Negative numbers denote
+// periods before the common era, where year 0 is 1 BC.
+func ordinalPeriod(year, length int) int64 {
+	if year > 0 {
+		return int64((year + length - 1) / length)
+	}
+	return -int64((1 - year + length - 1) / length)
+}
+
+// periodStart returns the first year of the century or millennium of a year.
+// Before the common era, this is the earliest year of the period.
+func periodStart(year, length int) int {
+	period := int(ordinalPeriod(year, length))
+	if period > 0 {
+		return (period-1)*length + 1
+	}
+	return period*length + 1
+}
+
+// isoWeekday returns the ISO 8601 day of the week, from Monday (1) to Sunday (7).
+func isoWeekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+	return int(t.Weekday())
+}
+
+func epochSeconds(t time.Time) float64 {
+	return float64(t.Unix()) + float64(t.Nanosecond())/1e9
+}
+
+// DateTruncFunc implements the 'date_trunc' SQL function.
+type DateTruncFunc struct {
+	twoArgs
+}
+
+func (DateTruncFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	unit, err := constant.AsBytes(args[0])
+	if err != nil {
+		return nil, err
+	}
+	field, err := parseDateField(string(unit))
+	if err != nil {
+		return nil, err
+	}
+	if field > DateFieldMillennium {
+		return nil, fmt.Errorf("date_trunc does not support unit: %s", unit)
+	}
+	t, err := asDateTime(args[1], state.CompileCtx.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeTimestamp(truncateTime(t, field)), nil
+}
+
+// truncateTime truncates a timestamp to the given field in its own time zone.
+func truncateTime(t time.Time, field DateField) time.Time {
+	year, month, day := t.Date()
+	hour, minute, sec := t.Clock()
+	nsec := t.Nanosecond()
+	switch field {
+	case DateFieldMicrosecond:
+		nsec -= nsec % 1e3
+	case DateFieldMillisecond:
+		nsec -= nsec % 1e6
+	default:
+		nsec = 0
+	}
+	if field >= DateFieldMinute {
+		sec = 0
+	}
+	if field >= DateFieldHour {
+		minute = 0
+	}
+	if field >= DateFieldDay {
+		hour = 0
+	}
+	switch field {
+	case DateFieldWeek:
+		day -= isoWeekday(t) - 1
+	case DateFieldMonth:
+		day = 1
+	case DateFieldQuarter:
+		month, day = (month-1)/3*3+1, 1
+	case DateFieldYear:
+		month, day = 1, 1
+	case DateFieldDecade:
+		year, month, day = int(floorDiv(int64(year), 10)*10), 1, 1
+	case DateFieldCentury:
+		year, month, day = periodStart(year, 100), 1, 1
+	case DateFieldMillennium:
+		year, month, day = periodStart(year, 1000), 1, 1
+	}
+	return time.Date(year, month, day, hour, minute, sec, nsec, t.Location())
+}
+
+// ToTimestampFunc implements the 'to_timestamp' SQL function, which converts
+// seconds since the Unix epoch into a timestamp. Fractional seconds are
+// rounded to microseconds.
+type ToTimestampFunc struct {
+	oneArg
+}
+
+func (ToTimestampFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	if args[0] == constant.Null {
+		return constant.Null, nil
+	}
+	if constant.IsInt64(args[0]) {
+		secs, _ := constant.AsInt64(args[0])
+		return constant.MakeTimestamp(time.Unix(secs, 0).In(state.CompileCtx.TimeZone)), nil
+	}
+	secs, err := constant.AsFloat(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if math.IsNaN(secs) || math.IsInf(secs, 0) || math.Abs(secs) > 1<<62 {
+		return nil, fmt.Errorf("timestamp out of range: %v", secs)
+	}
+	whole, frac := math.Modf(secs)
+	t := time.Unix(int64(whole), int64(math.Round(frac*1e6))*1e3)
+	return constant.MakeTimestamp(t.In(state.CompileCtx.TimeZone)), nil
+}
+
+// MakeTimestampFunc implements the 'make_timestamp' SQL function. The
+// seconds may be fractional and are rounded to microseconds. The timestamp
+// is in the default time zone.
+type MakeTimestampFunc struct {
+	varArgs
+}
+
+func (MakeTimestampFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	if len(args) != 6 {
+		return nil, fmt.Errorf("make_timestamp requires 6 arguments, got %d", len(args))
+	}
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	var parts [5]int64
+	for i := range parts {
+		part, err := constant.AsInt64(args[i])
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = part
+	}
+	secs, err := constant.AsFloat(args[5])
+	if err != nil {
+		return nil, err
+	}
+	year, month, day, hour, minute := parts[0], parts[1], parts[2], parts[3], parts[4]
+	if year < -9999 || year > 9999 || month < 1 || month > 12 || day < 1 || day > 31 ||
+		hour < 0 || hour > 23 || minute < 0 || minute > 59 || !(secs >= 0 && secs < 60) {
+		return nil, fmt.Errorf("date/time field value out of range: %d-%02d-%02d %02d:%02d:%v", year, month, day, hour, minute, secs)
+	}
+	whole, frac := math.Modf(secs)
+	t := time.Date(int(year), time.Month(month), int(day), int(hour), int(minute), int(whole), int(math.Round(frac*1e6))*1e3, state.CompileCtx.TimeZone)
+	if t.Day() != int(day) {
+		return nil, fmt.Errorf("date field value out of range: %d-%02d-%02d", year, month, day)
+	}
+	return constant.MakeTimestamp(t), nil
+}
+
+// AtTimeZoneFunc implements the 'AT TIME ZONE' SQL operator. It converts a
+// timestamp to the given time zone, keeping the instant it represents.
+type AtTimeZoneFunc struct {
+	twoArgs
+}
+
+func (AtTimeZoneFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	t, err := constant.AsTimestamp(args[0])
+	if err != nil {
+		return nil, err
+	}
+	zone, err := constant.AsBytes(args[1])
+	if err != nil {
+		return nil, err
+	}
+	loc, err := state.CompileCtx.ParseTimeZone(string(zone))
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeTimestamp(t.In(loc)), nil
+}
+
+// ToCharFunc implements the 'to_char' SQL function.
+//
+// A format containing `%` uses strftime directives, for example
+// `%Y-%m-%d %H:%M:%S`. Otherwise the format uses PostgreSQL template
+// patterns, for example `YYYY-MM-DD HH24:MI:SS`.
+type ToCharFunc struct {
+	twoArgs
+}
+
+func (ToCharFunc) Call(state *State, args Arguments) (constant.Value, error) {
+	if hasNull(args) {
+		return constant.Null, nil
+	}
+	t, err := asDateTime(args[0], state.CompileCtx.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	format, err := constant.AsBytes(args[1])
+	if err != nil {
+		return nil, err
+	}
+	var result []byte
+	if bytes.IndexByte(format, '%') >= 0 {
+		result, err = strftime(t, format)
+	} else {
+		result = pgFormatTime(t, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return constant.MakeBytes(result), nil
+}
+
+// strftime formats a timestamp using C strftime directives.
+func strftime(t time.Time, format []byte) ([]byte, error) {
+	var result []byte
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			result = append(result, format[i])
+			continue
+		}
+		i++
+		if i == len(format) {
+			return nil, fmt.Errorf("unterminated strftime directive: %s", format)
+		}
+		switch format[i] {
+		case 'a':
+			result = append(result, t.Weekday().String()[:3]...)
+		case 'A':
+			result = append(result, t.Weekday().String()...)
+		case 'b', 'h':
+			result = append(result, t.Month().String()[:3]...)
+		case 'B':
+			result = append(result, t.Month().String()...)
+		case 'd':
+			result = appendInt(result, t.Day(), 2)
+		case 'e':
+			result = append(result, fmt.Sprintf("%2d", t.Day())...)
+		case 'f':
+			result = appendInt(result, t.Nanosecond()/1e3, 6)
+		case 'F':
+			result = t.AppendFormat(result, "2006-01-02")
+		case 'H':
+			result = appendInt(result, t.Hour(), 2)
+		case 'I':
+			result = appendInt(result, hour12(t), 2)
+		case 'j':
+			result = appendInt(result, t.YearDay(), 3)
+		case 'm':
+			result = appendInt(result, int(t.Month()), 2)
+		case 'M':
+			result = appendInt(result, t.Minute(), 2)
+		case 'p':
+			result = t.AppendFormat(result, "PM")
+		case 's':
+			result = strconv.AppendInt(result, t.Unix(), 10)
+		case 'S':
+			result = appendInt(result, t.Second(), 2)
+		case 'T':
+			result = t.AppendFormat(result, "15:04:05")
+		case 'u':
+			result = strconv.AppendInt(result, int64(isoWeekday(t)), 10)
+		case 'V':
+			_, week := t.ISOWeek()
+			result = appendInt(result, week, 2)
+		case 'w':
+			result = strconv.AppendInt(result, int64(t.Weekday()), 10)
+		case 'y':
+			result = appendInt(result, t.Year()%100, 2)
+		case 'Y':
+			result = strconv.AppendInt(result, int64(t.Year()), 10)
+		case 'z':
+			result = t.AppendFormat(result, "-0700")
+		case 'Z':
+			result = t.AppendFormat(result, "MST")
+		case '%':
+			result = append(result, '%')
+		default:
+			return nil, fmt.Errorf("unknown strftime directive: %%%c", format[i])
+		}
+	}
+	return result, nil
+}
+
+// pgFormatPatterns are the PostgreSQL template patterns, longest first so
+// that e.g. `HH24` is not read as `HH`.
+var pgFormatPatterns = []string{
+	"HH24", "HH12", "IYYY", "YYYY", "MONTH", "Month", "month", "A.M.", "P.M.", "a.m.", "p.m.",
+	"DAY", "Day", "day", "DDD", "MON", "Mon", "mon",
+	"HH", "MI", "SS", "MS", "US", "AM", "PM", "am", "pm", "YY", "MM", "DY", "Dy", "dy",
+	"DD", "ID", "IW", "TZ", "tz", "OF", "D", "Q",
+}
+
+// pgFormatTime formats a timestamp using PostgreSQL template patterns.
+// The `FM` prefix suppresses padding. Text in double quotes is copied as is.
+func pgFormatTime(t time.Time, format []byte) []byte {
+	var result []byte
+	for len(format) > 0 {
+		if format[0] == '"' {
+			end := bytes.IndexByte(format[1:], '"')
+			if end == -1 {
+				result = append(result, format[1:]...)
+				break
+			}
+			result = append(result, format[1:end+1]...)
+			format = format[end+2:]
+			continue
+		}
+		fillMode := bytes.HasPrefix(format, []byte("FM"))
+		rest := format
+		if fillMode {
+			rest = format[2:]
+		}
+		pattern := ""
+		for _, p := range pgFormatPatterns {
+			if bytes.HasPrefix(rest, []byte(p)) {
+				pattern = p
+				break
+			}
+		}
+		if pattern == "" {
+			result = append(result, format[0])
+			format = format[1:]
+			continue
+		}
+		format = rest[len(pattern):]
+
+		pad := func(n, width int) {
+			if fillMode {
+				result = strconv.AppendInt(result, int64(n), 10)
+			} else {
+				result = appendInt(result, n, width)
+			}
+		}
+		name := func(s string) {
+			if fillMode {
+				result = append(result, s...)
+			} else {
+				result = append(result, fmt.Sprintf("%-9s", s)...)
+			}
+		}
+		switch pattern {
+		case "HH24":
+			pad(t.Hour(), 2)
+		case "HH12", "HH":
+			pad(hour12(t), 2)
+		case "MI":
+			pad(t.Minute(), 2)
+		case "SS":
+			pad(t.Second(), 2)
+		case "MS":
+			pad(t.Nanosecond()/1e6, 3)
+		case "US":
+			pad(t.Nanosecond()/1e3, 6)
+		case "AM", "PM":
+			result = t.AppendFormat(result, "PM")
+		case "am", "pm":
+			result = t.AppendFormat(result, "pm")
+		case "A.M.", "P.M.":
+			result = append(result, t.Format("PM")[0], '.', 'M', '.')
+		case "a.m.", "p.m.":
+			result = append(result, t.Format("pm")[0], '.', 'm', '.')
+		case "YYYY":
+			pad(t.Year(), 4)
+		case "IYYY":
+			isoYear, _ := t.ISOWeek()
+			pad(isoYear, 4)
+		case "YY":
+			pad(t.Year()%100, 2)
+		case "MONTH":
+			name(strings.ToUpper(t.Month().String()))
+		case "Month":
+			name(t.Month().String())
+		case "month":
+			name(strings.ToLower(t.Month().String()))
+		case "MON":
+			result = append(result, strings.ToUpper(t.Month().String()[:3])...)
+		case "Mon":
+			result = append(result, t.Month().String()[:3]...)
+		case "mon":
+			result = append(result, strings.ToLower(t.Month().String()[:3])...)
+		case "MM":
+			pad(int(t.Month()), 2)
+		case "DAY":
+			name(strings.ToUpper(t.Weekday().String()))
+		case "Day":
+			name(t.Weekday().String())
+		case "day":
+			name(strings.ToLower(t.Weekday().String()))
+		case "DY":
+			result = append(result, strings.ToUpper(t.Weekday().String()[:3])...)
+		case "Dy":
+			result = append(result, t.Weekday().String()[:3]...)
+		case "dy":
+			result = append(result, strings.ToLower(t.Weekday().String()[:3])...)
+		case "DDD":
+			pad(t.YearDay(), 3)
+		case "DD":
+			pad(t.Day(), 2)
+		case "D":
+			result = strconv.AppendInt(result, int64(t.Weekday())+1, 10)
+		case "ID":
+			result = strconv.AppendInt(result, int64(isoWeekday(t)), 10)
+		case "IW":
+			_, week := t.ISOWeek()
+			pad(week, 2)
+		case "Q":
+			result = strconv.AppendInt(result, int64(t.Month()-1)/3+1, 10)
+		case "TZ":
+			result = append(result, strings.ToUpper(t.Format("MST"))...)
+		case "tz":
+			result = append(result, strings.ToLower(t.Format("MST"))...)
+		case "OF":
+			result = t.AppendFormat(result, "-07:00")
+		}
+	}
+	return result
+}
+
+func hour12(t time.Time) int {
+	if h := t.Hour() % 12; h != 0 {
+		return h
+	}
+	return 12
+}
+
+// appendInt appends n zero-padded to the given width.
+func appendInt(dst []byte, n, width int) []byte {
+	if n < 0 {
+		dst = append(dst, '-')
+		n = -n
+	}
+	s := strconv.Itoa(n)
+	for i := len(s); i < width; i++ {
+		dst = append(dst, '0')
+	}
+	return append(dst, s...)
+}
+
+// timeWeights are the relative weights of the hours of the week, indexed by
+// 24*weekday+hour where weekday 0 is Sunday.
+type timeWeights [7 * 24]float64
+
+// timeProfiles are the predefined weighting profiles of 'rand.timestamp'
+// and 'rand.date'.
+var timeProfiles = map[string]*timeWeights{
+	// weekday covers Monday to Friday.
+	"weekday": makeTimeWeights(time.Monday, time.Friday, 0, 24),
+	// business_hours covers 09:00 to 17:00 from Monday to Friday.
+	"business_hours": makeTimeWeights(time.Monday, time.Friday, 9, 17),
+}
+
+// makeTimeWeights returns weights of 1 for the hours [fromHour, toHour) of
+// the days fromDay to toDay, and 0 for all other hours.
+func makeTimeWeights(fromDay, toDay time.Weekday, fromHour, toHour int) *timeWeights {
+	var w timeWeights
+	for day := fromDay; day <= toDay; day++ {
+		for hour := fromHour; hour < toHour; hour++ {
+			w[24*int(day)+hour] = 1
+		}
+	}
+	return &w
+}
+
+// parseTimeWeights parses the weighting profile argument, which is either
+// the name of a predefined profile or an array of 24 hourly weights that
+// applies to every day. It returns nil if the argument is absent, meaning
+// that all times are equally likely.
+func parseTimeWeights(v constant.Value) (*timeWeights, error) {
+	if v == nil || v == constant.Null {
+		return nil, nil
+	}
+	if v.Kind() == constant.KindBytes {
+		name, _ := constant.AsBytes(v)
+		w, ok := timeProfiles[strings.ToLower(string(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown time weighting profile: %s", name)
+		}
+		return w, nil
+	}
+	hourly, err := constant.AsArray(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(hourly) != 24 {
+		return nil, fmt.Errorf("time weighting profile requires 24 hourly weights, got %d", len(hourly))
+	}
+	var w timeWeights
+	positive := false
+	for hour, v := range hourly {
+		f, err := constant.AsFloat(v)
+		if err != nil {
+			return nil, err
+		}
+		if f < 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, fmt.Errorf("time weight must be a non-negative finite number, got %s", v)
+		}
+		positive = positive || f > 0
+		for day := 0; day < 7; day++ {
+			w[24*day+hour] = f
+		}
+	}
+	if !positive {
+		return nil, fmt.Errorf("time weighting profile requires a positive weight")
+	}
+	return &w, nil
+}
+
+func (w *timeWeights) at(t time.Time) float64 {
+	return w[24*int(t.Weekday())+t.Hour()]
+}
+
+func (w *timeWeights) max() float64 {
+	result := 0.0
+	for _, v := range w {
+		result = math.Max(result, v)
+	}
+	return result
+}
+
+// day returns the total weight of a day of the week.
+func (w *timeWeights) day(weekday int) float64 {
+	total := 0.0
+	for _, v := range w[24*weekday : 24*weekday+24] {
+		total += v
+	}
+	return total
+}
+
+// weightedSpan is a part of a random range that has a constant weight.
+type weightedSpan struct {
+	offset, length uint64
+	// cumWeight is the total weight of this and all previous spans.
+	cumWeight float64
+}
+
+// pickSpan returns a random offset in the spans, chosen in proportion to
+// their weights.
+func pickSpan(rng rand.Source64, spans []weightedSpan) uint64 {
+	u := randFloat64(rng) * spans[len(spans)-1].cumWeight
+	i := sort.Search(len(spans)-1, func(i int) bool { return spans[i].cumWeight > u })
+	return spans[i].offset + randUint64n(rng, spans[i].length)
+}
+
+// RandTimestampFunc implements the 'rand.timestamp' SQL function. It returns
+// a random timestamp in [start, end) with microsecond precision, optionally
+// weighted by a time profile evaluated in the time zone of start.
+type RandTimestampFunc struct {
+	varArgs
+}
+
+func (RandTimestampFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, fmt.Errorf("rand.timestamp requires 2 or 3 arguments, got %d", len(args))
+	}
+	if args[0] == constant.Null || args[1] == constant.Null {
+		return &Constant{constant.Null}, nil
+	}
+	start, err := asDateTime(args[0], ctx.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	end, err := asDateTime(args[1], ctx.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	start = start.Truncate(time.Microsecond)
+	span := end.UnixMicro() - start.UnixMicro()
+	if span <= 0 {
+		return nil, fmt.Errorf("rand.timestamp requires start < end, got %s and %s", args[0], args[1])
+	}
+	weights, err := parseTimeWeights(optionalArg(args, 2))
+	if err != nil {
+		return nil, err
+	}
+	r := &RandTimestamp{Start: start, Span: uint64(span), Weights: weights}
+	if weights == nil {
+		return r, nil
+	}
+	// A range longer than a week contains every hour of the week, so
+	// rejection sampling finds a time with a positive weight. Shorter ranges
+	// are split into hours and sampled exactly.
+	if r.Span > 8*24*uint64(time.Hour/time.Microsecond) {
+		r.MaxWeight = weights.max()
+		return r, nil
+	}
+	total := 0.0
+	for t := start; t.Before(end); {
+		year, month, day := t.Date()
+		next := time.Date(year, month, day, t.Hour()+1, 0, 0, 0, t.Location())
+		if !next.After(t) {
+			next = t.Add(time.Hour)
+		}
+		if next.After(end) {
+			next = end
+		}
+		length := uint64(next.UnixMicro() - t.UnixMicro())
+		if w := weights.at(t); w > 0 && length > 0 {
+			total += w * float64(length)
+			r.spans = append(r.spans, weightedSpan{
+				offset:    uint64(t.UnixMicro() - start.UnixMicro()),
+				length:    length,
+				cumWeight: total,
+			})
+		}
+		t = next
+	}
+	if len(r.spans) == 0 {
+		return nil, fmt.Errorf("rand.timestamp range has no time with a positive weight")
+	}
+	return r, nil
+}
+
+// RandDateFunc implements the 'rand.date' SQL function. It returns a random
+// date between the days of start and end inclusive, optionally weighted by
+// the total weight of each day in a time profile.
+type RandDateFunc struct {
+	varArgs
+}
+
+func (RandDateFunc) Compile(ctx *CompileContext, args Arguments) (Compiled, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, fmt.Errorf("rand.date requires 2 or 3 arguments, got %d", len(args))
+	}
+	if args[0] == constant.Null || args[1] == constant.Null {
+		return &Constant{constant.Null}, nil
+	}
+	start, err := asDateTime(args[0], ctx.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	end, err := asDateTime(args[1], ctx.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	start = truncateTime(start, DateFieldDay)
+	days := civilDay(end.In(start.Location())) - civilDay(start) + 1
+	if days <= 0 {
+		return nil, fmt.Errorf("rand.date requires start <= end, got %s and %s", args[0], args[1])
+	}
+	weights, err := parseTimeWeights(optionalArg(args, 2))
+	if err != nil {
+		return nil, err
+	}
+	r := &RandDate{Start: start, Days: uint64(days)}
+	if weights == nil {
+		return r, nil
+	}
+	for weekday := range r.Weights {
+		r.Weights[weekday] = weights.day(weekday)
+		r.MaxWeight = math.Max(r.MaxWeight, r.Weights[weekday])
+	}
+	// As in 'rand.timestamp', ranges of a week or longer use rejection
+	// sampling and shorter ones are sampled exactly.
+	if days >= 7 {
+		return r, nil
+	}
+	total := 0.0
+	for i := uint64(0); i < r.Days; i++ {
+		if w := r.weight(i); w > 0 {
+			total += w
+			r.spans = append(r.spans, weightedSpan{offset: i, length: 1, cumWeight: total})
+		}
+	}
+	if len(r.spans) == 0 {
+		return nil, fmt.Errorf("rand.date range has no day with a positive weight")
+	}
+	return r, nil
+}
+
+// civilDay returns the number of days from 1970-01-01 to the date of t.
+func civilDay(t time.Time) int64 {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / (24 * 60 * 60)
+}
+
+// Compiled expression types.
+type (
+	// RandTimestamp is a random timestamp in a range.
+	RandTimestamp struct {
+		Start time.Time
+		// Span is the length of the range in microseconds.
+		Span uint64
+		// Weights is the weighting profile, or nil if the distribution is
+		// uniform.
+		Weights   *timeWeights
+		MaxWeight float64
+		spans     []weightedSpan
+	}
+	// RandDate is a random date in a range.
+	RandDate struct {
+		Start time.Time
+		Days  uint64
+		// Weights are the weights of the days of the week, or all zero if
+		// the distribution is uniform.
+		Weights   [7]float64
+		MaxWeight float64
+		spans     []weightedSpan
+	}
+)
+
+func (r *RandTimestamp) Eval(state *State) (constant.Value, error) {
+	rng := state.Rng
+	if r.spans != nil {
+		return constant.MakeTimestamp(r.at(pickSpan(rng, r.spans))), nil
+	}
+	for {
+		t := r.at(randUint64n(rng, r.Span))
+		if r.Weights == nil || randFloat64(rng)*r.MaxWeight < r.Weights.at(t) {
+			return constant.MakeTimestamp(t), nil
+		}
+	}
+}
+
+func (r *RandTimestamp) at(offset uint64) time.Time {
+	return time.UnixMicro(r.Start.UnixMicro() + int64(offset)).In(r.Start.Location())
+}
+
+func (r *RandDate) Eval(state *State) (constant.Value, error) {
+	rng := state.Rng
+	if r.spans != nil {
+		return constant.MakeDate(r.Start.AddDate(0, 0, int(pickSpan(rng, r.spans)))), nil
+	}
+	for {
+		i := randUint64n(rng, r.Days)
+		if r.MaxWeight == 0 || randFloat64(rng)*r.MaxWeight < r.weight(i) {
+			return constant.MakeDate(r.Start.AddDate(0, 0, int(i))), nil
+		}
+	}
+}
+
+// weight returns the weight of the i-th day of the range.
+func (r *RandDate) weight(i uint64) float64 {
+	return r.Weights[(uint64(r.Start.Weekday())+i%7)%7]
+}
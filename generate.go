@@ -0,0 +1,323 @@
+package dbgen
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gozssky/dbgen/constant"
+)
+
+// ErrorPolicy decides what the Generator does when a column of a row fails
+// to evaluate. It implements flag.Value for the --on-error flag.
+type ErrorPolicy uint8
+
+const (
+	// OnErrorAbort stops the generation at the first error.
+	OnErrorAbort ErrorPolicy = iota
+	// OnErrorNull replaces the value of the failing column with NULL.
+	OnErrorNull
+	// OnErrorSkipRow drops the failing row and the rows derived from it.
+	OnErrorSkipRow
+)
+
+var errorPolicyNames = [...]string{
+	OnErrorAbort:   "abort",
+	OnErrorNull:    "null",
+	OnErrorSkipRow: "skip-row",
+}
+
+func (p ErrorPolicy) String() string {
+	if int(p) >= len(errorPolicyNames) {
+		return fmt.Sprintf("ErrorPolicy(%d)", p)
+	}
+	return errorPolicyNames[p]
+}
+
+// Set parses the name of a policy.
+func (p *ErrorPolicy) Set(s string) error {
+	for policy, name := range errorPolicyNames {
+		if strings.EqualFold(s, name) {
+			*p = ErrorPolicy(policy)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown error policy %q, expected abort, null or skip-row", s)
+}
+
+// maxReportedErrors is the number of errors kept in full by ErrorReport.
+const maxReportedErrors = 5
+
+// ErrorReport summarizes the errors tolerated by the OnErrorNull and
+// OnErrorSkipRow policies.
+type ErrorReport struct {
+	// Total is the number of errors.
+	Total int64
+	// Columns counts the errors of each failing column, in the order of
+	// their first error.
+	Columns []ColumnErrors
+	// First are the first few errors.
+	First []*RowError
+
+	index map[columnRef]int
+}
+
+// ColumnErrors is the number of errors of a column.
+type ColumnErrors struct {
+	Table  *Table
+	Column int
+	Count  int64
+}
+
+type columnRef struct {
+	table  *Table
+	column int
+}
+
+func (r *ErrorReport) add(table *Table, column int, err *RowError) {
+	if r.index == nil {
+		r.index = make(map[columnRef]int)
+	}
+	ref := columnRef{table, column}
+	i, ok := r.index[ref]
+	if !ok {
+		i = len(r.Columns)
+		r.index[ref] = i
+		r.Columns = append(r.Columns, ColumnErrors{Table: table, Column: column})
+	}
+	r.Columns[i].Count++
+	r.Total++
+	if len(r.First) < maxReportedErrors {
+		r.First = append(r.First, err)
+	}
+}
+
+func (r *ErrorReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d errors\n", r.Total)
+	for _, c := range r.Columns {
+		fmt.Fprintf(&sb, "  %s.%s: %d\n", c.Table.Name, c.Table.Columns[c.Column], c.Count)
+	}
+	if len(r.First) > 0 {
+		fmt.Fprintf(&sb, "first %d errors:\n", len(r.First))
+		for _, err := range r.First {
+			fmt.Fprintf(&sb, "  %v\n", err)
+		}
+	}
+	return sb.String()
+}
+
+// Generator generates the rows of a compiled template.
+type Generator struct {
+	Template *Template
+	State    *State
+	// OnError is what to do when a column fails to evaluate.
+	OnError ErrorPolicy
+	// Emit is called with each generated row. The values are not reused.
+	Emit func(table *Table, values []constant.Value) error
+	// EmitDatums, if not nil, is called with each generated row instead of
+	// Emit. It avoids boxing the numbers and strings of the columns, but the
+	// slice is reused for the next row of the table, so the datums must be
+	// written out or copied before it returns.
+	EmitDatums func(table *Table, datums []constant.Datum) error
+	// Errors are the errors tolerated so far.
+	Errors ErrorReport
+	// Stats, if not nil, collects statistics on the generated rows.
+	Stats *Stats
+	roots []*Table
+	// batched reports whether all the columns of each root are pure, so
+	// that they are evaluated a batch of rows at a time.
+	batched []bool
+	// datums are the rows of each table reused by EmitDatums.
+	datums map[*Table][]constant.Datum
+}
+
+// generateBatchSize is the number of rows of the tables with only pure
+// columns evaluated at once.
+const generateBatchSize = 1024
+
+// Init evaluates the global expressions. It must be called before
+// Generate.
+func (g *Generator) Init() error {
+	if _, err := g.Template.GlobalExprs.Eval(g.State); err != nil {
+		return err
+	}
+	derived := make(map[int]bool)
+	for _, table := range g.Template.Tables {
+		for _, d := range table.Derived {
+			derived[d.A] = true
+		}
+	}
+	g.roots, g.batched = g.roots[:0], g.batched[:0]
+	g.datums = make(map[*Table][]constant.Datum)
+	for _, table := range g.Template.Tables {
+		g.datums[table] = make([]constant.Datum, len(table.Row))
+	}
+	for i, table := range g.Template.Tables {
+		if !derived[i] {
+			g.roots = append(g.roots, table)
+			g.batched = append(g.batched, allPure(table.Row))
+		}
+	}
+	return nil
+}
+
+// Generate generates n rows of every table that is not derived from
+// another one, numbered from first, along with their derived rows.
+//
+// The rows of the tables whose columns are all pure are evaluated in
+// batches with Row.EvalBatch. A batch which fails is evaluated again row by
+// row, so that the failing columns are reported and handled by OnError.
+func (g *Generator) Generate(first, n int64) error {
+	batches := make([][]*Vector, len(g.roots))
+	for start := first; start < first+n; start += generateBatchSize {
+		size := first + n - start
+		if size > generateBatchSize {
+			size = generateBatchSize
+		}
+		for j, table := range g.roots {
+			batches[j] = nil
+			if g.batched[j] {
+				g.State.RowNum, g.State.SubRowNum = start, 1
+				if columns, err := table.Row.EvalBatch(g.State, int(size)); err == nil {
+					batches[j] = columns
+				}
+			}
+		}
+		for k := int64(0); k < size; k++ {
+			for j, table := range g.roots {
+				g.State.RowNum, g.State.SubRowNum = start+k, 1
+				var err error
+				if batches[j] != nil && g.EmitDatums != nil {
+					datums := g.datums[table]
+					for i, column := range batches[j] {
+						datums[i] = column.Datum(int(k))
+					}
+					err = g.emitDatums(table, datums)
+				} else if batches[j] != nil {
+					values := make([]constant.Value, len(batches[j]))
+					for i, column := range batches[j] {
+						values[i] = column.Value(int(k))
+					}
+					err = g.emitRow(table, values)
+				} else {
+					err = g.generateRow(table)
+				}
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// generateRow generates a row of a table at the current row number, followed
+// by the rows derived from it.
+func (g *Generator) generateRow(table *Table) error {
+	if g.EmitDatums != nil {
+		return g.generateDatums(table)
+	}
+	values := make([]constant.Value, len(table.Row))
+	for i := range table.Row {
+		value, err := table.evalColumn(g.State, i)
+		if err != nil {
+			if skip, err := g.tolerate(table, i, err); err != nil || skip {
+				return err
+			}
+			value = constant.Null
+		}
+		values[i] = value
+	}
+	return g.emitRow(table, values)
+}
+
+// generateDatums is generateRow for EmitDatums.
+func (g *Generator) generateDatums(table *Table) error {
+	datums := g.datums[table]
+	for i := range table.Row {
+		datum, err := table.evalColumnDatum(g.State, i)
+		if err != nil {
+			if skip, err := g.tolerate(table, i, err); err != nil || skip {
+				return err
+			}
+			datum = constant.NullDatum
+		}
+		datums[i] = datum
+	}
+	return g.emitDatums(table, datums)
+}
+
+// tolerate handles the error of the i-th column of a row according to
+// OnError. It returns the error if the generation must abort, and whether
+// the row must be skipped otherwise.
+func (g *Generator) tolerate(table *Table, i int, err error) (bool, error) {
+	var rowErr *RowError
+	if g.OnError == OnErrorAbort || !errors.As(err, &rowErr) {
+		return false, err
+	}
+	g.Errors.add(table, i, rowErr)
+	return g.OnError == OnErrorSkipRow, nil
+}
+
+// emitRow emits the evaluated row of a table, followed by the rows derived
+// from it.
+func (g *Generator) emitRow(table *Table, values []constant.Value) error {
+	if err := g.Emit(table, values); err != nil {
+		return err
+	}
+	if g.Stats != nil {
+		g.Stats.Add(table, values)
+	}
+	return g.generateDerived(table)
+}
+
+// emitDatums is emitRow for EmitDatums. The statistics are collected on
+// boxed values, so they give up most of the gain of the datums.
+func (g *Generator) emitDatums(table *Table, datums []constant.Datum) error {
+	if err := g.EmitDatums(table, datums); err != nil {
+		return err
+	}
+	if g.Stats != nil {
+		values := make([]constant.Value, len(datums))
+		for i, datum := range datums {
+			values[i] = datum.Value()
+		}
+		g.Stats.Add(table, values)
+	}
+	return g.generateDerived(table)
+}
+
+// generateDerived generates the rows derived from the current row of a
+// table.
+func (g *Generator) generateDerived(table *Table) error {
+	rowNum, subRowNum := g.State.RowNum, g.State.SubRowNum
+	for _, d := range table.Derived {
+		index, compiled := d.Unpack()
+		count, err := g.derivedCount(compiled)
+		if err != nil {
+			return err
+		}
+		for i := int64(1); i <= count; i++ {
+			g.State.RowNum, g.State.SubRowNum = rowNum, i
+			if err := g.generateRow(g.Template.Tables[index]); err != nil {
+				return err
+			}
+		}
+	}
+	g.State.RowNum, g.State.SubRowNum = rowNum, subRowNum
+	return nil
+}
+
+// derivedCount evaluates the number of rows of a derived table. Since it is
+// not a column, its errors always abort the generation.
+func (g *Generator) derivedCount(compiled Compiled) (int64, error) {
+	value, err := compiled.Eval(g.State)
+	if err != nil {
+		return 0, err
+	}
+	if value == constant.Null {
+		return 0, nil
+	}
+	return constant.AsInt64(value)
+}
@@ -0,0 +1,157 @@
+package dbgen_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gozssky/dbgen"
+	"github.com/gozssky/dbgen/constant"
+	"github.com/gozssky/dbgen/template"
+	"github.com/stretchr/testify/require"
+)
+
+const generateTemplate = `
+CREATE TABLE p (
+    id INT {{ rownum }},
+    v  INT {{ 10 / (rownum - 2) }}
+);
+/*{{ for each row of p generate 2 rows of c }}*/
+CREATE TABLE c (
+    id  INT {{ rownum }},
+    sub INT {{ subrownum }}
+);`
+
+// generateRows generates 3 rows of generateTemplate, with Emit or with
+// EmitDatums.
+func generateRows(t *testing.T, policy dbgen.ErrorPolicy, datums bool) ([]string, *dbgen.Generator, error) {
+	tmpl, err := template.Parse(generateTemplate)
+	require.NoError(t, err)
+	ctx := dbgen.NewCompileContext()
+	compiled, err := ctx.CompileTemplate(tmpl)
+	require.NoError(t, err)
+	var rows []string
+	g := &dbgen.Generator{
+		Template: compiled,
+		State:    &dbgen.State{CompileCtx: ctx},
+		OnError:  policy,
+		Emit: func(table *dbgen.Table, values []constant.Value) error {
+			var sb strings.Builder
+			sb.WriteString(table.Name.String())
+			for _, value := range values {
+				sb.WriteString(" ")
+				sb.WriteString(value.String())
+			}
+			rows = append(rows, sb.String())
+			return nil
+		},
+	}
+	if datums {
+		g.EmitDatums = func(table *dbgen.Table, datums []constant.Datum) error {
+			var sb strings.Builder
+			sb.WriteString(table.Name.String())
+			for _, datum := range datums {
+				sb.WriteString(" ")
+				sb.WriteString(datum.String())
+			}
+			rows = append(rows, sb.String())
+			return nil
+		}
+	}
+	require.NoError(t, g.Init())
+	err = g.Generate(1, 3)
+	return rows, g, err
+}
+
+// forEachEmit runs a test with Emit and with EmitDatums.
+func forEachEmit(t *testing.T, test func(t *testing.T, datums bool)) {
+	t.Run("Emit", func(t *testing.T) { test(t, false) })
+	t.Run("EmitDatums", func(t *testing.T) { test(t, true) })
+}
+
+func TestGenerateOnErrorAbort(t *testing.T) {
+	forEachEmit(t, func(t *testing.T, datums bool) {
+		rows, _, err := generateRows(t, dbgen.OnErrorAbort, datums)
+		var rowErr *dbgen.RowError
+		require.True(t, errors.As(err, &rowErr))
+		require.Equal(t, int64(2), rowErr.RowNum)
+		require.Equal(t, []string{"p 1 -10", "c 1 1", "c 1 2"}, rows)
+	})
+}
+
+func TestGenerateOnErrorNull(t *testing.T) {
+	forEachEmit(t, func(t *testing.T, datums bool) {
+		rows, g, err := generateRows(t, dbgen.OnErrorNull, datums)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"p 1 -10", "c 1 1", "c 1 2",
+			"p 2 NULL", "c 2 1", "c 2 2",
+			"p 3 10", "c 3 1", "c 3 2",
+		}, rows)
+		require.Equal(t, int64(1), g.Errors.Total)
+		require.Len(t, g.Errors.Columns, 1)
+		require.Equal(t, int64(1), g.Errors.Columns[0].Count)
+		require.Len(t, g.Errors.First, 1)
+		require.Contains(t, g.Errors.String(), "1 errors\n  p.v: 1\nfirst 1 errors:\n  error in column v of table p at row 2")
+	})
+}
+
+func TestGenerateOnErrorSkipRow(t *testing.T) {
+	forEachEmit(t, func(t *testing.T, datums bool) {
+		rows, g, err := generateRows(t, dbgen.OnErrorSkipRow, datums)
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			"p 1 -10", "c 1 1", "c 1 2",
+			"p 3 10", "c 3 1", "c 3 2",
+		}, rows)
+		require.Equal(t, int64(1), g.Errors.Total)
+	})
+}
+
+func TestGenerateBatches(t *testing.T) {
+	tmpl, err := template.Parse(`CREATE TABLE p ({{ rownum }}, {{ rownum * 0.5 }}, {{ 'p' || rownum }});`)
+	require.NoError(t, err)
+	ctx := dbgen.NewCompileContext()
+	compiled, err := ctx.CompileTemplate(tmpl)
+	require.NoError(t, err)
+	forEachEmit(t, func(t *testing.T, datums bool) {
+		var rows [][]constant.Value
+		g := &dbgen.Generator{
+			Template: compiled,
+			State:    &dbgen.State{CompileCtx: ctx},
+			Emit: func(_ *dbgen.Table, values []constant.Value) error {
+				rows = append(rows, values)
+				return nil
+			},
+		}
+		if datums {
+			g.EmitDatums = func(_ *dbgen.Table, datums []constant.Datum) error {
+				values := make([]constant.Value, len(datums))
+				for i, datum := range datums {
+					values[i] = datum.Value()
+				}
+				rows = append(rows, values)
+				return nil
+			}
+		}
+		require.NoError(t, g.Init())
+		// The rows span several batches, the last of which is partial.
+		require.NoError(t, g.Generate(5, 2500))
+		require.Len(t, rows, 2500)
+		for i, row := range rows {
+			rowNum := int64(i + 5)
+			expected, err := compiled.Tables[0].Row.Eval(&dbgen.State{CompileCtx: ctx, RowNum: rowNum, SubRowNum: 1})
+			require.NoError(t, err)
+			require.Equal(t, expected, row, rowNum)
+		}
+	})
+}
+
+func TestErrorPolicySet(t *testing.T) {
+	var policy dbgen.ErrorPolicy
+	require.NoError(t, policy.Set("skip-row"))
+	require.Equal(t, dbgen.OnErrorSkipRow, policy)
+	require.Equal(t, "skip-row", policy.String())
+	require.Error(t, policy.Set("ignore"))
+	require.Equal(t, "ErrorPolicy(7)", dbgen.ErrorPolicy(7).String())
+}
@@ -0,0 +1,129 @@
+package dbgen
+
+import (
+	"fmt"
+
+	"github.com/gozssky/dbgen/template"
+)
+
+// LintIssue is a suspicious use of a variable found by Lint.
+type LintIssue struct {
+	// Expr is the expression reading or assigning the variable.
+	Expr template.Expr
+	Msg  string
+}
+
+func (i *LintIssue) String() string {
+	pos := i.Expr.Position()
+	return fmt.Sprintf("line %d at column %d: %s", pos.Line, pos.Column, i.Msg)
+}
+
+// Lint reports the variables of a template which are read before any
+// assignment, which are assigned but never read, or which are assigned in
+// several tables, so that one table overwrites the value of another.
+//
+// The expressions are visited in the order they are evaluated during
+// generation: the global expressions, then each table followed by the tables
+// derived from it.
+func Lint(t *template.Template) []*LintIssue {
+	l := &linter{
+		tmpl:     t,
+		assigned: make(map[string]*assignment),
+		read:     make(map[string]bool),
+	}
+	l.owner = "the global expressions"
+	for _, expr := range t.GlobalExprs {
+		l.inspect(expr)
+	}
+	derived := make(map[int]bool)
+	for _, table := range t.Tables {
+		for _, d := range table.Derived {
+			derived[d.A] = true
+		}
+	}
+	for i := range t.Tables {
+		if !derived[i] {
+			l.table(i)
+		}
+	}
+	for _, name := range l.names {
+		if !l.read[name] {
+			l.report(l.assigned[name].first, "variable @%s is assigned but never read", name)
+		}
+	}
+	return l.issues
+}
+
+type linter struct {
+	tmpl *template.Template
+	// owner describes the table whose expressions are inspected.
+	owner string
+	// assigned are the variables assigned so far, whose names are in the
+	// order of their first assignment.
+	assigned map[string]*assignment
+	names    []string
+	// read are the variables read anywhere.
+	read   map[string]bool
+	issues []*LintIssue
+}
+
+// assignment records where a variable is assigned.
+type assignment struct {
+	first  *template.SetVariable
+	owners []string
+}
+
+func (l *linter) report(expr template.Expr, format string, args ...interface{}) {
+	l.issues = append(l.issues, &LintIssue{Expr: expr, Msg: fmt.Sprintf(format, args...)})
+}
+
+// table inspects the i-th table and the tables derived from it.
+func (l *linter) table(i int) {
+	table := l.tmpl.Tables[i]
+	owner := fmt.Sprintf("table %s", table.Name)
+	l.owner = owner
+	for _, col := range table.Columns {
+		l.inspect(col.Expr)
+	}
+	for _, d := range table.Derived {
+		l.owner = owner
+		l.inspect(d.B)
+		l.table(d.A)
+	}
+}
+
+func (l *linter) inspect(expr template.Expr) {
+	template.Inspect(expr, func(expr template.Expr) bool {
+		switch expr := expr.(type) {
+		case *template.GetVariable:
+			if _, ok := l.assigned[expr.Name]; !ok && !l.read[expr.Name] {
+				l.report(expr, "variable @%s is read before it is assigned", expr.Name)
+			}
+			l.read[expr.Name] = true
+		case *template.SetVariable:
+			// The value is evaluated before the assignment.
+			l.inspect(expr.Value)
+			l.assign(expr)
+			return false
+		}
+		return true
+	})
+}
+
+func (l *linter) assign(expr *template.SetVariable) {
+	a, ok := l.assigned[expr.Name]
+	if !ok {
+		a = &assignment{first: expr}
+		l.assigned[expr.Name] = a
+		l.names = append(l.names, expr.Name)
+	}
+	for _, owner := range a.owners {
+		if owner == l.owner {
+			return
+		}
+	}
+	if len(a.owners) > 0 {
+		l.report(expr, "variable @%s assigned in %s is also assigned in %s", expr.Name, l.owner, a.owners[0])
+	}
+	a.owners = append(a.owners, l.owner)
+}
@@ -0,0 +1,81 @@
+package dbgen_test
+
+import (
+	"testing"
+
+	"github.com/gozssky/dbgen"
+	"github.com/gozssky/dbgen/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	testCases := []struct {
+		name   string
+		input  string
+		issues []string
+	}{
+		{
+			"clean",
+			`{{ @n := 3 }}
+CREATE TABLE p (id INT {{ @id := rownum }}, n INT {{ @n }});
+/*{{ for each row of p generate 2 rows of c }}*/
+CREATE TABLE c (pid INT {{ @id }});`,
+			nil,
+		},
+		{
+			"typo",
+			`CREATE TABLE p (id INT {{ @parent_id := rownum }});
+/*{{ for each row of p generate 2 rows of c }}*/
+CREATE TABLE c (pid INT {{ @parnet_id }});`,
+			[]string{
+				"line 3 at column 28: variable @parnet_id is read before it is assigned",
+				"line 1 at column 27: variable @parent_id is assigned but never read",
+			},
+		},
+		{
+			"later-column",
+			`CREATE TABLE t (a INT {{ @b + 1 }}, b INT {{ @b := rownum }});`,
+			[]string{
+				"line 1 at column 26: variable @b is read before it is assigned",
+			},
+		},
+		{
+			"value-before-assignment",
+			`CREATE TABLE t (a INT {{ @a := coalesce(@a, 0) + 1 }});`,
+			[]string{
+				"line 1 at column 41: variable @a is read before it is assigned",
+			},
+		},
+		{
+			"derived-count",
+			`CREATE TABLE p (id INT {{ rownum }});
+/*{{ for each row of p generate @n rows of c }}*/
+CREATE TABLE c (n INT {{ @n := 2 }});`,
+			[]string{
+				"line 2 at column 33: variable @n is read before it is assigned",
+			},
+		},
+		{
+			"shadowing",
+			`{{ @x := 0 }}
+CREATE TABLE a (x INT {{ @x := rownum }}, y INT {{ @x }});
+/*{{ for each row of a generate 1 row of b }}*/
+CREATE TABLE b (x INT {{ @x := rownum * 2 }}, y INT {{ @x }});`,
+			[]string{
+				"line 2 at column 26: variable @x assigned in table a is also assigned in the global expressions",
+				"line 4 at column 26: variable @x assigned in table b is also assigned in the global expressions",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := template.Parse(tc.input)
+			require.NoError(t, err)
+			var issues []string
+			for _, issue := range dbgen.Lint(tmpl) {
+				issues = append(issues, issue.String())
+			}
+			require.Equal(t, tc.issues, issues)
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package dbgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"text/tabwriter"
+
+	"github.com/gozssky/dbgen/constant"
+	"github.com/gozssky/dbgen/template"
+)
+
+// Stats collects statistics on the values generated for every column, to
+// check their distributions without loading them anywhere.
+type Stats struct {
+	// Tables are the statistics of each table, in the order of their first
+	// row.
+	Tables []*TableStats
+	index  map[*Table]*TableStats
+}
+
+// TableStats are the statistics of the columns of a table.
+type TableStats struct {
+	Table   *Table
+	Columns []*ColumnStats
+}
+
+// ColumnStats are the statistics of the values of a column.
+type ColumnStats struct {
+	Name template.Name
+	// Count is the number of values, including NULLs.
+	Count int64
+	Nulls int64
+	// Min and Max are the extremes of the values, as ordered by
+	// constant.Cmp. Values which cannot be compared with them are ignored.
+	Min, Max constant.Value
+	// Numbers is the number of numeric values, whose Mean and standard
+	// deviation are tracked.
+	Numbers int64
+	Mean    float64
+	m2      float64
+	// Strings is the number of string values, whose total and maximum
+	// lengths in bytes are tracked.
+	Strings     int64
+	TotalLength int64
+	MaxLength   int
+	distinct    hyperLogLog
+}
+
+// Add adds a row of a table to the statistics.
+func (s *Stats) Add(table *Table, values []constant.Value) {
+	t, ok := s.index[table]
+	if !ok {
+		if s.index == nil {
+			s.index = make(map[*Table]*TableStats)
+		}
+		t = &TableStats{Table: table, Columns: make([]*ColumnStats, len(table.Columns))}
+		for i, name := range table.Columns {
+			t.Columns[i] = &ColumnStats{Name: name}
+		}
+		s.index[table] = t
+		s.Tables = append(s.Tables, t)
+	}
+	for i, value := range values {
+		t.Columns[i].add(value)
+	}
+}
+
+func (c *ColumnStats) add(value constant.Value) {
+	c.Count++
+	if value == constant.Null {
+		c.Nulls++
+		return
+	}
+	if c.Min == nil {
+		c.Min, c.Max = value, value
+	} else {
+		if cmp, _, err := constant.Cmp(value, c.Min); err == nil && cmp < 0 {
+			c.Min = value
+		}
+		if cmp, _, err := constant.Cmp(value, c.Max); err == nil && cmp > 0 {
+			c.Max = value
+		}
+	}
+	switch value.Kind() {
+	case constant.KindInt, constant.KindFloat, constant.KindDecimal:
+		if x, err := constant.AsFloat(value); err == nil {
+			// Welford's online algorithm.
+			c.Numbers++
+			delta := x - c.Mean
+			c.Mean += delta / float64(c.Numbers)
+			c.m2 += delta * (x - c.Mean)
+		}
+	case constant.KindBytes:
+		b, _ := constant.AsBytes(value)
+		c.Strings++
+		c.TotalLength += int64(len(b))
+		if len(b) > c.MaxLength {
+			c.MaxLength = len(b)
+		}
+	}
+	c.distinct.add(value)
+}
+
+// StdDev returns the population standard deviation of the numeric values.
+func (c *ColumnStats) StdDev() float64 {
+	if c.Numbers == 0 {
+		return 0
+	}
+	return math.Sqrt(c.m2 / float64(c.Numbers))
+}
+
+// AvgLength returns the average length in bytes of the string values.
+func (c *ColumnStats) AvgLength() float64 {
+	if c.Strings == 0 {
+		return 0
+	}
+	return float64(c.TotalLength) / float64(c.Strings)
+}
+
+// Distinct returns an estimate of the number of distinct non-NULL values.
+func (c *ColumnStats) Distinct() int64 {
+	return c.distinct.estimate()
+}
+
+// WriteTable writes the statistics as an aligned text table.
+func (s *Stats) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "TABLE\tCOLUMN\tCOUNT\tNULLS\tDISTINCT\tMIN\tMAX\tMEAN\tSTDDEV\tAVG LEN\tMAX LEN")
+	for _, t := range s.Tables {
+		for _, c := range t.Columns {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%s\t%s\t", t.Table.Name, c.Name, c.Count, c.Nulls, c.Distinct(), orNull(c.Min), orNull(c.Max))
+			if c.Numbers > 0 {
+				fmt.Fprintf(tw, "%.6g\t%.6g\t", c.Mean, c.StdDev())
+			} else {
+				fmt.Fprint(tw, "\t\t")
+			}
+			if c.Strings > 0 {
+				fmt.Fprintf(tw, "%.6g\t%d\n", c.AvgLength(), c.MaxLength)
+			} else {
+				fmt.Fprint(tw, "\t\n")
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+func orNull(v constant.Value) string {
+	if v == nil {
+		return constant.Null.String()
+	}
+	return v.String()
+}
+
+type jsonTableStats struct {
+	Name    string             `json:"name"`
+	Columns []*jsonColumnStats `json:"columns"`
+}
+
+type jsonColumnStats struct {
+	Name      string   `json:"name"`
+	Count     int64    `json:"count"`
+	Nulls     int64    `json:"nulls"`
+	Distinct  int64    `json:"distinct"`
+	Min       *string  `json:"min,omitempty"`
+	Max       *string  `json:"max,omitempty"`
+	Mean      *float64 `json:"mean,omitempty"`
+	StdDev    *float64 `json:"stddev,omitempty"`
+	AvgLength *float64 `json:"avg_length,omitempty"`
+	MaxLength *int     `json:"max_length,omitempty"`
+}
+
+// WriteJSON writes the statistics as a JSON array of tables.
+func (s *Stats) WriteJSON(w io.Writer) error {
+	tables := make([]*jsonTableStats, 0, len(s.Tables))
+	for _, t := range s.Tables {
+		table := &jsonTableStats{Name: t.Table.Name.String()}
+		for _, c := range t.Columns {
+			column := &jsonColumnStats{
+				Name:     c.Name.String(),
+				Count:    c.Count,
+				Nulls:    c.Nulls,
+				Distinct: c.Distinct(),
+			}
+			if c.Min != nil {
+				min, max := c.Min.String(), c.Max.String()
+				column.Min, column.Max = &min, &max
+			}
+			if c.Numbers > 0 {
+				mean, stddev := c.Mean, c.StdDev()
+				column.Mean, column.StdDev = &mean, &stddev
+			}
+			if c.Strings > 0 {
+				avg, max := c.AvgLength(), c.MaxLength
+				column.AvgLength, column.MaxLength = &avg, &max
+			}
+			table.Columns = append(table.Columns, column)
+		}
+		tables = append(tables, table)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(tables)
+}
+
+// hllPrecision is the number of bits of the hash selecting a register of a
+// hyperLogLog, whose standard error is about 1.04/sqrt(2^hllPrecision).
+const hllPrecision = 14
+
+// hyperLogLog estimates the number of distinct values added to it.
+type hyperLogLog struct {
+	registers []uint8
+}
+
+func (h *hyperLogLog) add(value constant.Value) {
+	if h.registers == nil {
+		h.registers = make([]uint8, 1<<hllPrecision)
+	}
+	// Integers and floats are hashed by their bits and strings by their bytes,
+	// without formatting them. The kind distinguishes values with the same
+	// bits or text, such as 1 and '1'.
+	var x uint64
+	switch kind := value.Kind(); {
+	case kind == constant.KindInt && constant.IsInt64(value):
+		i, _ := constant.AsInt64(value)
+		x = uint64(i)
+	case kind == constant.KindFloat:
+		f, _ := constant.AsFloat(value)
+		x = math.Float64bits(f)
+	case kind == constant.KindBytes:
+		b, _ := constant.AsBytes(value)
+		x = fnv64a(b)
+	default:
+		x = fnv64a(value.String())
+	}
+	x = mix64(x ^ uint64(value.Kind())*0x9e3779b97f4a7c15)
+	index := x >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// fnv64a returns the 64-bit FNV-1a hash of s.
+func fnv64a[T []byte | string](s T) uint64 {
+	x := uint64(14695981039346656037)
+	for i := 0; i < len(s); i++ {
+		x ^= uint64(s[i])
+		x *= 1099511628211
+	}
+	return x
+}
+
+func (h *hyperLogLog) estimate() int64 {
+	if h.registers == nil {
+		return 0
+	}
+	m := float64(len(h.registers))
+	var sum float64
+	var zeros int
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Linear counting is more accurate for small cardinalities.
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(estimate))
+}
+
+// mix64 is the finalizer of MurmurHash3, which spreads the bits of the
+// hashes.
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
@@ -0,0 +1,93 @@
+package dbgen_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/gozssky/dbgen"
+	"github.com/gozssky/dbgen/constant"
+	"github.com/gozssky/dbgen/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	tmpl, err := template.Parse(`
+CREATE TABLE t (
+    id   INT  {{ rownum }},
+    odd  INT  {{ CASE WHEN mod(rownum, 2) = 1 THEN rownum END }},
+    name TEXT {{ 'n' || rownum }}
+);`)
+	require.NoError(t, err)
+	ctx := dbgen.NewCompileContext()
+	compiled, err := ctx.CompileTemplate(tmpl)
+	require.NoError(t, err)
+	stats := &dbgen.Stats{}
+	g := &dbgen.Generator{
+		Template: compiled,
+		State:    &dbgen.State{CompileCtx: ctx},
+		Emit:     func(*dbgen.Table, []constant.Value) error { return nil },
+		Stats:    stats,
+	}
+	require.NoError(t, g.Init())
+	require.NoError(t, g.Generate(1, 1000))
+
+	require.Len(t, stats.Tables, 1)
+	columns := stats.Tables[0].Columns
+	id, odd, name := columns[0], columns[1], columns[2]
+	require.Equal(t, int64(1000), id.Count)
+	require.Equal(t, int64(0), id.Nulls)
+	require.Equal(t, "1", id.Min.String())
+	require.Equal(t, "1000", id.Max.String())
+	require.Equal(t, 500.5, id.Mean)
+	require.InDelta(t, math.Sqrt((1000*1000-1)/12.0), id.StdDev(), 1e-9)
+	require.InEpsilon(t, 1000, id.Distinct(), 0.03)
+
+	require.Equal(t, int64(1000), odd.Count)
+	require.Equal(t, int64(500), odd.Nulls)
+	require.Equal(t, "999", odd.Max.String())
+	require.Equal(t, 500.0, odd.Mean)
+	require.InEpsilon(t, 500, odd.Distinct(), 0.03)
+
+	require.Equal(t, "n1", name.Min.String())
+	require.Equal(t, "n999", name.Max.String())
+	require.Equal(t, int64(0), name.Numbers)
+	require.Equal(t, (9*2+90*3+900*4+5)/1000.0, name.AvgLength())
+	require.Equal(t, 5, name.MaxLength)
+
+	var table bytes.Buffer
+	require.NoError(t, stats.WriteTable(&table))
+	require.Contains(t, table.String(), "TABLE  COLUMN  COUNT  NULLS  DISTINCT")
+
+	var buf bytes.Buffer
+	require.NoError(t, stats.WriteJSON(&buf))
+	var decoded []struct {
+		Name    string
+		Columns []map[string]interface{}
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, "t", decoded[0].Name)
+	require.Equal(t, "name", decoded[0].Columns[2]["name"])
+	require.Equal(t, 5.0, decoded[0].Columns[2]["max_length"])
+	require.NotContains(t, decoded[0].Columns[2], "mean")
+	require.Equal(t, 500.5, decoded[0].Columns[0]["mean"])
+}
+
+func TestStatsDistinct(t *testing.T) {
+	table := &dbgen.Table{Name: template.NewQName("t"), Columns: []template.Name{template.NewName("v")}}
+	stats := &dbgen.Stats{}
+	for i := 0; i < 200000; i++ {
+		stats.Add(table, []constant.Value{constant.MakeInt64(int64(i % 50000))})
+	}
+	require.InEpsilon(t, 50000, stats.Tables[0].Columns[0].Distinct(), 0.05)
+}
+
+func TestStatsAddAllocs(t *testing.T) {
+	table := &dbgen.Table{Name: template.NewQName("t"), Columns: []template.Name{template.NewName("i"), template.NewName("f"), template.NewName("s")}}
+	stats := &dbgen.Stats{}
+	values := []constant.Value{constant.MakeInt64(1), constant.MakeFloat(0.5), constant.MakeBytes([]byte("x"))}
+	stats.Add(table, values)
+	// Numbers and strings are hashed without formatting them.
+	require.Zero(t, testing.AllocsPerRun(100, func() { stats.Add(table, values) }))
+}
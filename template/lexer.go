@@ -102,6 +102,8 @@ const (
 
 	// operators
 	tokenAssign    // :=
+	tokenTypeCast  // ::
+	tokenArrow     // ->
 	tokenLT        // <
 	tokenLE        // <=
 	tokenEQ        // =
@@ -137,7 +139,11 @@ const (
 	tokenElse             // ELSE
 	tokenEnd              // END
 	tokenTimestamp        // TIMESTAMP
+	tokenDate             // DATE
 	tokenInterval         // INTERVAL
+	tokenYear             // YEAR
+	tokenQuarter          // QUARTER
+	tokenMonth            // MONTH
 	tokenWeek             // WEEK
 	tokenDay              // DAY
 	tokenHour             // HOUR
@@ -164,6 +170,11 @@ const (
 	tokenGenerate         // GENERATE
 	tokenRows             // ROWS
 	tokenX                // X
+	tokenPosition         // POSITION
+	tokenIn               // IN
+	tokenExtract          // EXTRACT
+	tokenAtKeyword        // AT
+	tokenCast             // CAST
 )
 
 var keywords = map[string]tokenType{
@@ -184,7 +195,11 @@ var keywords = map[string]tokenType{
 	"else":              tokenElse,
 	"end":               tokenEnd,
 	"timestamp":         tokenTimestamp,
+	"date":              tokenDate,
 	"interval":          tokenInterval,
+	"year":              tokenYear,
+	"quarter":           tokenQuarter,
+	"month":             tokenMonth,
 	"week":              tokenWeek,
 	"day":               tokenDay,
 	"hour":              tokenHour,
@@ -211,6 +226,11 @@ var keywords = map[string]tokenType{
 	"generate":          tokenGenerate,
 	"rows":              tokenRows,
 	"x":                 tokenX,
+	"position":          tokenPosition,
+	"in":                tokenIn,
+	"extract":           tokenExtract,
+	"at":                tokenAtKeyword,
+	"cast":              tokenCast,
 }
 
 var specialChars = map[int]tokenType{
@@ -283,6 +303,8 @@ func (l *lexer) lex() token {
 	case '-':
 		if l.peekN(1) == '-' { // --
 			tok = l.lexComment()
+		} else if l.peekN(1) == '>' { // ->
+			tok = l.lexTwoChars(tokenArrow)
 		} else {
 			tok = l.lexChar()
 		}
@@ -335,6 +357,8 @@ func (l *lexer) lex() token {
 	case ':':
 		if l.peekN(1) == '=' {
 			tok = l.lexTwoChars(tokenAssign)
+		} else if l.peekN(1) == ':' {
+			tok = l.lexTwoChars(tokenTypeCast)
 		} else {
 			tok = l.lexChar()
 		}
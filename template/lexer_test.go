@@ -32,7 +32,11 @@ func TestLexer(t *testing.T) {
 		{"else", []token{mkToken(tokenElse, "else"), mkToken(tokenEOF, "")}},
 		{"end", []token{mkToken(tokenEnd, "end"), mkToken(tokenEOF, "")}},
 		{"timestamp", []token{mkToken(tokenTimestamp, "timestamp"), mkToken(tokenEOF, "")}},
+		{"date", []token{mkToken(tokenDate, "date"), mkToken(tokenEOF, "")}},
 		{"interval", []token{mkToken(tokenInterval, "interval"), mkToken(tokenEOF, "")}},
+		{"year", []token{mkToken(tokenYear, "year"), mkToken(tokenEOF, "")}},
+		{"quarter", []token{mkToken(tokenQuarter, "quarter"), mkToken(tokenEOF, "")}},
+		{"month", []token{mkToken(tokenMonth, "month"), mkToken(tokenEOF, "")}},
 		{"week", []token{mkToken(tokenWeek, "week"), mkToken(tokenEOF, "")}},
 		{"day", []token{mkToken(tokenDay, "day"), mkToken(tokenEOF, "")}},
 		{"hour", []token{mkToken(tokenHour, "hour"), mkToken(tokenEOF, "")}},
@@ -58,6 +62,10 @@ func TestLexer(t *testing.T) {
 		{"generate", []token{mkToken(tokenGenerate, "generate"), mkToken(tokenEOF, "")}},
 		{"rows", []token{mkToken(tokenRows, "rows"), mkToken(tokenEOF, "")}},
 		{"x", []token{mkToken(tokenX, "x"), mkToken(tokenEOF, "")}},
+		{"position", []token{mkToken(tokenPosition, "position"), mkToken(tokenEOF, "")}},
+		{"in", []token{mkToken(tokenIn, "in"), mkToken(tokenEOF, "")}},
+		{"extract", []token{mkToken(tokenExtract, "extract"), mkToken(tokenEOF, "")}},
+		{"at", []token{mkToken(tokenAtKeyword, "at"), mkToken(tokenEOF, "")}},
 		// Identifiers
 		{"abc", []token{mkToken(tokenIdent, "abc"), mkToken(tokenEOF, "")}},
 		{"_abc", []token{mkToken(tokenIdent, "_abc"), mkToken(tokenEOF, "")}},
@@ -153,6 +161,16 @@ func TestLexer(t *testing.T) {
 			mkToken(tokenAt, "@"), mkToken(tokenIdent, "a"), mkToken(tokenAssign, ":="),
 			mkToken(tokenIdent, "b"), mkToken(tokenEOF, ""),
 		}},
+		// Type casts
+		{"a::int", []token{
+			mkToken(tokenIdent, "a"), mkToken(tokenTypeCast, "::"), mkToken(tokenIdent, "int"),
+			mkToken(tokenEOF, ""),
+		}},
+		// Lambdas
+		{"x -> -x", []token{
+			mkToken(tokenX, "x"), mkToken(tokenArrow, "->"), mkToken(tokenSub, "-"),
+			mkToken(tokenX, "x"), mkToken(tokenEOF, ""),
+		}},
 		// Single char
 		{"!#$%?:", []token{
 			mkToken(tokenChar, "!"), mkToken(tokenChar, "#"), mkToken(tokenChar, "$"),
@@ -2,6 +2,7 @@ package template
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/gozssky/dbgen/constant"
@@ -10,10 +11,16 @@ import (
 
 // Parser is a parser for templates.
 type Parser struct {
+	// DecimalLiterals makes numeric literals with a fraction or an exponent
+	// exact decimals instead of floating-point numbers.
+	DecimalLiterals bool
+
 	lex   *lexer
 	input string
 	tok   token // one token look-ahead
 	tok1  token // two token look-ahead
+	// lambdaParams are the parameters of the enclosing lambdas.
+	lambdaParams []Name
 }
 
 func (p *Parser) init(input string) {
@@ -50,6 +57,11 @@ func (p *Parser) expect(tokTyp tokenType) error {
 	return nil
 }
 
+// pos returns the position of the current token.
+func (p *Parser) pos() Pos {
+	return Pos{Line: p.tok.line, Column: p.tok.col}
+}
+
 func (p *Parser) errorExpected(msg string) error {
 	return p.errorf("expected %s, found %s", msg, p.tok)
 }
@@ -484,12 +496,14 @@ func (p *Parser) parseStmt() (Expr, error) {
 		if p.tok.typ != tokenSemicolon {
 			return expr, nil
 		}
+		pos := p.pos()
 		p.next()
 		nextExpr, err := p.parseExpr()
 		if err != nil {
 			return nil, err
 		}
 		expr = &BinaryExpr{
+			Pos:   pos,
 			Op:    OpSemicolon,
 			Left:  expr,
 			Right: nextExpr,
@@ -508,15 +522,16 @@ func (p *Parser) parseBinaryExpr(prec int) (Expr, error) {
 	}
 	op, next := p.parseOp()
 	for op.IsBinary() && (op.Prec() > prec || op.Prec() == prec && op.IsRightAssoc()) {
+		pos := p.pos()
 		next()
 		right, err := p.parseBinaryExpr(op.Prec())
 		if err != nil {
 			return nil, err
 		}
 		if getV, ok := left.(*GetVariable); ok && op == OpAssign {
-			left = &SetVariable{Name: getV.Name, Value: right}
+			left = &SetVariable{Pos: getV.Pos, Name: getV.Name, Value: right}
 		} else {
-			left = &BinaryExpr{Op: op, Left: left, Right: right}
+			left = &BinaryExpr{Pos: pos, Op: op, Left: left, Right: right}
 		}
 		op, next = p.parseOp()
 	}
@@ -535,7 +550,7 @@ func (p *Parser) parseOp() (_ Op, next func()) {
 }
 
 func (p *Parser) parseUnaryExpr() (Expr, error) {
-	op := p.tok.Op()
+	op, pos := p.tok.Op(), p.pos()
 	switch op {
 	case OpNot:
 		p.next()
@@ -543,36 +558,70 @@ func (p *Parser) parseUnaryExpr() (Expr, error) {
 		if err != nil {
 			return nil, err
 		}
-		return &UnaryExpr{Op: op, Expr: expr}, nil
+		return &UnaryExpr{Pos: pos, Op: op, Expr: expr}, nil
 	case OpAdd, OpSub, OpBitNot:
 		p.next()
 		expr, err := p.parsePrimaryExpr()
 		if err != nil {
 			return nil, err
 		}
-		return &UnaryExpr{Op: op, Expr: expr}, nil
+		// `::` binds tighter than unary operators, so -1::text is -(1::text).
+		expr, err = p.parseTypeCasts(expr)
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Pos: pos, Op: op, Expr: expr}, nil
 	}
 	expr, err := p.parsePrimaryExpr()
 	if err != nil {
 		return nil, err
 	}
 
-	if p.tok.typ != tokenLeftBrack {
-		return expr, nil
+	if p.tok.typ == tokenLeftBrack {
+		expr, err = p.parseSubscript(expr)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	p.next()
-	index, err := p.parseExpr()
+	expr, err = p.parseTypeCasts(expr)
 	if err != nil {
 		return nil, err
 	}
-	if err := p.expect(tokenRightBrack); err != nil {
-		return nil, err
+
+	for p.tok.typ == tokenAtKeyword && p.tok1.typ == tokenTime {
+		pos := p.pos()
+		p.next()
+		p.next()
+		if err := p.expect(tokenZone); err != nil {
+			return nil, err
+		}
+		zone, err := p.parsePrimaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		expr = &AtTimeZone{Pos: pos, Value: expr, Zone: zone}
 	}
-	return &Subscript{Base: expr, Index: index}, nil
+	return expr, nil
 }
 
+// parsePrimaryExpr parses an operand, positioned at its first token.
 func (p *Parser) parsePrimaryExpr() (Expr, error) {
+	pos := p.pos()
+	expr, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	expr.(interface{ setPos(Pos) }).setPos(pos)
+	return expr, nil
+}
+
+func (p *Parser) parseOperand() (Expr, error) {
+	// Lambda parameters shadow keywords, so that e.g. `x -> x` works.
+	if param, ok := p.lambdaParam(); ok {
+		p.next()
+		return &LambdaParam{Name: param}, nil
+	}
 	switch p.tok.typ {
 	case tokenRowNum:
 		p.next()
@@ -582,13 +631,13 @@ func (p *Parser) parsePrimaryExpr() (Expr, error) {
 		return &SubRowNum{}, nil
 	case tokenNull:
 		p.next()
-		return &Constant{constant.Null}, nil
+		return &Constant{Value: constant.Null}, nil
 	case tokenTrue:
 		p.next()
-		return &Constant{constant.MakeBool(true)}, nil
+		return &Constant{Value: constant.MakeBool(true)}, nil
 	case tokenFalse:
 		p.next()
-		return &Constant{constant.MakeBool(false)}, nil
+		return &Constant{Value: constant.MakeBool(false)}, nil
 	case tokenCurrentTimestamp:
 		p.next()
 		return &CurrentTimestamp{}, nil
@@ -601,17 +650,21 @@ func (p *Parser) parsePrimaryExpr() (Expr, error) {
 		if err := p.expect(tokenRightParen); err != nil {
 			return nil, err
 		}
-		return &ParenExpr{expr}, nil
+		return &ParenExpr{Expr: expr}, nil
 	case tokenString:
-		expr := &Constant{constant.MakeBytes([]byte(unescape(p.tok.val)))}
+		expr := &Constant{Value: constant.MakeBytes([]byte(unescape(p.tok.val)))}
 		p.next()
 		return expr, nil
 	case tokenNumber:
-		val, err := constant.MakeNumberFromLiteral(p.tok.val)
+		makeNumber := constant.MakeNumberFromLiteral
+		if p.DecimalLiterals {
+			makeNumber = constant.MakeExactNumberFromLiteral
+		}
+		val, err := makeNumber(p.tok.val)
 		if err != nil {
 			return nil, err
 		}
-		expr := &Constant{val}
+		expr := &Constant{Value: val}
 		p.next()
 		return expr, nil
 	case tokenCase:
@@ -639,6 +692,20 @@ func (p *Parser) parsePrimaryExpr() (Expr, error) {
 		}
 		timestamp.Value = expr
 		return timestamp, nil
+	case tokenDate:
+		p.next()
+		expr, err := p.parsePrimaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &Date{Value: expr}, nil
+	case tokenTime:
+		p.next()
+		expr, err := p.parsePrimaryExpr()
+		if err != nil {
+			return nil, err
+		}
+		return &Time{Value: expr}, nil
 	case tokenInterval:
 		p.next()
 		expr, err := p.parseExpr()
@@ -647,6 +714,12 @@ func (p *Parser) parsePrimaryExpr() (Expr, error) {
 		}
 		interval := &Interval{Value: expr}
 		switch p.tok.typ {
+		case tokenYear:
+			interval.Unit = IntervalUnitYear
+		case tokenQuarter:
+			interval.Unit = IntervalUnitQuarter
+		case tokenMonth:
+			interval.Unit = IntervalUnitMonth
 		case tokenWeek:
 			interval.Unit = IntervalUnitWeek
 		case tokenDay:
@@ -664,6 +737,7 @@ func (p *Parser) parsePrimaryExpr() (Expr, error) {
 		default:
 			return nil, p.errorExpected("interval unit")
 		}
+		p.next()
 		return interval, nil
 	case tokenX:
 		p.next()
@@ -672,8 +746,8 @@ func (p *Parser) parsePrimaryExpr() (Expr, error) {
 			return nil, err
 		}
 		return &FuncExpr{
-			NewQName("hex", "decode"),
-			[]Expr{expr},
+			Name: NewQName("hex", "decode"),
+			Args: []Expr{expr},
 		}, nil
 	case tokenAt:
 		p.next()
@@ -709,34 +783,162 @@ func (p *Parser) parsePrimaryExpr() (Expr, error) {
 		return p.parseSubstring()
 	case tokenOverlay:
 		return p.parseOverlay()
-	default:
-		name, err := p.parseQName()
-		if err != nil {
-			return nil, err
-		}
-		if err := p.expect(tokenLeftParen); err != nil {
-			return nil, err
+	case tokenPosition:
+		return p.parsePosition()
+	case tokenExtract:
+		return p.parseExtract()
+	case tokenCast:
+		if p.tok1.typ == tokenLeftParen {
+			return p.parseCast()
 		}
-		funcExpr := &FuncExpr{
-			Name: name,
+	}
+
+	name, err := p.parseQName()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokenLeftParen); err != nil {
+		return nil, err
+	}
+	funcExpr := &FuncExpr{
+		Name: name,
+	}
+	for {
+		if p.tok.typ == tokenRightParen {
+			p.next()
+			return funcExpr, nil
 		}
-		for {
-			if p.tok.typ == tokenRightParen {
-				p.next()
-				return funcExpr, nil
+		if p.tok.typ == tokenUsing && len(funcExpr.Args) > 0 {
+			unit, err := p.parseUsingUnit()
+			if err != nil {
+				return nil, err
 			}
-			if len(funcExpr.Args) > 0 {
-				if err := p.expect(tokenComma); err != nil {
-					return nil, err
-				}
+			funcExpr.Unit = unit
+			if err := p.expect(tokenRightParen); err != nil {
+				return nil, err
 			}
-			expr, err := p.parseExpr()
-			if err != nil {
+			return funcExpr, nil
+		}
+		if len(funcExpr.Args) > 0 {
+			if err := p.expect(tokenComma); err != nil {
 				return nil, err
 			}
-			funcExpr.Args = append(funcExpr.Args, expr)
 		}
+		expr, err := p.parseFuncArg()
+		if err != nil {
+			return nil, err
+		}
+		funcExpr.Args = append(funcExpr.Args, expr)
+	}
+}
+
+// parseFuncArg parses a function argument, which may be a lambda.
+func (p *Parser) parseFuncArg() (Expr, error) {
+	pos := p.pos()
+	params, ok, err := p.parseLambdaParams()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return p.parseExpr()
+	}
+	p.lambdaParams = append(p.lambdaParams, params...)
+	body, err := p.parseExpr()
+	p.lambdaParams = p.lambdaParams[:len(p.lambdaParams)-len(params)]
+	if err != nil {
+		return nil, err
 	}
+	return &Lambda{Pos: pos, Params: params, Body: body}, nil
+}
+
+// parseLambdaParams parses the `x ->` or `(x, y) ->` head of a lambda. If
+// the input is not a lambda, it consumes nothing and returns false.
+func (p *Parser) parseLambdaParams() (_ []Name, ok bool, _ error) {
+	if p.tok.isIdent() && p.tok1.typ == tokenArrow {
+		params := []Name{NewName(p.tok.val)}
+		p.next()
+		p.next()
+		return params, true, nil
+	}
+	if p.tok.typ != tokenLeftParen || !p.tok1.isIdent() {
+		return nil, false, nil
+	}
+	// Look ahead for `) ->`, backtracking if it is a parenthesized expression.
+	lex, tok, tok1 := *p.lex, p.tok, p.tok1
+	var params []Name
+	p.next()
+	for p.tok.isIdent() {
+		params = append(params, NewName(p.tok.val))
+		p.next()
+		if p.tok.typ != tokenComma {
+			break
+		}
+		p.next()
+	}
+	if p.tok.typ != tokenRightParen || p.tok1.typ != tokenArrow {
+		*p.lex, p.tok, p.tok1 = lex, tok, tok1
+		return nil, false, nil
+	}
+	for i, param := range params {
+		for _, other := range params[:i] {
+			if param.N == other.N {
+				return nil, false, p.errorf("duplicate lambda parameter %s", param)
+			}
+		}
+	}
+	p.next()
+	p.next()
+	return params, true, nil
+}
+
+// lambdaParam returns the name of the lambda parameter at the current token,
+// if any.
+func (p *Parser) lambdaParam() (Name, bool) {
+	if len(p.lambdaParams) == 0 || !p.tok.isIdent() || p.tok1.typ == tokenLeftParen || p.tok1.typ == tokenPeriod {
+		return Name{}, false
+	}
+	name := NewName(p.tok.val)
+	for _, param := range p.lambdaParams {
+		if param.N == name.N {
+			return name, true
+		}
+	}
+	return Name{}, false
+}
+
+// parseSubscript parses an `[index]` or `[from:to]` suffix of an expression.
+func (p *Parser) parseSubscript(base Expr) (Expr, error) {
+	pos := p.pos()
+	if err := p.expect(tokenLeftBrack); err != nil {
+		return nil, err
+	}
+	isColon := func() bool { return p.tok.typ == tokenChar && p.tok.val == ":" }
+	var index Expr
+	if !isColon() {
+		var err error
+		if index, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if !isColon() {
+		if err := p.expect(tokenRightBrack); err != nil {
+			return nil, err
+		}
+		return &Subscript{Pos: pos, Base: base, Index: index}, nil
+	}
+	p.next()
+	slice := &Slice{Pos: pos, Base: base, From: index}
+	if p.tok.typ != tokenRightBrack {
+		to, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		slice.To = to
+	}
+	if err := p.expect(tokenRightBrack); err != nil {
+		return nil, err
+	}
+	return slice, nil
 }
 
 func (p *Parser) parseCaseValueWhen() (Expr, error) {
@@ -824,16 +1026,11 @@ func (p *Parser) parseSubstring() (Expr, error) {
 	}
 
 	if p.tok.typ == tokenUsing {
-		p.next()
-		switch p.tok.typ {
-		case tokenOctets:
-			substring.Unit = StringUnitOctets
-		case tokenCharacters:
-			substring.Unit = StringUnitCharacters
-		default:
-			return nil, p.errorExpected("OCTETS or CHARACTERS")
+		unit, err := p.parseUsingUnit()
+		if err != nil {
+			return nil, err
 		}
-		p.next()
+		substring.Unit = unit
 	}
 
 	if err := p.expect(tokenRightParen); err != nil {
@@ -884,16 +1081,11 @@ func (p *Parser) parseOverlay() (Expr, error) {
 	}
 
 	if p.tok.typ == tokenUsing {
-		p.next()
-		switch p.tok.typ {
-		case tokenOctets:
-			overlay.Unit = StringUnitOctets
-		case tokenCharacters:
-			overlay.Unit = StringUnitCharacters
-		default:
-			return nil, p.errorExpected("OCTETS or CHARACTERS")
+		unit, err := p.parseUsingUnit()
+		if err != nil {
+			return nil, err
 		}
-		p.next()
+		overlay.Unit = unit
 	}
 	if err := p.expect(tokenRightParen); err != nil {
 		return nil, err
@@ -901,6 +1093,222 @@ func (p *Parser) parseOverlay() (Expr, error) {
 	return overlay, nil
 }
 
+func (p *Parser) parsePosition() (Expr, error) {
+	if err := p.expect(tokenPosition); err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokenLeftParen); err != nil {
+		return nil, err
+	}
+	position := &Position{}
+	needle, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	position.Needle = needle
+
+	// Both the SQL standard form `position(needle IN haystack)` and the
+	// function call form `position(needle, haystack)` are accepted.
+	if p.tok.typ == tokenComma {
+		p.next()
+	} else if err := p.expect(tokenIn); err != nil {
+		return nil, err
+	}
+	haystack, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	position.Haystack = haystack
+
+	if p.tok.typ == tokenUsing {
+		unit, err := p.parseUsingUnit()
+		if err != nil {
+			return nil, err
+		}
+		position.Unit = unit
+	}
+	if err := p.expect(tokenRightParen); err != nil {
+		return nil, err
+	}
+	return position, nil
+}
+
+func (p *Parser) parseExtract() (Expr, error) {
+	if err := p.expect(tokenExtract); err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokenLeftParen); err != nil {
+		return nil, err
+	}
+	if !p.tok.isIdent() {
+		return nil, p.errorExpected("field name")
+	}
+	extract := &Extract{Field: strings.ToLower(unescape(p.tok.val))}
+	p.next()
+	if err := p.expect(tokenFrom); err != nil {
+		return nil, err
+	}
+	value, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	extract.Value = value
+	if err := p.expect(tokenRightParen); err != nil {
+		return nil, err
+	}
+	return extract, nil
+}
+
+func (p *Parser) parseCast() (Expr, error) {
+	if err := p.expect(tokenCast); err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokenLeftParen); err != nil {
+		return nil, err
+	}
+	value, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.tok.isIdent() || !strings.EqualFold(p.tok.val, "as") {
+		return nil, p.errorExpected("AS")
+	}
+	p.next()
+	typ, err := p.parseDataType()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tokenRightParen); err != nil {
+		return nil, err
+	}
+	return &Cast{Value: value, Type: typ}, nil
+}
+
+// parseTypeCasts parses any `::type` suffixes following an expression.
+func (p *Parser) parseTypeCasts(expr Expr) (Expr, error) {
+	for p.tok.typ == tokenTypeCast {
+		pos := p.pos()
+		p.next()
+		typ, err := p.parseDataType()
+		if err != nil {
+			return nil, err
+		}
+		expr = &Cast{Pos: pos, Value: expr, Type: typ}
+	}
+	return expr, nil
+}
+
+// parseDataType parses the name of a data type, such as `INTEGER` or
+// `DECIMAL(10, 2)`.
+func (p *Parser) parseDataType() (constant.Type, error) {
+	if !p.tok.isIdent() {
+		return constant.Type{}, p.errorExpected("type name")
+	}
+	var typ constant.Type
+	name := strings.ToLower(unescape(p.tok.val))
+	switch name {
+	case "boolean", "bool":
+		typ.Kind = constant.KindBool
+	case "smallint", "int2":
+		typ = constant.Type{Kind: constant.KindInt, Bits: 16}
+	case "integer", "int", "int4":
+		typ = constant.Type{Kind: constant.KindInt, Bits: 32}
+	case "bigint", "int8":
+		typ = constant.Type{Kind: constant.KindInt, Bits: 64}
+	case "real", "float4":
+		typ = constant.Type{Kind: constant.KindFloat, Bits: 32}
+	case "float", "float8", "double":
+		typ = constant.Type{Kind: constant.KindFloat, Bits: 64}
+		if name == "double" && strings.EqualFold(p.tok1.val, "precision") {
+			p.next()
+		}
+	case "decimal", "numeric":
+		typ.Kind = constant.KindDecimal
+	case "varchar", "text":
+		typ.Kind = constant.KindBytes
+	case "bytea":
+		typ = constant.Type{Kind: constant.KindBytes, Binary: true}
+	case "timestamp":
+		typ.Kind = constant.KindTimestamp
+	case "date":
+		typ.Kind = constant.KindDate
+	case "time":
+		typ.Kind = constant.KindTime
+	case "interval":
+		typ.Kind = constant.KindInterval
+	case "json":
+		typ.Kind = constant.KindJSON
+	default:
+		return constant.Type{}, p.errorf("unknown type %s", p.tok)
+	}
+	p.next()
+	if p.tok.typ != tokenLeftParen || name != "varchar" && typ.Kind != constant.KindDecimal {
+		return typ, nil
+	}
+	p.next()
+	n, err := p.parseTypeModifier()
+	if err != nil {
+		return constant.Type{}, err
+	}
+	if n == 0 {
+		return constant.Type{}, p.errorf("type modifier must be positive")
+	}
+	if typ.Kind == constant.KindBytes {
+		typ.Length = n
+	} else {
+		if n > constant.MaxDecimalScale {
+			return constant.Type{}, p.errorf("decimal precision must be at most %d", constant.MaxDecimalScale)
+		}
+		typ.Precision = n
+		if p.tok.typ == tokenComma {
+			p.next()
+			if typ.Scale, err = p.parseTypeModifier(); err != nil {
+				return constant.Type{}, err
+			}
+			if typ.Scale > typ.Precision {
+				return constant.Type{}, p.errorf("decimal scale %d must not exceed the precision %d", typ.Scale, typ.Precision)
+			}
+		}
+	}
+	if err := p.expect(tokenRightParen); err != nil {
+		return constant.Type{}, err
+	}
+	return typ, nil
+}
+
+// parseTypeModifier parses a non-negative integer, such as the length of a
+// VARCHAR.
+func (p *Parser) parseTypeModifier() (int, error) {
+	if p.tok.typ != tokenNumber {
+		return 0, p.errorExpected("number")
+	}
+	n, err := strconv.Atoi(p.tok.val)
+	if err != nil || n < 0 {
+		return 0, p.errorf("invalid type modifier %s", p.tok)
+	}
+	p.next()
+	return n, nil
+}
+
+// parseUsingUnit parses a `USING CHARACTERS` or `USING OCTETS` clause.
+// The USING keyword is known to be present.
+func (p *Parser) parseUsingUnit() (StringUnit, error) {
+	if err := p.expect(tokenUsing); err != nil {
+		return 0, err
+	}
+	var unit StringUnit
+	switch p.tok.typ {
+	case tokenOctets:
+		unit = StringUnitOctets
+	case tokenCharacters:
+		unit = StringUnitCharacters
+	default:
+		return 0, p.errorExpected("OCTETS or CHARACTERS")
+	}
+	p.next()
+	return unit, nil
+}
+
 func Parse(input string) (*Template, error) {
 	var p Parser
 	return p.Parse(input)
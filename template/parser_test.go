@@ -1,6 +1,7 @@
 package template_test
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -161,6 +162,8 @@ CREATE TABLE D ( … );`,
 			for _, table := range tmpl.Tables {
 				table.Content = ""
 			}
+			clearPos(tc.tmpl)
+			clearPos(tmpl)
 			require.Equal(t, tc.tmpl, tmpl)
 		})
 	}
@@ -172,6 +175,37 @@ func makeExpr(t *testing.T, input string) template.Expr {
 	return expr
 }
 
+// clearPos zeroes the positions of all expressions reachable from v, so that
+// parsed expressions can be compared with the expected ones.
+func clearPos(v interface{}) {
+	clearPosValue(reflect.ValueOf(v))
+}
+
+func clearPosValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			clearPosValue(v.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			clearPosValue(v.Index(i))
+		}
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(template.Pos{}) {
+			if v.CanSet() {
+				v.Set(reflect.Zero(v.Type()))
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				clearPosValue(v.Field(i))
+			}
+		}
+	}
+}
+
 func TestTableContent(t *testing.T) {
 	datadriven.RunTest(t, "testdata/table_content", func(t *testing.T, d *datadriven.TestData) string {
 		tmpl, err := template.Parse(d.Input)
@@ -343,12 +377,34 @@ func TestParseExpr(t *testing.T) {
 			"TIMESTAMP WITH TIME ZONE '2016-01-02 15:04:05.999 Asia/Hong_Kong'",
 			true,
 		},
+		{
+			"date '2016-01-02'",
+			&template.Date{Value: &template.Constant{Value: constant.MakeBytes([]byte("2016-01-02"))}},
+			"DATE '2016-01-02'",
+			true,
+		},
+		{
+			"time @t",
+			&template.Time{Value: &template.GetVariable{Name: "t"}},
+			"TIME @`t`",
+			true,
+		},
 		{
 			"interval 30 minute",
 			&template.Interval{Unit: template.IntervalUnitMinute, Value: &template.Constant{Value: constant.MakeInt64(30)}},
 			"INTERVAL 30 MINUTE",
 			true,
 		},
+		{
+			"interval 1 month + @x",
+			&template.BinaryExpr{
+				Op:    template.OpAdd,
+				Left:  &template.Interval{Unit: template.IntervalUnitMonth, Value: &template.Constant{Value: constant.MakeInt64(1)}},
+				Right: &template.GetVariable{Name: "x"},
+			},
+			"INTERVAL 1 MONTH + @`x`",
+			true,
+		},
 		{
 			"array['X', 'Y', 'Z']",
 			&template.Array{Elems: []template.Expr{
@@ -365,6 +421,60 @@ func TestParseExpr(t *testing.T) {
 			"@`x`[1]",
 			true,
 		},
+		{
+			"@x[2:@n]",
+			&template.Slice{Base: &template.GetVariable{Name: "x"}, From: &template.Constant{Value: constant.MakeInt64(2)}, To: &template.GetVariable{Name: "n"}},
+			"@`x`[2:@`n`]",
+			true,
+		},
+		{
+			"@x[:3]",
+			&template.Slice{Base: &template.GetVariable{Name: "x"}, To: &template.Constant{Value: constant.MakeInt64(3)}},
+			"@`x`[:3]",
+			true,
+		},
+		{
+			"array_reduce(@a, 0, (Acc, x) -> acc + x)",
+			&template.FuncExpr{
+				Name: template.NewQName("array_reduce"),
+				Args: []template.Expr{
+					&template.GetVariable{Name: "a"},
+					&template.Constant{Value: constant.MakeInt64(0)},
+					&template.Lambda{
+						Params: []template.Name{template.NewName("Acc"), template.NewName("x")},
+						Body: &template.BinaryExpr{
+							Op:    template.OpAdd,
+							Left:  &template.LambdaParam{Name: template.NewName("acc")},
+							Right: &template.LambdaParam{Name: template.NewName("x")},
+						},
+					},
+				},
+			},
+			"array_reduce(@`a`, 0, (Acc, x) -> acc + x)",
+			true,
+		},
+		{
+			"array_map(@a, x -> -x * abs(x))",
+			&template.FuncExpr{
+				Name: template.NewQName("array_map"),
+				Args: []template.Expr{
+					&template.GetVariable{Name: "a"},
+					&template.Lambda{
+						Params: []template.Name{template.NewName("x")},
+						Body: &template.BinaryExpr{
+							Op:   template.OpMul,
+							Left: &template.UnaryExpr{Op: template.OpSub, Expr: &template.LambdaParam{Name: template.NewName("x")}},
+							Right: &template.FuncExpr{
+								Name: template.NewQName("abs"),
+								Args: []template.Expr{&template.LambdaParam{Name: template.NewName("x")}},
+							},
+						},
+					},
+				},
+			},
+			"array_map(@`a`, x -> - x * abs(x))",
+			true,
+		},
 		{
 			"substring('ⓘⓝⓟⓤⓣ' FROM 2 FOR 3 USING CHARACTERS)",
 			&template.Substring{
@@ -399,6 +509,94 @@ func TestParseExpr(t *testing.T) {
 			"overlay('input' PLACING 'replacement' FROM 2 FOR 3 USING CHARACTERS)",
 			true,
 		},
+		{
+			"position('p' IN 'input' USING OCTETS)",
+			&template.Position{
+				Needle:   &template.Constant{Value: constant.MakeBytes([]byte("p"))},
+				Haystack: &template.Constant{Value: constant.MakeBytes([]byte("input"))},
+				Unit:     template.StringUnitOctets,
+			},
+			"position('p' IN 'input' USING OCTETS)",
+			true,
+		},
+		{
+			"position('p', 'input')",
+			&template.Position{
+				Needle:   &template.Constant{Value: constant.MakeBytes([]byte("p"))},
+				Haystack: &template.Constant{Value: constant.MakeBytes([]byte("input"))},
+			},
+			"position('p' IN 'input')",
+			true,
+		},
+		{
+			"lpad('input', 10 USING CHARACTERS)",
+			&template.FuncExpr{
+				Name: template.NewQName("lpad"),
+				Args: []template.Expr{
+					&template.Constant{Value: constant.MakeBytes([]byte("input"))},
+					&template.Constant{Value: constant.MakeInt64(10)},
+				},
+				Unit: template.StringUnitCharacters,
+			},
+			"lpad('input', 10 USING CHARACTERS)",
+			true,
+		},
+		{
+			"extract(Year FROM current_timestamp)",
+			&template.Extract{Field: "year", Value: &template.CurrentTimestamp{}},
+			"extract(YEAR FROM current_timestamp)",
+			true,
+		},
+		{
+			"@ts at time zone 'UTC' + interval 1 hour",
+			&template.BinaryExpr{
+				Op: template.OpAdd,
+				Left: &template.AtTimeZone{
+					Value: &template.GetVariable{Name: "ts"},
+					Zone:  &template.Constant{Value: constant.MakeBytes([]byte("UTC"))},
+				},
+				Right: &template.Interval{Unit: template.IntervalUnitHour, Value: &template.Constant{Value: constant.MakeInt64(1)}},
+			},
+			"@`ts` AT TIME ZONE 'UTC' + INTERVAL 1 HOUR",
+			true,
+		},
+		{
+			"cast(@x AS decimal(10, 2))",
+			&template.Cast{
+				Value: &template.GetVariable{Name: "x"},
+				Type:  constant.Type{Kind: constant.KindDecimal, Precision: 10, Scale: 2},
+			},
+			"CAST(@`x` AS DECIMAL(10, 2))",
+			true,
+		},
+		{
+			"-'1'::int::double precision",
+			&template.UnaryExpr{
+				Op: template.OpSub,
+				Expr: &template.Cast{
+					Value: &template.Cast{
+						Value: &template.Constant{Value: constant.MakeBytes([]byte("1"))},
+						Type:  constant.Type{Kind: constant.KindInt, Bits: 32},
+					},
+					Type: constant.Type{Kind: constant.KindFloat, Bits: 64},
+				},
+			},
+			"- CAST(CAST('1' AS INTEGER) AS DOUBLE PRECISION)",
+			true,
+		},
+		{
+			"@x[1]::varchar(3) || 'a'",
+			&template.BinaryExpr{
+				Op: template.OpConcat,
+				Left: &template.Cast{
+					Value: &template.Subscript{Base: &template.GetVariable{Name: "x"}, Index: &template.Constant{Value: constant.MakeInt64(1)}},
+					Type:  constant.Type{Kind: constant.KindBytes, Length: 3},
+				},
+				Right: &template.Constant{Value: constant.MakeBytes([]byte("a"))},
+			},
+			"CAST(@`x`[1] AS VARCHAR(3)) || 'a'",
+			true,
+		},
 		{
 			"rand.regex('[0-9a-z]+', 'i', 100)",
 			&template.FuncExpr{
@@ -573,6 +771,7 @@ func TestParseExpr(t *testing.T) {
 		t.Run(tc.input, func(t *testing.T) {
 			expr, err := template.ParseExpr(tc.input)
 			require.NoError(t, err)
+			clearPos(expr)
 			require.Equal(t, tc.expr, expr)
 			exprStr := expr.String()
 			require.Equal(t, tc.exprStr, exprStr)
@@ -580,8 +779,79 @@ func TestParseExpr(t *testing.T) {
 			reparsed, err := template.ParseExpr(exprStr)
 			require.NoError(t, err)
 			if tc.isExprStrSameAsInput {
+				clearPos(reparsed)
 				require.Equal(t, tc.expr, reparsed)
 			}
 		})
 	}
 }
+
+func TestParseDecimalLiterals(t *testing.T) {
+	p := template.Parser{DecimalLiterals: true}
+	expr, err := p.ParseExpr("12.30 + 1")
+	require.NoError(t, err)
+	binary := expr.(*template.BinaryExpr)
+	require.Equal(t, constant.KindDecimal, binary.Left.(*template.Constant).Value.Kind())
+	require.Equal(t, constant.KindInt, binary.Right.(*template.Constant).Value.Kind())
+	require.Equal(t, "12.30 + 1", expr.String())
+
+	expr, err = template.ParseExpr("12.30")
+	require.NoError(t, err)
+	require.Equal(t, constant.KindFloat, expr.(*template.Constant).Value.Kind())
+}
+
+func TestParsePositions(t *testing.T) {
+	expr, err := template.ParseExpr("1 +\n  f(rownum, -@x)::text\n  || 'a'")
+	require.NoError(t, err)
+	concat := expr.(*template.BinaryExpr)
+	require.Equal(t, template.Pos{Line: 3, Column: 3}, concat.Position())
+	add := concat.Left.(*template.BinaryExpr)
+	require.Equal(t, template.Pos{Line: 1, Column: 3}, add.Position())
+	require.Equal(t, template.Pos{Line: 1, Column: 1}, add.Left.Position())
+	cast := add.Right.(*template.Cast)
+	require.Equal(t, template.Pos{Line: 2, Column: 17}, cast.Position())
+	call := cast.Value.(*template.FuncExpr)
+	require.Equal(t, template.Pos{Line: 2, Column: 3}, call.Position())
+	require.Equal(t, template.Pos{Line: 2, Column: 5}, call.Args[0].Position())
+	neg := call.Args[1].(*template.UnaryExpr)
+	require.Equal(t, template.Pos{Line: 2, Column: 13}, neg.Position())
+	require.Equal(t, template.Pos{Line: 2, Column: 14}, neg.Expr.Position())
+	require.Equal(t, template.Pos{Line: 3, Column: 6}, concat.Right.Position())
+
+	expr, err = template.ParseExpr("@a := g(x -> x[1])")
+	require.NoError(t, err)
+	set := expr.(*template.SetVariable)
+	require.Equal(t, template.Pos{Line: 1, Column: 1}, set.Position())
+	lambda := set.Value.(*template.FuncExpr).Args[0].(*template.Lambda)
+	require.Equal(t, template.Pos{Line: 1, Column: 9}, lambda.Position())
+	require.Equal(t, template.Pos{Line: 1, Column: 15}, lambda.Body.(*template.Subscript).Position())
+	require.Equal(t, template.Pos{Line: 1, Column: 14}, lambda.Body.(*template.Subscript).Base.Position())
+}
+
+func TestParseCastErrors(t *testing.T) {
+	for _, input := range []string{
+		"cast(1 as)",
+		"cast(1 int)",
+		"1::unknown",
+		"1::varchar(0)",
+		"1::decimal(2, 3)",
+		"1::decimal(1001)",
+		"cast(1 as text",
+	} {
+		_, err := template.ParseExpr(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestParseLambdaErrors(t *testing.T) {
+	for _, input := range []string{
+		"f((x, x) -> x)",
+		"f(x ->)",
+		"f((x, 1) -> x)",
+		"f(x -> y)",
+		"x -> x",
+	} {
+		_, err := template.ParseExpr(input)
+		require.Error(t, err, input)
+	}
+}
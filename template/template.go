@@ -117,9 +117,35 @@ func (n Name) String() string {
 // Expr represents an expression.
 type Expr interface {
 	fmt.Stringer
+	// Position returns the position of the expression in the parsed input.
+	Position() Pos
 	isExpr()
 }
 
+// Pos is the line and column of an expression in the parsed input, both
+// starting at 1. Every expression embeds a Pos, which is zero if the
+// expression was not made by the parser.
+type Pos struct {
+	Line   int
+	Column int
+}
+
+func (p Pos) Position() Pos {
+	return p
+}
+
+// IsValid reports whether the position is known.
+func (p Pos) IsValid() bool {
+	return p.Line > 0
+}
+
+// setPos sets the position, unless it is already known.
+func (p *Pos) setPos(pos Pos) {
+	if !p.IsValid() {
+		*p = pos
+	}
+}
+
 func (*RowNum) isExpr()           {}
 func (*SubRowNum) isExpr()        {}
 func (*CurrentTimestamp) isExpr() {}
@@ -132,25 +158,34 @@ func (*ParenExpr) isExpr()        {}
 func (*FuncExpr) isExpr()         {}
 func (*CaseValueWhen) isExpr()    {}
 func (*Timestamp) isExpr()        {}
+func (*Date) isExpr()             {}
+func (*Time) isExpr()             {}
 func (*Interval) isExpr()         {}
 func (*Array) isExpr()            {}
 func (*Subscript) isExpr()        {}
+func (*Slice) isExpr()            {}
 func (*Substring) isExpr()        {}
 func (*Overlay) isExpr()          {}
+func (*Position) isExpr()         {}
+func (*Extract) isExpr()          {}
+func (*AtTimeZone) isExpr()       {}
+func (*Cast) isExpr()             {}
+func (*Lambda) isExpr()           {}
+func (*LambdaParam) isExpr()      {}
 
-type RowNum struct{}
+type RowNum struct{ Pos }
 
 func (*RowNum) String() string {
 	return "rownum"
 }
 
-type SubRowNum struct{}
+type SubRowNum struct{ Pos }
 
 func (*SubRowNum) String() string {
 	return "subrownum"
 }
 
-type CurrentTimestamp struct{}
+type CurrentTimestamp struct{ Pos }
 
 func (*CurrentTimestamp) String() string {
 	return "current_timestamp"
@@ -158,6 +193,7 @@ func (*CurrentTimestamp) String() string {
 
 // Constant represents a constant value, numeric or string.
 type Constant struct {
+	Pos
 	constant.Value
 }
 
@@ -170,15 +206,35 @@ func (c *Constant) String() string {
 		} else {
 			return fmt.Sprintf("X'%x'", b)
 		}
+	case constant.KindDate:
+		return fmt.Sprintf("DATE '%s'", c.Value)
+	case constant.KindTime:
+		return fmt.Sprintf("TIME '%s'", c.Value)
+	case constant.KindJSON:
+		return fmt.Sprintf("json(%s)", singleQuote(c.Value.String()))
 	case constant.KindInterval:
-		d, _ := constant.AsInterval(c.Value)
-		return fmt.Sprintf("INTERVAL %d MICROSECOND", d/time.Microsecond)
+		i, _ := constant.AsInterval(c.Value)
+		var parts []string
+		if i.Months != 0 {
+			parts = append(parts, fmt.Sprintf("INTERVAL %d MONTH", i.Months))
+		}
+		if i.Days != 0 {
+			parts = append(parts, fmt.Sprintf("INTERVAL %d DAY", i.Days))
+		}
+		if i.Microseconds != 0 || len(parts) == 0 {
+			parts = append(parts, fmt.Sprintf("INTERVAL %d MICROSECOND", i.Microseconds))
+		}
+		if len(parts) == 1 {
+			return parts[0]
+		}
+		return "(" + strings.Join(parts, " + ") + ")"
 	default:
 		return c.Value.String()
 	}
 }
 
 type GetVariable struct {
+	Pos
 	Name string
 }
 
@@ -187,6 +243,7 @@ func (gv *GetVariable) String() string {
 }
 
 type SetVariable struct {
+	Pos
 	Name  string
 	Value Expr
 }
@@ -344,6 +401,7 @@ func (op Op) IsBinary() bool {
 }
 
 type UnaryExpr struct {
+	Pos
 	Op   Op
 	Expr Expr
 }
@@ -353,6 +411,7 @@ func (expr *UnaryExpr) String() string {
 }
 
 type BinaryExpr struct {
+	Pos
 	Op    Op
 	Left  Expr
 	Right Expr
@@ -363,6 +422,7 @@ func (expr *BinaryExpr) String() string {
 }
 
 type ParenExpr struct {
+	Pos
 	Expr Expr
 }
 
@@ -371,8 +431,12 @@ func (expr *ParenExpr) String() string {
 }
 
 type FuncExpr struct {
+	Pos
 	Name *QName
 	Args []Expr
+	// Unit is the string unit given by a trailing `USING` clause,
+	// or zero if the clause is absent.
+	Unit StringUnit
 }
 
 func (f *FuncExpr) String() string {
@@ -385,11 +449,13 @@ func (f *FuncExpr) String() string {
 		}
 		sb.WriteString(arg.String())
 	}
+	sb.WriteString(f.Unit.usingClause())
 	sb.WriteByte(')')
 	return sb.String()
 }
 
 type CaseValueWhen struct {
+	Pos
 	Value Expr
 	Whens []*When
 	Else  Expr
@@ -424,6 +490,7 @@ func (w *When) String() string {
 }
 
 type Timestamp struct {
+	Pos
 	WithTimezone bool
 	Value        Expr
 }
@@ -435,20 +502,76 @@ func (t *Timestamp) String() string {
 	return fmt.Sprintf("TIMESTAMP %s", t.Value)
 }
 
-type IntervalUnit time.Duration
+type Date struct {
+	Pos
+	Value Expr
+}
+
+func (d *Date) String() string {
+	return fmt.Sprintf("DATE %s", d.Value)
+}
+
+type Time struct {
+	Pos
+	Value Expr
+}
+
+func (t *Time) String() string {
+	return fmt.Sprintf("TIME %s", t.Value)
+}
+
+type IntervalUnit int
 
 const (
-	IntervalUnitWeek        = IntervalUnit(7 * 24 * time.Hour)
-	IntervalUnitDay         = IntervalUnit(24 * time.Hour)
-	IntervalUnitHour        = IntervalUnit(time.Hour)
-	IntervalUnitMinute      = IntervalUnit(time.Minute)
-	IntervalUnitSecond      = IntervalUnit(time.Second)
-	IntervalUnitMillisecond = IntervalUnit(time.Millisecond)
-	IntervalUnitMicrosecond = IntervalUnit(time.Microsecond)
+	IntervalUnitYear IntervalUnit = iota + 1
+	IntervalUnitQuarter
+	IntervalUnitMonth
+	IntervalUnitWeek
+	IntervalUnitDay
+	IntervalUnitHour
+	IntervalUnitMinute
+	IntervalUnitSecond
+	IntervalUnitMillisecond
+	IntervalUnitMicrosecond
 )
 
+// Interval returns the length of one unit. Years, quarters and months are
+// counted in calendar months, and weeks in calendar days.
+func (u IntervalUnit) Interval() constant.Interval {
+	switch u {
+	case IntervalUnitYear:
+		return constant.Interval{Months: 12}
+	case IntervalUnitQuarter:
+		return constant.Interval{Months: 3}
+	case IntervalUnitMonth:
+		return constant.Interval{Months: 1}
+	case IntervalUnitWeek:
+		return constant.Interval{Days: 7}
+	case IntervalUnitDay:
+		return constant.Interval{Days: 1}
+	case IntervalUnitHour:
+		return constant.DurationInterval(time.Hour)
+	case IntervalUnitMinute:
+		return constant.DurationInterval(time.Minute)
+	case IntervalUnitSecond:
+		return constant.DurationInterval(time.Second)
+	case IntervalUnitMillisecond:
+		return constant.DurationInterval(time.Millisecond)
+	case IntervalUnitMicrosecond:
+		return constant.DurationInterval(time.Microsecond)
+	default:
+		return constant.Interval{}
+	}
+}
+
 func (u IntervalUnit) String() string {
 	switch u {
+	case IntervalUnitYear:
+		return "YEAR"
+	case IntervalUnitQuarter:
+		return "QUARTER"
+	case IntervalUnitMonth:
+		return "MONTH"
 	case IntervalUnitWeek:
 		return "WEEK"
 	case IntervalUnitDay:
@@ -469,6 +592,7 @@ func (u IntervalUnit) String() string {
 }
 
 type Interval struct {
+	Pos
 	Unit  IntervalUnit
 	Value Expr
 }
@@ -478,6 +602,7 @@ func (i *Interval) String() string {
 }
 
 type Array struct {
+	Pos
 	Elems []Expr
 }
 
@@ -495,6 +620,7 @@ func (a *Array) String() string {
 }
 
 type Subscript struct {
+	Pos
 	Base  Expr
 	Index Expr
 }
@@ -503,6 +629,25 @@ func (s *Subscript) String() string {
 	return fmt.Sprintf("%s[%s]", s.Base, s.Index)
 }
 
+// Slice is an `array[from:to]` expression. From and To are nil if omitted.
+type Slice struct {
+	Pos
+	Base Expr
+	From Expr
+	To   Expr
+}
+
+func (s *Slice) String() string {
+	var from, to string
+	if s.From != nil {
+		from = s.From.String()
+	}
+	if s.To != nil {
+		to = s.To.String()
+	}
+	return fmt.Sprintf("%s[%s:%s]", s.Base, from, to)
+}
+
 // StringUnit specifies how to index a (byte) string.
 type StringUnit int
 
@@ -513,7 +658,19 @@ const (
 	StringUnitOctets
 )
 
+func (u StringUnit) usingClause() string {
+	switch u {
+	case StringUnitCharacters:
+		return " USING CHARACTERS"
+	case StringUnitOctets:
+		return " USING OCTETS"
+	default:
+		return ""
+	}
+}
+
 type Substring struct {
+	Pos
 	Input Expr
 	From  Expr
 	For   Expr
@@ -543,6 +700,7 @@ func (s *Substring) String() string {
 }
 
 type Overlay struct {
+	Pos
 	Input   Expr
 	Placing Expr
 	From    Expr
@@ -571,3 +729,136 @@ func (o *Overlay) String() string {
 	sb.WriteByte(')')
 	return sb.String()
 }
+
+type Position struct {
+	Pos
+	Needle   Expr
+	Haystack Expr
+	Unit     StringUnit
+}
+
+func (p *Position) String() string {
+	return fmt.Sprintf("position(%s IN %s%s)", p.Needle, p.Haystack, p.Unit.usingClause())
+}
+
+type Extract struct {
+	Pos
+	// Field is the lower-cased name of the extracted field, e.g. "year".
+	Field string
+	Value Expr
+}
+
+func (e *Extract) String() string {
+	return fmt.Sprintf("extract(%s FROM %s)", strings.ToUpper(e.Field), e.Value)
+}
+
+type AtTimeZone struct {
+	Pos
+	Value Expr
+	Zone  Expr
+}
+
+func (a *AtTimeZone) String() string {
+	return fmt.Sprintf("%s AT TIME ZONE %s", a.Value, a.Zone)
+}
+
+// Cast is a `CAST(value AS type)` or `value::type` expression.
+type Cast struct {
+	Pos
+	Value Expr
+	Type  constant.Type
+}
+
+func (c *Cast) String() string {
+	return fmt.Sprintf("CAST(%s AS %s)", c.Value, c.Type)
+}
+
+// Lambda is a `x -> body` or `(x, y) -> body` expression. Lambdas are only
+// allowed as arguments of higher-order functions.
+type Lambda struct {
+	Pos
+	Params []Name
+	Body   Expr
+}
+
+func (l *Lambda) String() string {
+	if len(l.Params) == 1 {
+		return fmt.Sprintf("%s -> %s", l.Params[0], l.Body)
+	}
+	names := make([]string, 0, len(l.Params))
+	for _, param := range l.Params {
+		names = append(names, param.String())
+	}
+	return fmt.Sprintf("(%s) -> %s", strings.Join(names, ", "), l.Body)
+}
+
+// LambdaParam is a reference to a parameter of an enclosing lambda.
+type LambdaParam struct {
+	Pos
+	Name Name
+}
+
+func (lp *LambdaParam) String() string {
+	return lp.Name.String()
+}
+
+// Inspect traverses an expression in the order its operands are evaluated.
+// It calls f(expr) and, if f returns true, inspects each non-nil operand of
+// expr.
+func Inspect(expr Expr, f func(Expr) bool) {
+	if !f(expr) {
+		return
+	}
+	var operands []Expr
+	switch expr := expr.(type) {
+	case *SetVariable:
+		operands = []Expr{expr.Value}
+	case *UnaryExpr:
+		operands = []Expr{expr.Expr}
+	case *BinaryExpr:
+		operands = []Expr{expr.Left, expr.Right}
+	case *ParenExpr:
+		operands = []Expr{expr.Expr}
+	case *FuncExpr:
+		operands = expr.Args
+	case *CaseValueWhen:
+		operands = append(operands, expr.Value)
+		for _, when := range expr.Whens {
+			operands = append(operands, when.Cond, when.Then)
+		}
+		operands = append(operands, expr.Else)
+	case *Timestamp:
+		operands = []Expr{expr.Value}
+	case *Date:
+		operands = []Expr{expr.Value}
+	case *Time:
+		operands = []Expr{expr.Value}
+	case *Interval:
+		operands = []Expr{expr.Value}
+	case *Array:
+		operands = expr.Elems
+	case *Subscript:
+		operands = []Expr{expr.Base, expr.Index}
+	case *Slice:
+		operands = []Expr{expr.Base, expr.From, expr.To}
+	case *Substring:
+		operands = []Expr{expr.Input, expr.From, expr.For}
+	case *Overlay:
+		operands = []Expr{expr.Input, expr.Placing, expr.From, expr.For}
+	case *Position:
+		operands = []Expr{expr.Needle, expr.Haystack}
+	case *Extract:
+		operands = []Expr{expr.Value}
+	case *AtTimeZone:
+		operands = []Expr{expr.Value, expr.Zone}
+	case *Cast:
+		operands = []Expr{expr.Value}
+	case *Lambda:
+		operands = []Expr{expr.Body}
+	}
+	for _, operand := range operands {
+		if operand != nil {
+			Inspect(operand, f)
+		}
+	}
+}
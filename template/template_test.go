@@ -83,3 +83,24 @@ func TestQName(t *testing.T) {
 		})
 	}
 }
+
+func TestInspect(t *testing.T) {
+	expr, err := template.ParseExpr("@a := f(rownum, CASE WHEN @b THEN 1 END) + g(x -> x)")
+	require.NoError(t, err)
+	var visited []string
+	template.Inspect(expr, func(expr template.Expr) bool {
+		visited = append(visited, expr.String())
+		fn, ok := expr.(*template.FuncExpr)
+		return !ok || fn.Name.String() != "g"
+	})
+	require.Equal(t, []string{
+		"@`a` := f(rownum, CASE WHEN @`b` THEN 1 END) + g(x -> x)",
+		"f(rownum, CASE WHEN @`b` THEN 1 END) + g(x -> x)",
+		"f(rownum, CASE WHEN @`b` THEN 1 END)",
+		"rownum",
+		"CASE WHEN @`b` THEN 1 END",
+		"@`b`",
+		"1",
+		"g(x -> x)",
+	}, visited)
+}
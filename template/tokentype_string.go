@@ -27,71 +27,82 @@ func _() {
 	_ = x[tokenPeriod-16]
 	_ = x[tokenAt-17]
 	_ = x[tokenAssign-18]
-	_ = x[tokenLT-19]
-	_ = x[tokenLE-20]
-	_ = x[tokenEQ-21]
-	_ = x[tokenNE-22]
-	_ = x[tokenGT-23]
-	_ = x[tokenGE-24]
-	_ = x[tokenConcat-25]
-	_ = x[tokenAdd-26]
-	_ = x[tokenSub-27]
-	_ = x[tokenMul-28]
-	_ = x[tokenFloatDiv-29]
-	_ = x[tokenBitAnd-30]
-	_ = x[tokenBitOr-31]
-	_ = x[tokenBitXor-32]
-	_ = x[tokenBitNot-33]
-	_ = x[tokenSemicolon-34]
-	_ = x[tokenCreate-35]
-	_ = x[tokenTable-36]
-	_ = x[tokenOr-37]
-	_ = x[tokenAnd-38]
-	_ = x[tokenNot-39]
-	_ = x[tokenIs-40]
-	_ = x[tokenRowNum-41]
-	_ = x[tokenSubRowNum-42]
-	_ = x[tokenNull-43]
-	_ = x[tokenTrue-44]
-	_ = x[tokenFalse-45]
-	_ = x[tokenCase-46]
-	_ = x[tokenWhen-47]
-	_ = x[tokenThen-48]
-	_ = x[tokenElse-49]
-	_ = x[tokenEnd-50]
-	_ = x[tokenTimestamp-51]
-	_ = x[tokenInterval-52]
-	_ = x[tokenWeek-53]
-	_ = x[tokenDay-54]
-	_ = x[tokenHour-55]
-	_ = x[tokenMinute-56]
-	_ = x[tokenSecond-57]
-	_ = x[tokenMillisecond-58]
-	_ = x[tokenMicrosecond-59]
-	_ = x[tokenWith-60]
-	_ = x[tokenTime-61]
-	_ = x[tokenZone-62]
-	_ = x[tokenSubstring-63]
-	_ = x[tokenFrom-64]
-	_ = x[tokenFor-65]
-	_ = x[tokenUsing-66]
-	_ = x[tokenCharacters-67]
-	_ = x[tokenOctets-68]
-	_ = x[tokenOverlay-69]
-	_ = x[tokenPlacing-70]
-	_ = x[tokenCurrentTimestamp-71]
-	_ = x[tokenArray-72]
-	_ = x[tokenEach-73]
-	_ = x[tokenRow-74]
-	_ = x[tokenOf-75]
-	_ = x[tokenGenerate-76]
-	_ = x[tokenRows-77]
-	_ = x[tokenX-78]
+	_ = x[tokenTypeCast-19]
+	_ = x[tokenArrow-20]
+	_ = x[tokenLT-21]
+	_ = x[tokenLE-22]
+	_ = x[tokenEQ-23]
+	_ = x[tokenNE-24]
+	_ = x[tokenGT-25]
+	_ = x[tokenGE-26]
+	_ = x[tokenConcat-27]
+	_ = x[tokenAdd-28]
+	_ = x[tokenSub-29]
+	_ = x[tokenMul-30]
+	_ = x[tokenFloatDiv-31]
+	_ = x[tokenBitAnd-32]
+	_ = x[tokenBitOr-33]
+	_ = x[tokenBitXor-34]
+	_ = x[tokenBitNot-35]
+	_ = x[tokenSemicolon-36]
+	_ = x[tokenCreate-37]
+	_ = x[tokenTable-38]
+	_ = x[tokenOr-39]
+	_ = x[tokenAnd-40]
+	_ = x[tokenNot-41]
+	_ = x[tokenIs-42]
+	_ = x[tokenRowNum-43]
+	_ = x[tokenSubRowNum-44]
+	_ = x[tokenNull-45]
+	_ = x[tokenTrue-46]
+	_ = x[tokenFalse-47]
+	_ = x[tokenCase-48]
+	_ = x[tokenWhen-49]
+	_ = x[tokenThen-50]
+	_ = x[tokenElse-51]
+	_ = x[tokenEnd-52]
+	_ = x[tokenTimestamp-53]
+	_ = x[tokenDate-54]
+	_ = x[tokenInterval-55]
+	_ = x[tokenYear-56]
+	_ = x[tokenQuarter-57]
+	_ = x[tokenMonth-58]
+	_ = x[tokenWeek-59]
+	_ = x[tokenDay-60]
+	_ = x[tokenHour-61]
+	_ = x[tokenMinute-62]
+	_ = x[tokenSecond-63]
+	_ = x[tokenMillisecond-64]
+	_ = x[tokenMicrosecond-65]
+	_ = x[tokenWith-66]
+	_ = x[tokenTime-67]
+	_ = x[tokenZone-68]
+	_ = x[tokenSubstring-69]
+	_ = x[tokenFrom-70]
+	_ = x[tokenFor-71]
+	_ = x[tokenUsing-72]
+	_ = x[tokenCharacters-73]
+	_ = x[tokenOctets-74]
+	_ = x[tokenOverlay-75]
+	_ = x[tokenPlacing-76]
+	_ = x[tokenCurrentTimestamp-77]
+	_ = x[tokenArray-78]
+	_ = x[tokenEach-79]
+	_ = x[tokenRow-80]
+	_ = x[tokenOf-81]
+	_ = x[tokenGenerate-82]
+	_ = x[tokenRows-83]
+	_ = x[tokenX-84]
+	_ = x[tokenPosition-85]
+	_ = x[tokenIn-86]
+	_ = x[tokenExtract-87]
+	_ = x[tokenAtKeyword-88]
+	_ = x[tokenCast-89]
 }
 
-const _tokenType_name = "ErrorEOFCharCommentIdentStringNumberLeftDelimRightDelimLeftParenRightParenLeftBrackRightBrackLeftBraceRightBraceCommaPeriodAtAssignLTLEEQNEGTGEConcatAddSubMulFloatDivBitAndBitOrBitXorBitNotSemicolonCreateTableOrAndNotIsRowNumSubRowNumNullTrueFalseCaseWhenThenElseEndTimestampIntervalWeekDayHourMinuteSecondMillisecondMicrosecondWithTimeZoneSubstringFromForUsingCharactersOctetsOverlayPlacingCurrentTimestampArrayEachRowOfGenerateRowsX"
+const _tokenType_name = "ErrorEOFCharCommentIdentStringNumberLeftDelimRightDelimLeftParenRightParenLeftBrackRightBrackLeftBraceRightBraceCommaPeriodAtAssignTypeCastArrowLTLEEQNEGTGEConcatAddSubMulFloatDivBitAndBitOrBitXorBitNotSemicolonCreateTableOrAndNotIsRowNumSubRowNumNullTrueFalseCaseWhenThenElseEndTimestampDateIntervalYearQuarterMonthWeekDayHourMinuteSecondMillisecondMicrosecondWithTimeZoneSubstringFromForUsingCharactersOctetsOverlayPlacingCurrentTimestampArrayEachRowOfGenerateRowsXPositionInExtractAtKeywordCast"
 
-var _tokenType_index = [...]uint16{0, 5, 8, 12, 19, 24, 30, 36, 45, 55, 64, 74, 83, 93, 102, 112, 117, 123, 125, 131, 133, 135, 137, 139, 141, 143, 149, 152, 155, 158, 166, 172, 177, 183, 189, 198, 204, 209, 211, 214, 217, 219, 225, 234, 238, 242, 247, 251, 255, 259, 263, 266, 275, 283, 287, 290, 294, 300, 306, 317, 328, 332, 336, 340, 349, 353, 356, 361, 371, 377, 384, 391, 407, 412, 416, 419, 421, 429, 433, 434}
+var _tokenType_index = [...]uint16{0, 5, 8, 12, 19, 24, 30, 36, 45, 55, 64, 74, 83, 93, 102, 112, 117, 123, 125, 131, 139, 144, 146, 148, 150, 152, 154, 156, 162, 165, 168, 171, 179, 185, 190, 196, 202, 211, 217, 222, 224, 227, 230, 232, 238, 247, 251, 255, 260, 264, 268, 272, 276, 279, 288, 292, 300, 304, 311, 316, 320, 323, 327, 333, 339, 350, 361, 365, 369, 373, 382, 386, 389, 394, 404, 410, 417, 424, 440, 445, 449, 452, 454, 462, 466, 467, 475, 477, 484, 493, 497}
 
 func (i tokenType) String() string {
 	if i < 0 || i >= tokenType(len(_tokenType_index)-1) {
@@ -0,0 +1,205 @@
+package dbgen
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gozssky/dbgen/constant"
+	"github.com/gozssky/dbgen/template"
+)
+
+// TypeError is an argument of a function whose possible kinds are never
+// accepted by the function.
+type TypeError struct {
+	Expr template.Expr
+	Msg  string
+}
+
+func (e *TypeError) Error() string {
+	pos := e.Expr.Position()
+	return fmt.Sprintf("type error on line %d at column %d: %s (in %s)", pos.Line, pos.Column, e.Msg, e.Expr)
+}
+
+// CheckTypes infers the possible kinds of the expressions of a template,
+// using the signatures of the functions, and returns all the type errors
+// joined. The kinds of a variable are those of all its assignments, plus
+// NULL before the first one.
+//
+// Only the arguments which can never have an accepted kind are reported,
+// so that a template checked without errors may still fail at runtime.
+func CheckTypes(t *template.Template) error {
+	c := &typeChecker{vars: make(map[string]constant.KindSet)}
+	// The kinds of the variables only grow, until they are stable.
+	for {
+		c.changed = false
+		c.checkTemplate(t)
+		if !c.changed {
+			break
+		}
+	}
+	c.report = true
+	c.checkTemplate(t)
+	return errors.Join(c.errs...)
+}
+
+type typeChecker struct {
+	// vars are the kinds assigned to each variable.
+	vars map[string]constant.KindSet
+	// changed reports whether vars changed during the current pass.
+	changed bool
+	// report enables recording errors, in the last pass.
+	report bool
+	errs   []error
+}
+
+var nullKinds = constant.KindSetOf(constant.KindNull)
+
+func (c *typeChecker) checkTemplate(t *template.Template) {
+	for _, expr := range t.GlobalExprs {
+		c.kinds(expr)
+	}
+	for _, table := range t.Tables {
+		for _, col := range table.Columns {
+			c.kinds(col.Expr)
+		}
+		for _, d := range table.Derived {
+			c.expect(d.B, "the number of derived rows", intKinds)
+		}
+	}
+}
+
+func (c *typeChecker) errorf(expr template.Expr, format string, args ...interface{}) {
+	if c.report {
+		c.errs = append(c.errs, &TypeError{Expr: expr, Msg: fmt.Sprintf(format, args...)})
+	}
+}
+
+// expect checks that an expression may have one of the wanted kinds.
+func (c *typeChecker) expect(expr template.Expr, what string, want constant.KindSet) {
+	got := c.kinds(expr).Without(constant.KindNull)
+	if got != 0 && got&want == 0 {
+		c.errorf(expr, "%s must be %s, got %s", what, want, got)
+	}
+}
+
+// kinds returns the possible kinds of the value of an expression.
+func (c *typeChecker) kinds(expr template.Expr) constant.KindSet {
+	switch expr := expr.(type) {
+	case *template.RowNum, *template.SubRowNum:
+		return intKinds
+	case *template.CurrentTimestamp:
+		return constant.KindSetOf(constant.KindTimestamp)
+	case *template.Constant:
+		return constant.KindSetOf(expr.Value.Kind())
+	case *template.GetVariable:
+		return c.vars[expr.Name] | nullKinds
+	case *template.SetVariable:
+		kinds := c.kinds(expr.Value)
+		if old := c.vars[expr.Name]; old|kinds != old {
+			c.vars[expr.Name] = old | kinds
+			c.changed = true
+		}
+		return kinds
+	case *template.UnaryExpr:
+		fn, ok := UnaryFuncs[expr.Op]
+		if !ok {
+			return constant.AnyKind
+		}
+		return c.call(fmt.Sprintf("operator %s", expr.Op), fn, expr.Expr)
+	case *template.BinaryExpr:
+		fn, ok := BinaryFuncs[expr.Op]
+		if !ok {
+			// The result of `;` is its right operand.
+			c.kinds(expr.Left)
+			return c.kinds(expr.Right)
+		}
+		return c.call(fmt.Sprintf("operator %s", expr.Op), fn, expr.Left, expr.Right)
+	case *template.ParenExpr:
+		return c.kinds(expr.Expr)
+	case *template.FuncExpr:
+		fn, ok := GenericFuncs[expr.Name.UniqueName()]
+		if !ok || fn.NumArgs() >= 0 && len(expr.Args) != fn.NumArgs() {
+			// Lambda functions and errors reported by CompileExpr.
+			for _, arg := range expr.Args {
+				c.kinds(arg)
+			}
+			return constant.AnyKind
+		}
+		return c.call(expr.Name.String(), fn, expr.Args...)
+	case *template.CaseValueWhen:
+		if expr.Value != nil {
+			c.kinds(expr.Value)
+		}
+		var kinds constant.KindSet
+		for _, when := range expr.Whens {
+			if expr.Value != nil {
+				c.kinds(when.Cond)
+			} else {
+				c.expect(when.Cond, "the condition of CASE", boolKinds)
+			}
+			kinds |= c.kinds(when.Then)
+		}
+		if expr.Else == nil {
+			return kinds | nullKinds
+		}
+		return kinds | c.kinds(expr.Else)
+	case *template.Timestamp:
+		if expr.WithTimezone {
+			return c.call("TIMESTAMP WITH TIME ZONE", TimestampWithTimeZoneFunc{}, expr.Value)
+		}
+		return c.call("TIMESTAMP", TimestampFunc{}, expr.Value)
+	case *template.Date:
+		return c.call("DATE", DateFunc{}, expr.Value)
+	case *template.Time:
+		return c.call("TIME", TimeFunc{}, expr.Value)
+	case *template.Interval:
+		c.expect(expr.Value, "the value of INTERVAL", numericKinds)
+		return constant.KindSetOf(constant.KindInterval)
+	case *template.Array:
+		return c.call("ARRAY", ArrayFunc{}, expr.Elems...)
+	case *template.Subscript:
+		return c.call("subscript", SubscriptFunc{}, expr.Base, expr.Index)
+	case *template.Slice:
+		return c.call("slice", ArraySliceFunc{}, expr.Base, expr.From, expr.To)
+	case *template.Substring:
+		return c.call("SUBSTRING", SubstringFunc{Unit: expr.Unit}, expr.Input, expr.From, expr.For)
+	case *template.Overlay:
+		return c.call("OVERLAY", OverlayFunc{Unit: expr.Unit}, expr.Input, expr.Placing, expr.From, expr.For)
+	case *template.Position:
+		return c.call("POSITION", PositionFunc{Unit: expr.Unit}, expr.Needle, expr.Haystack)
+	case *template.Extract:
+		c.kinds(expr.Value)
+		return constant.AnyKind
+	case *template.AtTimeZone:
+		return c.call("AT TIME ZONE", AtTimeZoneFunc{}, expr.Value, expr.Zone)
+	case *template.Cast:
+		return c.call("CAST", CastFunc{Type: expr.Type}, expr.Value)
+	case *template.Lambda:
+		c.kinds(expr.Body)
+		return constant.AnyKind
+	default:
+		return constant.AnyKind
+	}
+}
+
+// call checks the arguments of a function against its signature and
+// returns the possible kinds of its result. Missing optional arguments are
+// nil.
+func (c *typeChecker) call(name string, fn Function, args ...template.Expr) constant.KindSet {
+	sig := fn.Signature()
+	for i, arg := range args {
+		if arg == nil {
+			continue
+		}
+		if len(sig.Args) == 0 {
+			c.kinds(arg)
+			continue
+		}
+		want := sig.Args[len(sig.Args)-1]
+		if i < len(sig.Args) {
+			want = sig.Args[i]
+		}
+		c.expect(arg, fmt.Sprintf("argument %d of %s", i+1, name), want)
+	}
+	return sig.Result | nullKinds
+}
@@ -0,0 +1,55 @@
+package dbgen_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gozssky/dbgen"
+	"github.com/gozssky/dbgen/template"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckTypes(t *testing.T) {
+	testCases := []struct {
+		input string
+		errs  []string
+	}{
+		{`CREATE TABLE t (a INT {{ rownum + 1 }}, b TEXT {{ lower('A' || rownum) }});`, nil},
+		{`CREATE TABLE t (a INT {{ 'abc' + 1 }});`, []string{
+			"type error on line 1 at column 26: argument 1 of operator + must be Int|Float|Decimal|Timestamp|Date|Time|Interval, got Bytes (in 'abc')",
+		}},
+		{"CREATE TABLE t (\n  a INT {{ rand.range('a', 5) }},\n  b INT {{ NOT rownum }}\n);", []string{
+			"type error on line 2 at column 23: argument 1 of rand.range must be Int, got Bytes (in 'a')",
+			"type error on line 3 at column 16: argument 1 of operator NOT must be Bool, got Int (in rownum)",
+		}},
+		// NULL is accepted everywhere, and unknown kinds are not errors.
+		{`CREATE TABLE t (a INT {{ NULL + 1 }}, b INT {{ abs(json('1')) }});`, nil},
+		// The kinds of a variable come from all its assignments.
+		{`{{ @x := 'a' }} CREATE TABLE t (a INT {{ @x + 1 }}, b INT {{ @y := 1 }});`, []string{
+			"type error on line 1 at column 42: argument 1 of operator + must be Int|Float|Decimal|Timestamp|Date|Time|Interval, got Bytes (in @`x`)",
+		}},
+		{`{{ @x := 'a' }} CREATE TABLE t (a INT {{ @x + 1 }}, b INT {{ @x := 1 }});`, nil},
+		{`CREATE TABLE t (a INT {{ CASE WHEN rownum THEN 1 END }});`, []string{
+			"type error on line 1 at column 36: the condition of CASE must be Bool, got Int (in rownum)",
+		}},
+		{`CREATE TABLE t (a INT {{ upper(rownum::text) || rownum::int }});`, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			tmpl, err := template.Parse(tc.input)
+			require.NoError(t, err)
+			err = dbgen.CheckTypes(tmpl)
+			if tc.errs == nil {
+				require.NoError(t, err)
+				return
+			}
+			var msgs []string
+			for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+				var typeErr *dbgen.TypeError
+				require.True(t, errors.As(err, &typeErr))
+				msgs = append(msgs, typeErr.Error())
+			}
+			require.Equal(t, tc.errs, msgs)
+		})
+	}
+}
@@ -2,6 +2,9 @@ package dbgen
 
 import (
 	"bufio"
+	"io"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gozssky/dbgen/constant"
 	"github.com/gozssky/dbgen/template"
@@ -25,24 +28,83 @@ type Writer interface {
 	WriteRowGroupTrailer() error
 }
 
+// CSVWriter writes rows as CSV with the quoting of encoding/csv. NULL is
+// written as `\N`.
 type CSVWriter struct {
 	bufw *bufio.Writer
+	// buf is the text of the value being written.
+	buf []byte
 }
 
-func (w *CSVWriter) WriteValue(value constant.Value) error {
-	panic("unimplemented")
+// NewCSVWriter returns a CSVWriter buffering its output to w. Flush must be
+// called once all the rows are written.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{bufw: bufio.NewWriter(w)}
 }
 
-func (w *CSVWriter) WriteFileHeader(table *Table) error {
-	panic("unimplemented")
+func (w *CSVWriter) WriteValue(value constant.Value) error {
+	if value == constant.Null {
+		_, err := w.bufw.WriteString(`\N`)
+		return err
+	}
+	w.buf = append(w.buf[:0], value.String()...)
+	return w.writeField()
+}
+
+// WriteDatum writes a single datum like WriteValue, without boxing it.
+func (w *CSVWriter) WriteDatum(datum constant.Datum) error {
+	if datum.IsNull() {
+		_, err := w.bufw.WriteString(`\N`)
+		return err
+	}
+	w.buf = datum.AppendText(w.buf[:0])
+	return w.writeField()
+}
+
+// writeField writes buf, quoting it if needed.
+func (w *CSVWriter) writeField() error {
+	if !csvFieldNeedsQuotes(w.buf) {
+		_, err := w.bufw.Write(w.buf)
+		return err
+	}
+	_ = w.bufw.WriteByte('"')
+	for _, c := range w.buf {
+		if c == '"' {
+			_ = w.bufw.WriteByte('"')
+		}
+		_ = w.bufw.WriteByte(c)
+	}
+	return w.bufw.WriteByte('"')
+}
+
+// csvFieldNeedsQuotes reports whether a field must be quoted, following
+// encoding/csv.
+func csvFieldNeedsQuotes(field []byte) bool {
+	if len(field) == 0 {
+		return false
+	}
+	if string(field) == `\.` {
+		return true
+	}
+	for _, c := range field {
+		if c == '\n' || c == '\r' || c == '"' || c == ',' {
+			return true
+		}
+	}
+	r, _ := utf8.DecodeRune(field)
+	return unicode.IsSpace(r)
+}
+
+func (w *CSVWriter) WriteFileHeader(_ *Table) error {
+	return nil
 }
 
 func (w *CSVWriter) WriteRowGroupHeader(_ *Table) error {
-	panic("unimplemented")
+	return nil
 }
 
 func (w *CSVWriter) WriteValueHeader(_ template.Name) error {
-	panic("unimplemented")
+	return nil
 }
 
 func (w *CSVWriter) WriteValueSeparator() error {
@@ -54,7 +116,12 @@ func (w *CSVWriter) WriteRowSeparator() error {
 }
 
 func (w *CSVWriter) WriteRowGroupTrailer() error {
-	panic("unimplemented")
+	return nil
+}
+
+// Flush writes the buffered rows.
+func (w *CSVWriter) Flush() error {
+	return w.bufw.Flush()
 }
 
 type ParquetWriter struct {
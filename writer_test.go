@@ -0,0 +1,52 @@
+package dbgen_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/gozssky/dbgen"
+	"github.com/gozssky/dbgen/constant"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVWriter(t *testing.T) {
+	values := []constant.Value{
+		constant.MakeInt64(-1),
+		constant.MakeFloat(0.5),
+		constant.Null,
+		constant.MakeBytes([]byte("")),
+		constant.MakeBytes([]byte("plain")),
+		constant.MakeBytes([]byte(`a,"b"`)),
+		constant.MakeBytes([]byte("line\nbreak")),
+		constant.MakeBytes([]byte(" leading space")),
+		constant.MakeBytes([]byte(`\.`)),
+	}
+	var expected bytes.Buffer
+	w := csv.NewWriter(&expected)
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = value.String()
+	}
+	record[2] = `\N`
+	require.NoError(t, w.Write(record))
+	w.Flush()
+
+	for _, datums := range []bool{false, true} {
+		var actual bytes.Buffer
+		cw := dbgen.NewCSVWriter(&actual)
+		for i, value := range values {
+			if i > 0 {
+				require.NoError(t, cw.WriteValueSeparator())
+			}
+			if datums {
+				require.NoError(t, cw.WriteDatum(constant.DatumOf(value)))
+			} else {
+				require.NoError(t, cw.WriteValue(value))
+			}
+		}
+		require.NoError(t, cw.WriteRowSeparator())
+		require.NoError(t, cw.Flush())
+		require.Equal(t, expected.String(), actual.String(), datums)
+	}
+}